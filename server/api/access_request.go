@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/dto"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AccessRequestApi struct{}
+
+// AccessRequestPagingEndpoint type=mine 查询自己提交的申请，type=approve 查询自己可以审批的申请
+func (api AccessRequestApi) AccessRequestPagingEndpoint(c echo.Context) error {
+	pageIndex, _ := strconv.Atoi(c.QueryParam("pageIndex"))
+	pageSize, _ := strconv.Atoi(c.QueryParam("pageSize"))
+	status := c.QueryParam("status")
+
+	account, _ := GetCurrentAccount(c)
+	var userId, ownerId string
+	if c.QueryParam("type") == "approve" {
+		isMember, err := service.AccessRequestService.IsApproverGroupMember(account.ID)
+		if err != nil {
+			return err
+		}
+		if constant.TypeAdmin != account.Type && !isMember {
+			ownerId = account.ID
+		}
+	} else {
+		userId = account.ID
+	}
+
+	items, total, err := repository.AccessRequestRepository.Find(context.TODO(), pageIndex, pageSize, userId, ownerId, status)
+	if err != nil {
+		return err
+	}
+
+	return Success(c, Map{
+		"total": total,
+		"items": items,
+	})
+}
+
+// AccessRequestAssetsEndpoint 可以申请访问的资产，只返回基本信息
+func (api AccessRequestApi) AccessRequestAssetsEndpoint(c echo.Context) error {
+	account, _ := GetCurrentAccount(c)
+	assets, err := repository.AssetRepository.FindAll(context.TODO())
+	if err != nil {
+		return err
+	}
+	items := make([]Map, 0)
+	for _, asset := range assets {
+		if asset.Owner == account.ID {
+			continue
+		}
+		items = append(items, Map{
+			"id":       asset.ID,
+			"name":     asset.Name,
+			"protocol": asset.Protocol,
+		})
+	}
+	return Success(c, items)
+}
+
+func (api AccessRequestApi) AccessRequestCreateEndpoint(c echo.Context) error {
+	var item dto.AccessRequest
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+
+	account, _ := GetCurrentAccount(c)
+	request, err := service.AccessRequestService.Create(account, item, c.RealIP())
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, request)
+}
+
+func (api AccessRequestApi) AccessRequestApproveEndpoint(c echo.Context) error {
+	return api.review(c, service.AccessRequestService.Approve)
+}
+
+func (api AccessRequestApi) AccessRequestDenyEndpoint(c echo.Context) error {
+	return api.review(c, service.AccessRequestService.Deny)
+}
+
+func (api AccessRequestApi) AccessRequestRevokeEndpoint(c echo.Context) error {
+	return api.review(c, service.AccessRequestService.Revoke)
+}
+
+func (api AccessRequestApi) review(c echo.Context, action func(*model.User, string, dto.AccessRequestReview, string) error) error {
+	id := c.Param("id")
+	var item dto.AccessRequestReview
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+
+	account, _ := GetCurrentAccount(c)
+	if err := action(account, id, item, c.RealIP()); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, "")
+}
+
+func (api AccessRequestApi) AccessRequestCancelEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	account, _ := GetCurrentAccount(c)
+	if err := service.AccessRequestService.Cancel(account, id, c.RealIP()); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, "")
+}
+
+// AccessRequestLogsEndpoint 申请人及审批人可以查看申请的审计记录
+func (api AccessRequestApi) AccessRequestLogsEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	request, err := repository.AccessRequestRepository.FindById(context.TODO(), id)
+	if err != nil {
+		return err
+	}
+
+	account, _ := GetCurrentAccount(c)
+	if request.UserId != account.ID {
+		ok, err := service.AccessRequestService.CanApprove(account, request)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return Fail(c, -1, constant.ErrAccessRequestForbidden.Error())
+		}
+	}
+
+	items, err := repository.AccessRequestLogRepository.FindByRequestId(context.TODO(), id)
+	if err != nil {
+		return err
+	}
+	return Success(c, items)
+}
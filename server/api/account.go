@@ -2,9 +2,13 @@ package api
 
 import (
 	"context"
-	"errors"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
 	"path"
 	"strconv"
+	"strings"
 
 	"next-terminal/server/config"
 	"next-terminal/server/constant"
@@ -17,7 +21,6 @@ import (
 	"next-terminal/server/utils"
 
 	"github.com/labstack/echo/v4"
-	"gorm.io/gorm"
 )
 
 type AccountApi struct{}
@@ -28,21 +31,14 @@ func (api AccountApi) LoginEndpoint(c echo.Context) error {
 		return err
 	}
 
-	// 存储登录失败次数信息
-	loginFailCountKey := c.RealIP() + loginAccount.Username
-	v, ok := cache.LoginFailedKeyManager.Get(loginFailCountKey)
-	if !ok {
-		v = 1
-	}
-	count := v.(int)
-	if count >= 5 {
-		return Fail(c, -1, "登录失败次数过多，请等待5分钟后再试")
+	// 连续登录失败次数过多时账户会被锁定
+	if err := service.LoginLockService.Check(loginAccount.Username); err != nil {
+		return Fail(c, -1, err.Error())
 	}
 
-	user, err := repository.UserRepository.FindByUsername(context.TODO(), loginAccount.Username)
+	user, err := service.UserService.Authenticate(loginAccount.Username, loginAccount.Password)
 	if err != nil {
-		count++
-		cache.LoginFailedKeyManager.Set(loginFailCountKey, count, cache.LoginLockExpiration)
+		count := service.LoginLockService.Failed(loginAccount.Username)
 		// 保存登录日志
 		if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), loginAccount.Username, false, loginAccount.Remember, "", "账号或密码不正确"); err != nil {
 			return err
@@ -54,18 +50,41 @@ func (api AccountApi) LoginEndpoint(c echo.Context) error {
 		return Fail(c, -1, "该账户已停用")
 	}
 
-	if err := utils.Encoder.Match([]byte(user.Password), []byte(loginAccount.Password)); err != nil {
-		count++
-		cache.LoginFailedKeyManager.Set(loginFailCountKey, count, cache.LoginLockExpiration)
-		// 保存登录日志
-		if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), loginAccount.Username, false, loginAccount.Remember, "", "账号或密码不正确"); err != nil {
+	// 登录策略限制了来源IP及时间段
+	if err := service.LoginPolicyService.Check(context.TODO(), user, c.RealIP()); err != nil {
+		if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), loginAccount.Username, false, loginAccount.Remember, "", err.Error()); err != nil {
 			return err
 		}
-		return FailWithData(c, -1, "您输入的账号或密码不正确", count)
+		return Fail(c, -1, err.Error())
+	}
+
+	// 密码已过期，需要修改密码后重新登录
+	if service.PasswordPolicyService.Expired(user) {
+		return Fail(c, 2, "您的密码已过期，请修改密码")
+	}
+
+	// 需要进行双因素认证，告知前端可以使用的认证方式
+	methods, _, err := service.MfaService.Methods(context.TODO(), user)
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	if len(methods) > 0 {
+		// 只能使用邮件验证码时直接发送
+		if len(methods) == 1 && methods[0] == constant.MfaEmail {
+			if err := service.MfaService.SendEmailCode(user); err != nil {
+				return Fail(c, -1, err.Error())
+			}
+		}
+		return FailWithData(c, 0, "", Map{
+			"totp":     utils.Contains(methods, constant.MfaTotp),
+			"webauthn": utils.Contains(methods, constant.MfaWebAuthn),
+			"email":    utils.Contains(methods, constant.MfaEmail),
+		})
 	}
 
-	if user.TOTPSecret != "" && user.TOTPSecret != "-" {
-		return Fail(c, 0, "")
+	// 同时在线的登录数限制
+	if err := service.ConcurrentLimitService.CheckLogin(user.Username); err != nil {
+		return Fail(c, -1, err.Error())
 	}
 
 	token, err := api.LoginSuccess(loginAccount, user)
@@ -97,6 +116,8 @@ func (api AccountApi) LoginSuccess(loginAccount dto.LoginAccount, user model.Use
 		cache.TokenManager.Set(token, authorization, cache.NotRememberExpiration)
 	}
 
+	// 登录成功后清空连续登录失败次数
+	service.LoginLockService.Reset(user.Username)
 	// 修改登录状态
 	err := repository.UserRepository.Update(context.TODO(), &model.User{Online: true, ID: user.ID})
 	return token, err
@@ -108,21 +129,14 @@ func (api AccountApi) LoginWithTotpEndpoint(c echo.Context) error {
 		return err
 	}
 
-	// 存储登录失败次数信息
-	loginFailCountKey := c.RealIP() + loginAccount.Username
-	v, ok := cache.LoginFailedKeyManager.Get(loginFailCountKey)
-	if !ok {
-		v = 1
-	}
-	count := v.(int)
-	if count >= 5 {
-		return Fail(c, -1, "登录失败次数过多，请等待5分钟后再试")
+	// 连续登录失败次数过多时账户会被锁定
+	if err := service.LoginLockService.Check(loginAccount.Username); err != nil {
+		return Fail(c, -1, err.Error())
 	}
 
-	user, err := repository.UserRepository.FindByUsername(context.TODO(), loginAccount.Username)
+	user, err := service.UserService.Authenticate(loginAccount.Username, loginAccount.Password)
 	if err != nil {
-		count++
-		cache.LoginFailedKeyManager.Set(loginFailCountKey, count, cache.LoginLockExpiration)
+		count := service.LoginLockService.Failed(loginAccount.Username)
 		// 保存登录日志
 		if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), loginAccount.Username, false, loginAccount.Remember, "", "账号或密码不正确"); err != nil {
 			return err
@@ -134,19 +148,35 @@ func (api AccountApi) LoginWithTotpEndpoint(c echo.Context) error {
 		return Fail(c, -1, "该账户已停用")
 	}
 
-	if err := utils.Encoder.Match([]byte(user.Password), []byte(loginAccount.Password)); err != nil {
-		count++
-		cache.LoginFailedKeyManager.Set(loginFailCountKey, count, cache.LoginLockExpiration)
-		// 保存登录日志
-		if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), loginAccount.Username, false, loginAccount.Remember, "", "账号或密码不正确"); err != nil {
+	// 登录策略限制了来源IP及时间段
+	if err := service.LoginPolicyService.Check(context.TODO(), user, c.RealIP()); err != nil {
+		if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), loginAccount.Username, false, loginAccount.Remember, "", err.Error()); err != nil {
 			return err
 		}
-		return FailWithData(c, -1, "您输入的账号或密码不正确", count)
+		return Fail(c, -1, err.Error())
+	}
+
+	// 密码已过期，需要修改密码后重新登录
+	if service.PasswordPolicyService.Expired(user) {
+		return Fail(c, 2, "您的密码已过期，请修改密码")
+	}
+
+	methods, _, err := service.MfaService.Methods(context.TODO(), user)
+	if err != nil {
+		return Fail(c, -1, err.Error())
 	}
 
-	if !totp.Validate(loginAccount.TOTP, user.TOTPSecret) {
-		count++
-		cache.LoginFailedKeyManager.Set(loginFailCountKey, count, cache.LoginLockExpiration)
+	if len(loginAccount.WebAuthn) > 0 && utils.Contains(methods, constant.MfaWebAuthn) {
+		if err := service.WebAuthnService.FinishLogin(context.TODO(), rootUrl(c), user, loginAccount.WebAuthn); err != nil {
+			count := service.LoginLockService.Failed(loginAccount.Username)
+			// 保存登录日志
+			if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), loginAccount.Username, false, loginAccount.Remember, "", "WebAuthn认证失败"); err != nil {
+				return err
+			}
+			return FailWithData(c, -1, "WebAuthn认证失败", count)
+		}
+	} else if !service.MfaService.Verify(user, methods, loginAccount.TOTP) {
+		count := service.LoginLockService.Failed(loginAccount.Username)
 		// 保存登录日志
 		if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), loginAccount.Username, false, loginAccount.Remember, "", "双因素认证授权码不正确"); err != nil {
 			return err
@@ -154,6 +184,11 @@ func (api AccountApi) LoginWithTotpEndpoint(c echo.Context) error {
 		return FailWithData(c, -1, "您输入双因素认证授权码不正确", count)
 	}
 
+	// 同时在线的登录数限制
+	if err := service.ConcurrentLimitService.CheckLogin(user.Username); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+
 	token, err := api.LoginSuccess(loginAccount, user)
 	if err != nil {
 		return err
@@ -166,6 +201,117 @@ func (api AccountApi) LoginWithTotpEndpoint(c echo.Context) error {
 	return Success(c, token)
 }
 
+// oidcStateCookie 保存发起单点登录时的 state
+const oidcStateCookie = "next-terminal-oidc-state"
+
+func (api AccountApi) OidcLoginEndpoint(c echo.Context) error {
+	redirectUrl := rootUrl(c) + "/login/oidc/callback"
+	authCodeURL, state, err := service.OidcService.AuthCodeURL(c.Request().Context(), redirectUrl)
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	// 将 state 绑定到发起登录的浏览器，回调时校验
+	c.SetCookie(&http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/login/oidc",
+		MaxAge:   int(cache.OidcStateExpiration.Seconds()),
+		Secure:   c.Scheme() == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.Redirect(http.StatusFound, authCodeURL)
+}
+
+func (api AccountApi) OidcCallbackEndpoint(c echo.Context) error {
+	if errorCode := c.QueryParam("error"); errorCode != "" {
+		return c.HTML(http.StatusUnauthorized, "单点登录失败: "+html.EscapeString(errorCode))
+	}
+
+	var browserState string
+	if cookie, err := c.Cookie(oidcStateCookie); err == nil {
+		browserState = cookie.Value
+	}
+	c.SetCookie(&http.Cookie{Name: oidcStateCookie, Path: "/login/oidc", MaxAge: -1, HttpOnly: true})
+
+	user, err := service.OidcService.Login(c.Request().Context(), c.QueryParam("state"), browserState, c.QueryParam("code"))
+	if err != nil {
+		// 保存登录日志
+		if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), user.Username, false, false, "", "单点登录失败"); err != nil {
+			return err
+		}
+		return c.HTML(http.StatusUnauthorized, "单点登录失败: "+html.EscapeString(err.Error()))
+	}
+
+	return api.ssoLogin(c, user)
+}
+
+// ssoLogin 单点登录认证成功后，与账号密码登录一样校验账号锁定、登录策略、双因素认证及同时在线数限制
+func (api AccountApi) ssoLogin(c echo.Context, user model.User) error {
+	if user.Status == constant.StatusDisabled {
+		return c.HTML(http.StatusForbidden, "该账户已停用")
+	}
+
+	// 管理员锁定及连续登录失败次数过多时锁定的账户
+	if err := service.LoginLockService.Check(user.Username); err != nil {
+		return c.HTML(http.StatusForbidden, html.EscapeString(err.Error()))
+	}
+
+	reason := ""
+	if err := service.LoginPolicyService.Check(context.TODO(), user, c.RealIP()); err != nil {
+		reason = err.Error()
+	} else {
+		reason = api.ssoMfaCheck(user)
+	}
+	if reason != "" {
+		if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), user.Username, false, false, "", reason); err != nil {
+			return err
+		}
+		return c.HTML(http.StatusForbidden, html.EscapeString(reason))
+	}
+
+	if err := service.ConcurrentLimitService.CheckLogin(user.Username); err != nil {
+		return c.HTML(http.StatusForbidden, html.EscapeString(err.Error()))
+	}
+
+	loginAccount := dto.LoginAccount{Username: user.Username}
+	token, err := api.LoginSuccess(loginAccount, user)
+	if err != nil {
+		return err
+	}
+	// 保存登录日志，重启后通过登录日志恢复令牌
+	if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), user.Username, true, false, token, ""); err != nil {
+		return err
+	}
+
+	return redirectWithToken(c, token)
+}
+
+// ssoMfaCheck 单点登录无法再进行双因素认证，用户组要求双因素认证时只有开启了信任认证服务器才允许登录，返回拒绝的原因
+func (api AccountApi) ssoMfaCheck(user model.User) string {
+	_, required, err := service.MfaService.Methods(context.TODO(), user)
+	if err != nil {
+		return err.Error()
+	}
+	if !required {
+		return ""
+	}
+	propertiesMap := repository.PropertyRepository.FindAllMap(context.TODO())
+	if propertiesMap[constant.SsoTrustIdpMfa] == "true" {
+		return ""
+	}
+	return "您所在的用户组要求进行双因素认证，无法使用单点登录"
+}
+
+// redirectWithToken 单点登录成功后与前端登录的处理保持一致，将令牌写入 localStorage 后跳转到首页
+func redirectWithToken(c echo.Context, token string) error {
+	tokenJson, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return c.HTML(http.StatusOK, fmt.Sprintf(`<script>localStorage.setItem('%s', %s); window.location.href = '/';</script>`, constant.Token, tokenJson))
+}
+
 func (api AccountApi) LogoutEndpoint(c echo.Context) error {
 	token := GetToken(c)
 	service.UserService.Logout(token)
@@ -196,7 +342,46 @@ func (api AccountApi) ConfirmTOTPEndpoint(c echo.Context) error {
 		return err
 	}
 
-	return Success(c, nil)
+	// 开启双因素认证时生成恢复码，用于丢失设备时登录
+	codes, err := service.RecoveryCodeService.Generate(account.ID)
+	if err != nil {
+		return err
+	}
+
+	return Success(c, Map{
+		"recoveryCodes": codes,
+	})
+}
+
+// RecoveryCodeCountEndpoint 剩余可用的恢复码数量
+func (api AccountApi) RecoveryCodeCountEndpoint(c echo.Context) error {
+	account, _ := GetCurrentAccount(c)
+	count, err := service.RecoveryCodeService.Count(account.ID)
+	if err != nil {
+		return err
+	}
+	return Success(c, Map{
+		"count": count,
+	})
+}
+
+// RecoveryCodeGenEndpoint 重新生成恢复码，旧的恢复码全部失效
+func (api AccountApi) RecoveryCodeGenEndpoint(c echo.Context) error {
+	account, _ := GetCurrentAccount(c)
+	user, err := repository.UserRepository.FindById(context.TODO(), account.ID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPSecret == "" || user.TOTPSecret == "-" {
+		return Fail(c, -1, "请先开启双因素认证")
+	}
+	codes, err := service.RecoveryCodeService.Generate(account.ID)
+	if err != nil {
+		return err
+	}
+	return Success(c, Map{
+		"recoveryCodes": codes,
+	})
 }
 
 func (api AccountApi) ReloadTOTPEndpoint(c echo.Context) error {
@@ -235,6 +420,9 @@ func (api AccountApi) ResetTOTPEndpoint(c echo.Context) error {
 	if err := repository.UserRepository.Update(context.TODO(), u); err != nil {
 		return err
 	}
+	if err := repository.RecoveryCodeRepository.DeleteByUserId(context.TODO(), account.ID); err != nil {
+		return err
+	}
 	return Success(c, "")
 }
 
@@ -249,32 +437,33 @@ func (api AccountApi) ChangePasswordEndpoint(c echo.Context) error {
 		return err
 	}
 
-	if err := utils.Encoder.Match([]byte(account.Password), []byte(changePassword.OldPassword)); err != nil {
-		return Fail(c, -1, "您输入的原密码不正确")
+	if account.Source == constant.SourceLdap {
+		return Fail(c, -1, "LDAP用户请到目录服务器修改密码")
 	}
 
-	passwd, err := utils.Encoder.Encode([]byte(changePassword.NewPassword))
+	user, err := repository.UserRepository.FindById(context.TODO(), account.ID)
 	if err != nil {
 		return err
 	}
-	u := &model.User{
-		Password: string(passwd),
-		ID:       account.ID,
+	if err := utils.Encoder.Match([]byte(user.Password), []byte(changePassword.OldPassword)); err != nil {
+		return Fail(c, -1, "您输入的原密码不正确")
 	}
 
-	if err := repository.UserRepository.Update(context.TODO(), u); err != nil {
-		return err
+	if err := service.PasswordPolicyService.ChangePassword(context.TODO(), user, changePassword.NewPassword, false); err != nil {
+		return Fail(c, -1, err.Error())
 	}
 
 	return api.LogoutEndpoint(c)
 }
 
 type AccountInfo struct {
-	Id         string `json:"id"`
-	Username   string `json:"username"`
-	Nickname   string `json:"nickname"`
-	Type       string `json:"type"`
-	EnableTotp bool   `json:"enableTotp"`
+	Id             string   `json:"id"`
+	Username       string   `json:"username"`
+	Nickname       string   `json:"nickname"`
+	Type           string   `json:"type"`
+	EnableTotp     bool     `json:"enableTotp"`
+	EnableEmailOtp bool     `json:"enableEmailOtp"`
+	Permissions    []string `json:"permissions"` // 管理员拥有全部权限
 }
 
 func (api AccountApi) InfoEndpoint(c echo.Context) error {
@@ -286,11 +475,21 @@ func (api AccountApi) InfoEndpoint(c echo.Context) error {
 	}
 
 	info := AccountInfo{
-		Id:         user.ID,
-		Username:   user.Username,
-		Nickname:   user.Nickname,
-		Type:       user.Type,
-		EnableTotp: user.TOTPSecret != "" && user.TOTPSecret != "-",
+		Id:             user.ID,
+		Username:       user.Username,
+		Nickname:       user.Nickname,
+		Type:           user.Type,
+		EnableTotp:     user.TOTPSecret != "" && user.TOTPSecret != "-",
+		EnableEmailOtp: user.EmailOtp && user.Mail != "",
+	}
+	if user.Type == constant.TypeAdmin {
+		info.Permissions = constant.Permissions
+	} else {
+		permissions, err := service.RoleService.FindPermissionsByUserId(context.TODO(), user.ID)
+		if err != nil {
+			return err
+		}
+		info.Permissions = permissions
 	}
 	return Success(c, info)
 }
@@ -346,21 +545,259 @@ func (api AccountApi) AccountStorageEndpoint(c echo.Context) error {
 
 func (api AccountApi) AccessTokenGetEndpoint(c echo.Context) error {
 	account, _ := GetCurrentAccount(c)
-	accessToken, err := repository.AccessTokenRepository.FindByUserId(context.TODO(), account.ID)
+	items, err := repository.AccessTokenRepository.FindByUserId(context.TODO(), account.ID)
+	if err != nil {
+		return err
+	}
+	return Success(c, items)
+}
+
+func (api AccountApi) AccessTokenScopesEndpoint(c echo.Context) error {
+	return Success(c, constant.AccessTokenScopes)
+}
+
+// AccessTokenGenEndpoint 令牌只在创建时返回一次
+func (api AccountApi) AccessTokenGenEndpoint(c echo.Context) error {
+	var item dto.AccessToken
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+	account, _ := GetCurrentAccount(c)
+	token, err := service.AccessTokenService.GenAccessToken(account.ID, item)
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, Map{
+		"token": token,
+	})
+}
+
+func (api AccountApi) AccessTokenDeleteEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	account, _ := GetCurrentAccount(c)
+	if err := service.AccessTokenService.DeleteById(account.ID, id); err != nil {
+		return err
+	}
+	return Success(c, nil)
+}
+
+func (api AccountApi) AuthorizedKeyGetEndpoint(c echo.Context) error {
+	account, _ := GetCurrentAccount(c)
+	items, err := repository.AuthorizedKeyRepository.FindByUserId(context.TODO(), account.ID)
+	if err != nil {
+		return err
+	}
+	return Success(c, items)
+}
+
+func (api AccountApi) AuthorizedKeyCreateEndpoint(c echo.Context) error {
+	account, _ := GetCurrentAccount(c)
+	var item dto.AuthorizedKey
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+	authorizedKey, err := service.AuthorizedKeyService.Create(context.TODO(), account.ID, item)
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, authorizedKey)
+}
+
+func (api AccountApi) AuthorizedKeyDeleteEndpoint(c echo.Context) error {
+	account, _ := GetCurrentAccount(c)
+	if err := repository.AuthorizedKeyRepository.DeleteByIdAndUserId(context.TODO(), c.Param("id"), account.ID); err != nil {
+		return err
+	}
+	return Success(c, nil)
+}
+
+// EmailOtpSendEndpoint 校验账号密码后发送邮件验证码
+func (api AccountApi) EmailOtpSendEndpoint(c echo.Context) error {
+	var loginAccount dto.LoginAccount
+	if err := c.Bind(&loginAccount); err != nil {
+		return err
+	}
+
+	// 连续登录失败次数过多时账户会被锁定
+	if err := service.LoginLockService.Check(loginAccount.Username); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+
+	user, err := service.UserService.Authenticate(loginAccount.Username, loginAccount.Password)
 	if err != nil {
-		if errors.Is(gorm.ErrRecordNotFound, err) {
-			accessToken = model.AccessToken{}
-		} else {
+		count := service.LoginLockService.Failed(loginAccount.Username)
+		// 保存登录日志
+		if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), loginAccount.Username, false, loginAccount.Remember, "", "账号或密码不正确"); err != nil {
 			return err
 		}
+		return FailWithData(c, -1, "您输入的账号或密码不正确", count)
+	}
+
+	if user.Status == constant.StatusDisabled {
+		return Fail(c, -1, "该账户已停用")
+	}
+
+	methods, _, err := service.MfaService.Methods(context.TODO(), user)
+	if err != nil {
+		return Fail(c, -1, err.Error())
 	}
-	return Success(c, accessToken)
+	if !utils.Contains(methods, constant.MfaEmail) {
+		return Fail(c, -1, "您还没有开启邮件验证码")
+	}
+
+	if err := service.MfaService.SendEmailCode(user); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, nil)
 }
 
-func (api AccountApi) AccessTokenGenEndpoint(c echo.Context) error {
+// EmailOtpEndpoint 开启或关闭邮件验证码双因素认证
+func (api AccountApi) EmailOtpEndpoint(c echo.Context) error {
 	account, _ := GetCurrentAccount(c)
-	if err := service.AccessTokenService.GenAccessToken(account.ID); err != nil {
+	enabled := c.QueryParam("enabled") == "true"
+
+	user, err := repository.UserRepository.FindById(context.TODO(), account.ID)
+	if err != nil {
 		return err
 	}
+	if enabled && user.Mail == "" {
+		return Fail(c, -1, "请先设置邮箱")
+	}
+	if err := repository.UserRepository.UpdateEmailOtp(context.TODO(), account.ID, enabled); err != nil {
+		return err
+	}
+	return Success(c, nil)
+}
+
+// WebAuthnLoginBeginEndpoint 校验账号密码后生成使用认证器登录所需的参数
+func (api AccountApi) WebAuthnLoginBeginEndpoint(c echo.Context) error {
+	var loginAccount dto.LoginAccount
+	if err := c.Bind(&loginAccount); err != nil {
+		return err
+	}
+
+	// 连续登录失败次数过多时账户会被锁定
+	if err := service.LoginLockService.Check(loginAccount.Username); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+
+	user, err := service.UserService.Authenticate(loginAccount.Username, loginAccount.Password)
+	if err != nil {
+		count := service.LoginLockService.Failed(loginAccount.Username)
+		// 保存登录日志
+		if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), loginAccount.Username, false, loginAccount.Remember, "", "账号或密码不正确"); err != nil {
+			return err
+		}
+		return FailWithData(c, -1, "您输入的账号或密码不正确", count)
+	}
+
+	if user.Status == constant.StatusDisabled {
+		return Fail(c, -1, "该账户已停用")
+	}
+
+	// 密码已过期，需要修改密码后重新登录
+	if service.PasswordPolicyService.Expired(user) {
+		return Fail(c, 2, "您的密码已过期，请修改密码")
+	}
+
+	if !service.WebAuthnService.Enabled(context.TODO(), user.ID) {
+		return Fail(c, -1, "您还没有注册WebAuthn认证器")
+	}
+
+	options, err := service.WebAuthnService.BeginLogin(context.TODO(), rootUrl(c), user)
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, options)
+}
+
+func (api AccountApi) WebAuthnCredentialGetEndpoint(c echo.Context) error {
+	account, _ := GetCurrentAccount(c)
+	items, err := repository.WebAuthnCredentialRepository.FindByUserId(context.TODO(), account.ID)
+	if err != nil {
+		return err
+	}
+	return Success(c, items)
+}
+
+func (api AccountApi) WebAuthnRegistrationBeginEndpoint(c echo.Context) error {
+	account, _ := GetCurrentAccount(c)
+	options, err := service.WebAuthnService.BeginRegistration(context.TODO(), rootUrl(c), *account)
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, options)
+}
+
+func (api AccountApi) WebAuthnRegistrationFinishEndpoint(c echo.Context) error {
+	account, _ := GetCurrentAccount(c)
+	item, err := service.WebAuthnService.FinishRegistration(context.TODO(), rootUrl(c), *account, c.QueryParam("name"), c.Request().Body)
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, item)
+}
+
+func (api AccountApi) WebAuthnCredentialDeleteEndpoint(c echo.Context) error {
+	account, _ := GetCurrentAccount(c)
+	if err := repository.WebAuthnCredentialRepository.DeleteByIdAndUserId(context.TODO(), c.Param("id"), account.ID); err != nil {
+		return err
+	}
+	return Success(c, nil)
+}
+
+// ChangeExpiredPasswordEndpoint 密码过期的用户无法登录，需要校验原密码后修改
+func (api AccountApi) ChangeExpiredPasswordEndpoint(c echo.Context) error {
+	var changePassword dto.ChangeExpiredPassword
+	if err := c.Bind(&changePassword); err != nil {
+		return err
+	}
+
+	// 连续登录失败次数过多时账户会被锁定
+	if err := service.LoginLockService.Check(changePassword.Username); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+
+	user, err := service.UserService.Authenticate(changePassword.Username, changePassword.OldPassword)
+	if err != nil {
+		count := service.LoginLockService.Failed(changePassword.Username)
+		return FailWithData(c, -1, "您输入的账号、密码或双因素认证授权码不正确", count)
+	}
+
+	if user.Status == constant.StatusDisabled {
+		return Fail(c, -1, "该账户已停用")
+	}
+	if !service.PasswordPolicyService.Expired(user) {
+		return Fail(c, -1, "您的密码未过期")
+	}
+
+	// WebAuthn 认证器无法在此处使用，只校验授权码、恢复码或邮件验证码
+	methods, _, err := service.MfaService.Methods(context.TODO(), user)
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	totpEnabled, emailEnabled := utils.Contains(methods, constant.MfaTotp), utils.Contains(methods, constant.MfaEmail)
+	if totpEnabled || emailEnabled {
+		if strings.TrimSpace(changePassword.TOTP) == "" {
+			// 未填写验证码时告知前端可以使用的认证方式，只能使用邮件验证码时直接发送
+			if !totpEnabled {
+				if err := service.MfaService.SendEmailCode(user); err != nil {
+					return Fail(c, -1, err.Error())
+				}
+			}
+			return FailWithData(c, 0, "", Map{
+				"totp":  totpEnabled,
+				"email": emailEnabled,
+			})
+		}
+		if !service.MfaService.Verify(user, methods, changePassword.TOTP) {
+			count := service.LoginLockService.Failed(changePassword.Username)
+			return FailWithData(c, -1, "您输入的账号、密码或双因素认证授权码不正确", count)
+		}
+	}
+
+	if err := service.PasswordPolicyService.ChangePassword(context.TODO(), user, changePassword.NewPassword, false); err != nil {
+		return Fail(c, -1, err.Error())
+	}
 	return Success(c, nil)
 }
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+
+	"next-terminal/server/config"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AssetAccountApi struct{}
+
+func (api AssetAccountApi) AssetAccountAllEndpoint(c echo.Context) error {
+	assetId := c.Param("id")
+	if err := new(AssetApi).PreCheckAssetPermission(c, assetId); err != nil {
+		return err
+	}
+	items, err := repository.AssetAccountRepository.FindByAssetId(context.TODO(), assetId)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Password = ""
+		items[i].PrivateKey = ""
+		items[i].Passphrase = ""
+	}
+	return Success(c, items)
+}
+
+func (api AssetAccountApi) AssetAccountGetEndpoint(c echo.Context) error {
+	assetId := c.Param("id")
+	if err := new(AssetApi).PreCheckAssetPermission(c, assetId); err != nil {
+		return err
+	}
+	item, err := api.find(assetId, c.Param("accountId"))
+	if err != nil {
+		return err
+	}
+	if err := service.AssetAccountService.Decrypt(&item, config.GlobalCfg.EncryptionPassword); err != nil {
+		return err
+	}
+	return Success(c, item)
+}
+
+func (api AssetAccountApi) AssetAccountCreateEndpoint(c echo.Context) error {
+	assetId := c.Param("id")
+	if err := new(AssetApi).PreCheckAssetPermission(c, assetId); err != nil {
+		return err
+	}
+	var item model.AssetAccount
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+	item.AssetId = assetId
+	if err := service.AssetAccountService.Create(context.TODO(), &item); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, "")
+}
+
+func (api AssetAccountApi) AssetAccountUpdateEndpoint(c echo.Context) error {
+	assetId := c.Param("id")
+	if err := new(AssetApi).PreCheckAssetPermission(c, assetId); err != nil {
+		return err
+	}
+	accountId := c.Param("accountId")
+	if _, err := api.find(assetId, accountId); err != nil {
+		return err
+	}
+	var item model.AssetAccount
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+	item.AssetId = assetId
+	if err := service.AssetAccountService.UpdateById(context.TODO(), &item, accountId); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, "")
+}
+
+func (api AssetAccountApi) AssetAccountDeleteEndpoint(c echo.Context) error {
+	assetId := c.Param("id")
+	if err := new(AssetApi).PreCheckAssetPermission(c, assetId); err != nil {
+		return err
+	}
+	accountId := c.Param("accountId")
+	if _, err := api.find(assetId, accountId); err != nil {
+		return err
+	}
+	if err := repository.AssetAccountRepository.DeleteById(context.TODO(), accountId); err != nil {
+		return err
+	}
+	return Success(c, nil)
+}
+
+// AssetAccountAllowedEndpoint 当前用户访问资产时可以选择的账号
+func (api AssetAccountApi) AssetAccountAllowedEndpoint(c echo.Context) error {
+	asset, err := repository.AssetRepository.FindById(context.TODO(), c.QueryParam("assetId"))
+	if err != nil {
+		return err
+	}
+	user, _ := GetCurrentAccount(c)
+	items, err := service.AssetAccountService.FindAllowed(context.TODO(), asset, user)
+	if err != nil {
+		return err
+	}
+	return Success(c, items)
+}
+
+func (api AssetAccountApi) find(assetId, accountId string) (model.AssetAccount, error) {
+	asset, err := repository.AssetRepository.FindById(context.TODO(), assetId)
+	if err != nil {
+		return model.AssetAccount{}, err
+	}
+	return service.AssetAccountService.FindAccount(context.TODO(), asset, accountId)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CommandRuleApi struct{}
+
+func (api CommandRuleApi) CommandRulePagingEndpoint(c echo.Context) error {
+	pageIndex, _ := strconv.Atoi(c.QueryParam("pageIndex"))
+	pageSize, _ := strconv.Atoi(c.QueryParam("pageSize"))
+	name := c.QueryParam("name")
+	action := c.QueryParam("action")
+
+	order := c.QueryParam("order")
+	field := c.QueryParam("field")
+
+	items, total, err := repository.CommandRuleRepository.Find(context.TODO(), pageIndex, pageSize, name, action, order, field)
+	if err != nil {
+		return err
+	}
+
+	return Success(c, Map{
+		"total": total,
+		"items": items,
+	})
+}
+
+func (api CommandRuleApi) CommandRuleCreateEndpoint(c echo.Context) error {
+	var item model.CommandRule
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+
+	if err := service.CommandRuleService.Create(&item); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, item)
+}
+
+func (api CommandRuleApi) CommandRuleUpdateEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	var item model.CommandRule
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+
+	if err := service.CommandRuleService.UpdateById(id, &item); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, "")
+}
+
+func (api CommandRuleApi) CommandRuleDeleteEndpoint(c echo.Context) error {
+	ids := c.Param("id")
+	split := strings.Split(ids, ",")
+	for i := range split {
+		if err := repository.CommandRuleRepository.DeleteById(context.TODO(), split[i]); err != nil {
+			return err
+		}
+	}
+	return Success(c, nil)
+}
+
+func (api CommandRuleApi) CommandRuleGetEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	item, err := repository.CommandRuleRepository.FindById(context.TODO(), id)
+	if err != nil {
+		return err
+	}
+	return Success(c, item)
+}
+
+// CommandRuleRecordPagingEndpoint 命令匹配拦截规则的审计记录
+func (api CommandRuleApi) CommandRuleRecordPagingEndpoint(c echo.Context) error {
+	pageIndex, _ := strconv.Atoi(c.QueryParam("pageIndex"))
+	pageSize, _ := strconv.Atoi(c.QueryParam("pageSize"))
+	sessionId := c.QueryParam("sessionId")
+	username := c.QueryParam("username")
+	assetName := c.QueryParam("assetName")
+	action := c.QueryParam("action")
+	command := c.QueryParam("command")
+
+	items, total, err := repository.CommandRuleRecordRepository.Find(context.TODO(), pageIndex, pageSize, sessionId, username, assetName, action, command)
+	if err != nil {
+		return err
+	}
+
+	return Success(c, Map{
+		"total": total,
+		"items": items,
+	})
+}
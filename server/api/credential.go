@@ -9,6 +9,7 @@ import (
 
 	"next-terminal/server/config"
 	"next-terminal/server/constant"
+	"next-terminal/server/dto"
 	"next-terminal/server/model"
 	"next-terminal/server/repository"
 	"next-terminal/server/service"
@@ -103,6 +104,13 @@ func (api CredentialApi) CredentialUpdateEndpoint(c echo.Context) error {
 		return err
 	}
 
+	original, err := service.CredentialService.FindByIdAndDecrypt(context.TODO(), id)
+	if err != nil {
+		return err
+	}
+	// 修改了密码或密钥才视为已完成轮换
+	var rotated bool
+
 	switch item.Type {
 	case constant.Custom:
 		item.PrivateKey = "-"
@@ -113,6 +121,7 @@ func (api CredentialApi) CredentialUpdateEndpoint(c echo.Context) error {
 		if item.Password == "" {
 			item.Password = "-"
 		}
+		rotated = item.Password != original.Password
 		if item.Password != "-" {
 			encryptedCBC, err := utils.AesEncryptCBC([]byte(item.Password), config.GlobalCfg.EncryptionPassword)
 			if err != nil {
@@ -128,6 +137,7 @@ func (api CredentialApi) CredentialUpdateEndpoint(c echo.Context) error {
 		if item.PrivateKey == "" {
 			item.PrivateKey = "-"
 		}
+		rotated = item.PrivateKey != original.PrivateKey
 		if item.PrivateKey != "-" {
 			encryptedCBC, err := utils.AesEncryptCBC([]byte(item.PrivateKey), config.GlobalCfg.EncryptionPassword)
 			if err != nil {
@@ -149,10 +159,16 @@ func (api CredentialApi) CredentialUpdateEndpoint(c echo.Context) error {
 		return Fail(c, -1, "类型错误")
 	}
 	item.Encrypted = true
+	item.NeedsRotation = false
 
 	if err := repository.CredentialRepository.UpdateById(context.TODO(), &item, id); err != nil {
 		return err
 	}
+	if rotated && original.NeedsRotation {
+		if err := repository.CredentialRepository.UpdateNeedsRotationById(context.TODO(), false, id); err != nil {
+			return err
+		}
+	}
 
 	return Success(c, nil)
 }
@@ -204,6 +220,72 @@ func (api CredentialApi) CredentialChangeOwnerEndpoint(c echo.Context) error {
 	return Success(c, "")
 }
 
+// CredentialGenerateEndpoint 在服务端生成密钥对，只返回公钥
+func (api CredentialApi) CredentialGenerateEndpoint(c echo.Context) error {
+	var item dto.CredentialGenerate
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+
+	account, _ := GetCurrentAccount(c)
+	credential, publicKey, err := service.CredentialService.GenerateKeyPair(context.TODO(), item, account.ID)
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, Map{
+		"id":        credential.ID,
+		"publicKey": publicKey,
+	})
+}
+
+func (api CredentialApi) CredentialPublicKeyEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	if err := api.PreCheckCredentialPermission(c, id); err != nil {
+		return err
+	}
+
+	publicKey, err := service.CredentialService.PublicKey(context.TODO(), id)
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, publicKey)
+}
+
+func (api CredentialApi) CredentialDeployEndpoint(c echo.Context) error {
+	return api.deploy(c, service.CredentialService.DeployPublicKey)
+}
+
+func (api CredentialApi) CredentialRevokeEndpoint(c echo.Context) error {
+	return api.deploy(c, service.CredentialService.RevokePublicKey)
+}
+
+// deploy 部署及撤销公钥需要同时拥有凭证及资产的权限，返回每个资产的执行结果
+func (api CredentialApi) deploy(c echo.Context, action func(context.Context, string, dto.CredentialDeploy) ([]dto.CredentialDeployResult, error)) error {
+	id := c.Param("id")
+	if err := api.PreCheckCredentialPermission(c, id); err != nil {
+		return err
+	}
+
+	var item dto.CredentialDeploy
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+	if len(item.AssetIds) == 0 {
+		return Fail(c, -1, "请选择资产")
+	}
+	for _, assetId := range item.AssetIds {
+		if err := new(AssetApi).PreCheckAssetPermission(c, assetId); err != nil {
+			return err
+		}
+	}
+
+	results, err := action(context.TODO(), id, item)
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, results)
+}
+
 func (api CredentialApi) PreCheckCredentialPermission(c echo.Context, id string) error {
 	item, err := repository.CredentialRepository.FindById(context.TODO(), id)
 	if err != nil {
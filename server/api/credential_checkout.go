@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/dto"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CredentialCheckoutApi struct{}
+
+// CredentialCheckoutPagingEndpoint type=mine 查询自己的借出记录，type=approve 查询自己可以审批的借出申请
+func (api CredentialCheckoutApi) CredentialCheckoutPagingEndpoint(c echo.Context) error {
+	pageIndex, _ := strconv.Atoi(c.QueryParam("pageIndex"))
+	pageSize, _ := strconv.Atoi(c.QueryParam("pageSize"))
+	status := c.QueryParam("status")
+
+	account, _ := GetCurrentAccount(c)
+	var userId, ownerId string
+	if c.QueryParam("type") == "approve" {
+		isMember, err := service.AccessRequestService.IsApproverGroupMember(account.ID)
+		if err != nil {
+			return err
+		}
+		if constant.TypeAdmin != account.Type && !isMember {
+			ownerId = account.ID
+		}
+	} else {
+		userId = account.ID
+	}
+
+	items, total, err := repository.CredentialCheckoutRepository.Find(context.TODO(), pageIndex, pageSize, userId, ownerId, status)
+	if err != nil {
+		return err
+	}
+
+	return Success(c, Map{
+		"total": total,
+		"items": items,
+	})
+}
+
+func (api CredentialCheckoutApi) CredentialCheckoutCreateEndpoint(c echo.Context) error {
+	var item dto.CredentialCheckout
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+
+	account, _ := GetCurrentAccount(c)
+	checkout, err := service.CredentialCheckoutService.Create(account, item, c.RealIP())
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, checkout)
+}
+
+func (api CredentialCheckoutApi) CredentialCheckoutApproveEndpoint(c echo.Context) error {
+	return api.review(c, service.CredentialCheckoutService.Approve)
+}
+
+func (api CredentialCheckoutApi) CredentialCheckoutDenyEndpoint(c echo.Context) error {
+	return api.review(c, service.CredentialCheckoutService.Deny)
+}
+
+func (api CredentialCheckoutApi) review(c echo.Context, action func(*model.User, string, dto.CredentialCheckoutReview, string) error) error {
+	id := c.Param("id")
+	var item dto.CredentialCheckoutReview
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+
+	account, _ := GetCurrentAccount(c)
+	if err := action(account, id, item, c.RealIP()); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, "")
+}
+
+func (api CredentialCheckoutApi) CredentialCheckoutCancelEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	account, _ := GetCurrentAccount(c)
+	if err := service.CredentialCheckoutService.Cancel(account, id, c.RealIP()); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, "")
+}
+
+// CredentialCheckoutRevealEndpoint 查看借出凭证的密码或密钥
+func (api CredentialCheckoutApi) CredentialCheckoutRevealEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	account, _ := GetCurrentAccount(c)
+	secret, err := service.CredentialCheckoutService.Reveal(account, id, c.RealIP())
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, secret)
+}
+
+func (api CredentialCheckoutApi) CredentialCheckoutCheckInEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	account, _ := GetCurrentAccount(c)
+	if err := service.CredentialCheckoutService.CheckIn(account, id, c.RealIP()); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, "")
+}
+
+// CredentialCheckoutLogsEndpoint 借出人及审批人可以查看借出的审计记录
+func (api CredentialCheckoutApi) CredentialCheckoutLogsEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	checkout, err := repository.CredentialCheckoutRepository.FindById(context.TODO(), id)
+	if err != nil {
+		return err
+	}
+
+	account, _ := GetCurrentAccount(c)
+	if checkout.UserId != account.ID {
+		ok, err := service.CredentialCheckoutService.CanApprove(account, checkout)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return Fail(c, -1, constant.ErrCheckoutForbidden.Error())
+		}
+	}
+
+	items, err := repository.CredentialCheckoutLogRepository.FindByCheckoutId(context.TODO(), id)
+	if err != nil {
+		return err
+	}
+	return Success(c, items)
+}
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"path"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"next-terminal/server/config"
 	"next-terminal/server/constant"
@@ -31,6 +33,7 @@ const (
 	AccessGatewayCreateError int = 804
 	AssetNotActive           int = 805
 	NewSshClientError        int = 806
+	SessionLimitExceeded     int = 807
 )
 
 var UpGrader = websocket.Upgrader{
@@ -69,6 +72,10 @@ func (api GuacamoleApi) Guacamole(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	if err := service.ConcurrentLimitService.CheckConnect(s); err != nil {
+		utils.Disconnect(ws, SessionLimitExceeded, err.Error())
+		return nil
+	}
 	api.setConfig(propertyMap, s, configuration)
 
 	if s.AccessGatewayId != "" && s.AccessGatewayId != "-" {
@@ -102,6 +109,13 @@ func (api GuacamoleApi) Guacamole(c echo.Context) error {
 	if len(attributes) > 0 {
 		api.setAssetConfig(attributes, s, configuration)
 	}
+	// 授权策略限制的剪贴板方向，不允许被资产属性覆盖
+	if s.Copy == "0" {
+		configuration.SetParameter(guacd.DisableCopy, "true")
+	}
+	if s.Paste == "0" {
+		configuration.SetParameter(guacd.DisablePaste, "true")
+	}
 	for name := range configuration.Parameters {
 		// 替换数据库空格字符串占位符为真正的空格
 		if configuration.Parameters[name] == "-" {
@@ -126,6 +140,8 @@ func (api GuacamoleApi) Guacamole(c echo.Context) error {
 		Mode:        s.Mode,
 		WebSocket:   ws,
 		GuacdTunnel: guacdTunnel,
+		Supervisor:  session.NewSupervisor(attributes[constant.DualControl]),
+		Limit:       session.NewLimit(s.ConnectedTime.Time, s.MaxDuration, s.IdleTimeout),
 	}
 
 	if configuration.Protocol == constant.SSH {
@@ -158,7 +174,7 @@ func (api GuacamoleApi) Guacamole(c echo.Context) error {
 		return err
 	}
 
-	guacamoleHandler := NewGuacamoleHandler(ws, guacdTunnel)
+	guacamoleHandler := NewGuacamoleHandler(sessionId, ws, guacdTunnel, nextSession.Limit)
 	guacamoleHandler.Start()
 	defer guacamoleHandler.Stop()
 
@@ -172,6 +188,14 @@ func (api GuacamoleApi) Guacamole(c echo.Context) error {
 			service.SessionService.CloseSessionById(sessionId, Normal, "用户正常退出")
 			return nil
 		}
+		opcodes := instructionOpcodes(message)
+		if !nextSession.InputAllowed() && !allowedWhilePaused(opcodes) {
+			// 双人复核的会话在监控人不在线时丢弃键盘、鼠标、剪贴板及文件传输等指令
+			continue
+		}
+		if hasInputInstruction(opcodes) {
+			nextSession.Limit.Active()
+		}
 		_, err = guacdTunnel.WriteAndFlush(message)
 		if err != nil {
 			service.SessionService.CloseSessionById(sessionId, TunnelClosed, "远程连接已关闭")
@@ -220,6 +244,10 @@ func (api GuacamoleApi) GuacamoleMonitor(c echo.Context) error {
 		utils.Disconnect(ws, AssetNotActive, "会话离线")
 		return nil
 	}
+	if !service.SessionService.MonitorAllowed(s) {
+		utils.Disconnect(ws, ForcedDisconnect, "授权策略禁止监控此会话")
+		return nil
+	}
 	connectionId := s.ConnectionId
 	configuration := guacd.NewConfiguration()
 	configuration.ConnectionID = connectionId
@@ -256,8 +284,10 @@ func (api GuacamoleApi) GuacamoleMonitor(c echo.Context) error {
 	nextSession.ID = utils.UUID()
 	forObsSession.Observer.Add <- nextSession
 	log.Debugf("[%v:%v] 观察者[%v]加入会话[%v]", sessionId, connectionId, nextSession.ID, s.ConnectionId)
+	user, _ := GetCurrentAccount(c)
+	service.SessionService.SupervisorJoin(sessionId, nextSession.ID, user)
 
-	guacamoleHandler := NewGuacamoleHandler(ws, guacdTunnel)
+	guacamoleHandler := NewGuacamoleHandler(sessionId, ws, guacdTunnel, nil)
 	guacamoleHandler.Start()
 	defer guacamoleHandler.Stop()
 
@@ -271,6 +301,9 @@ func (api GuacamoleApi) GuacamoleMonitor(c echo.Context) error {
 			observerId := nextSession.ID
 			forObsSession.Observer.Del <- observerId
 			log.Debugf("[%v:%v] 观察者[%v]退出会话", sessionId, connectionId, observerId)
+			if service.SessionService.SupervisorLeave(sessionId, observerId) {
+				service.SessionService.CloseSessionById(sessionId, ForcedDisconnect, DualControlTerminatedReason)
+			}
 			return nil
 		}
 		_, err = guacdTunnel.WriteAndFlush(message)
@@ -281,8 +314,85 @@ func (api GuacamoleApi) GuacamoleMonitor(c echo.Context) error {
 	}
 }
 
+// pausedOpcodes 双人复核的会话在监控人不在线时只转发维持连接所需的指令
+var pausedOpcodes = map[string]bool{
+	"sync":       true,
+	"size":       true,
+	"nop":        true,
+	"disconnect": true,
+}
+
+// instructionOpcodes 解析消息中全部指令的操作码，一条消息可能包含多条指令，格式错误时返回 nil
+func instructionOpcodes(message []byte) []string {
+	var opcodes []string
+	content := string(message)
+	first := true
+	for len(content) > 0 {
+		dot := strings.IndexByte(content, '.')
+		if dot <= 0 {
+			return nil
+		}
+		length, err := strconv.Atoi(content[:dot])
+		if err != nil || length < 0 {
+			return nil
+		}
+		content = content[dot+1:]
+		// 元素的长度为字符数而不是字节数
+		i := 0
+		for n := 0; n < length; n++ {
+			if i >= len(content) {
+				return nil
+			}
+			_, size := utf8.DecodeRuneInString(content[i:])
+			i += size
+		}
+		if first {
+			opcodes = append(opcodes, content[:i])
+		}
+		content = content[i:]
+		if len(content) == 0 {
+			return nil
+		}
+		switch content[0] {
+		case ',':
+			first = false
+		case ';':
+			first = true
+		default:
+			return nil
+		}
+		content = content[1:]
+	}
+	if !first {
+		return nil
+	}
+	return opcodes
+}
+
+func allowedWhilePaused(opcodes []string) bool {
+	if len(opcodes) == 0 {
+		return false
+	}
+	for _, opcode := range opcodes {
+		if !pausedOpcodes[opcode] {
+			return false
+		}
+	}
+	return true
+}
+
+// hasInputInstruction 判断是否包含键盘、鼠标或触摸操作的指令
+func hasInputInstruction(opcodes []string) bool {
+	for _, opcode := range opcodes {
+		if opcode == "key" || opcode == "mouse" || opcode == "touch" {
+			return true
+		}
+	}
+	return false
+}
+
 func (api GuacamoleApi) setConfig(propertyMap map[string]string, s model.Session, configuration *guacd.Configuration) {
-	if propertyMap[guacd.EnableRecording] == "true" {
+	if propertyMap[guacd.EnableRecording] == "true" || s.ForceRecording == "1" {
 		configuration.SetParameter(guacd.RecordingPath, path.Join(config.GlobalCfg.Guacd.Recording, s.ID))
 		configuration.SetParameter(guacd.CreateRecordingPath, "true")
 	} else {
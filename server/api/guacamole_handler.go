@@ -2,28 +2,41 @@ package api
 
 import (
 	"context"
+	"sync"
+	"time"
 
+	"next-terminal/server/global/session"
 	"next-terminal/server/guacd"
 	"next-terminal/server/log"
+	"next-terminal/server/service"
 	"next-terminal/server/utils"
 
 	"github.com/gorilla/websocket"
 )
 
+// LimitWarning 会话即将超出授权策略限制时发送给客户端的指令
+const LimitWarning = "nt-limit-warning"
+
 type GuacamoleHandler struct {
-	ws     *websocket.Conn
-	tunnel *guacd.Tunnel
-	ctx    context.Context
-	cancel context.CancelFunc
+	sessionId string
+	ws        *websocket.Conn
+	tunnel    *guacd.Tunnel
+	limit     *session.Limit
+	mutex     *sync.Mutex
+	ctx       context.Context
+	cancel    context.CancelFunc
 }
 
-func NewGuacamoleHandler(ws *websocket.Conn, tunnel *guacd.Tunnel) *GuacamoleHandler {
+func NewGuacamoleHandler(sessionId string, ws *websocket.Conn, tunnel *guacd.Tunnel, limit *session.Limit) *GuacamoleHandler {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &GuacamoleHandler{
-		ws:     ws,
-		tunnel: tunnel,
-		ctx:    ctx,
-		cancel: cancel,
+		sessionId: sessionId,
+		ws:        ws,
+		tunnel:    tunnel,
+		limit:     limit,
+		mutex:     &sync.Mutex{},
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 }
 
@@ -42,7 +55,7 @@ func (r GuacamoleHandler) Start() {
 				if len(instruction) == 0 {
 					continue
 				}
-				err = r.ws.WriteMessage(websocket.TextMessage, instruction)
+				err = r.write(instruction)
 				if err != nil {
 					log.Debugf("WebSocket写入失败，即将关闭Guacd连接...")
 					return
@@ -50,8 +63,40 @@ func (r GuacamoleHandler) Start() {
 			}
 		}
 	}()
+	if r.limit != nil {
+		go r.checkLimit()
+	}
 }
 
 func (r GuacamoleHandler) Stop() {
 	r.cancel()
 }
+
+func (r GuacamoleHandler) write(message []byte) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.ws.WriteMessage(websocket.TextMessage, message)
+}
+
+// checkLimit 检查授权策略限制的会话时长，超出时断开会话
+func (r GuacamoleHandler) checkLimit() {
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case now := <-tick.C:
+			warning, reason := r.limit.Check(now)
+			if reason != "" {
+				_ = r.tunnel.Close()
+				service.SessionService.CloseSessionById(r.sessionId, SessionLimitExceeded, reason)
+				return
+			}
+			if warning != "" {
+				instruction := guacd.NewInstruction(LimitWarning, warning)
+				_ = r.write([]byte(instruction.String()))
+			}
+		}
+	}
+}
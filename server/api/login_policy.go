@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"next-terminal/server/dto"
+	"next-terminal/server/repository"
+	"next-terminal/server/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type LoginPolicyApi struct{}
+
+func (api LoginPolicyApi) LoginPolicyPagingEndpoint(c echo.Context) error {
+	pageIndex, _ := strconv.Atoi(c.QueryParam("pageIndex"))
+	pageSize, _ := strconv.Atoi(c.QueryParam("pageSize"))
+	name := c.QueryParam("name")
+
+	order := c.QueryParam("order")
+	field := c.QueryParam("field")
+
+	items, total, err := repository.LoginPolicyRepository.Find(context.TODO(), pageIndex, pageSize, name, order, field)
+	if err != nil {
+		return err
+	}
+
+	return Success(c, Map{
+		"total": total,
+		"items": items,
+	})
+}
+
+func (api LoginPolicyApi) LoginPolicyCreateEndpoint(c echo.Context) error {
+	var item dto.LoginPolicy
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+
+	policy, err := service.LoginPolicyService.Create(item)
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, policy)
+}
+
+func (api LoginPolicyApi) LoginPolicyUpdateEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	var item dto.LoginPolicy
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+
+	if err := service.LoginPolicyService.Update(id, item); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return Success(c, "")
+}
+
+func (api LoginPolicyApi) LoginPolicyDeleteEndpoint(c echo.Context) error {
+	ids := c.Param("id")
+	split := strings.Split(ids, ",")
+	for i := range split {
+		if err := service.LoginPolicyService.DeleteById(split[i]); err != nil {
+			return err
+		}
+	}
+	return Success(c, nil)
+}
+
+func (api LoginPolicyApi) LoginPolicyGetEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	item, err := service.LoginPolicyService.FindById(context.TODO(), id)
+	if err != nil {
+		return err
+	}
+	return Success(c, item)
+}
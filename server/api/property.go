@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 
+	"next-terminal/server/constant"
 	"next-terminal/server/repository"
 	"next-terminal/server/service"
 
@@ -13,6 +14,8 @@ type PropertyApi struct{}
 
 func (api PropertyApi) PropertyGetEndpoint(c echo.Context) error {
 	properties := repository.PropertyRepository.FindAllMap(context.TODO())
+	// SSH CA 私钥只在服务端使用
+	delete(properties, constant.SshCAPrivateKey)
 	return Success(c, properties)
 }
 
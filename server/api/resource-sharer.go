@@ -6,6 +6,7 @@ import (
 	"next-terminal/server/dto"
 	"next-terminal/server/repository"
 	"next-terminal/server/service"
+	"next-terminal/server/utils"
 
 	"github.com/labstack/echo/v4"
 )
@@ -43,8 +44,15 @@ func (api ResourceSharerApi) ResourceAddByUserIdAssignEndPoint(c echo.Context) e
 		return err
 	}
 
-	if err := service.UserService.AddSharerResources(context.TODO(), ru.UserGroupId, ru.UserId, ru.StrategyId, ru.ResourceType, ru.ResourceIds); err != nil {
-		return err
+	var validFrom, validUntil utils.JsonTime
+	if ru.ValidFrom != nil {
+		validFrom = *ru.ValidFrom
+	}
+	if ru.ValidUntil != nil {
+		validUntil = *ru.ValidUntil
+	}
+	if err := service.UserService.AddSharerResources(context.TODO(), ru.UserGroupId, ru.UserId, ru.StrategyId, ru.ResourceType, ru.ResourceIds, ru.AccountIds, validFrom, validUntil); err != nil {
+		return Fail(c, -1, err.Error())
 	}
 
 	return Success(c, "")
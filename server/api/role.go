@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/dto"
+	"next-terminal/server/repository"
+	"next-terminal/server/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type RoleApi struct{}
+
+func (api RoleApi) RoleAllEndpoint(c echo.Context) error {
+	items, err := repository.RoleRepository.FindAll(context.TODO())
+	if err != nil {
+		return err
+	}
+	return Success(c, items)
+}
+
+func (api RoleApi) RolePagingEndpoint(c echo.Context) error {
+	pageIndex, _ := strconv.Atoi(c.QueryParam("pageIndex"))
+	pageSize, _ := strconv.Atoi(c.QueryParam("pageSize"))
+	name := c.QueryParam("name")
+
+	order := c.QueryParam("order")
+	field := c.QueryParam("field")
+
+	items, total, err := repository.RoleRepository.Find(context.TODO(), pageIndex, pageSize, name, order, field)
+	if err != nil {
+		return err
+	}
+
+	return Success(c, Map{
+		"total": total,
+		"items": items,
+	})
+}
+
+// RolePermissionsEndpoint 可以分配给角色的全部权限
+func (api RoleApi) RolePermissionsEndpoint(c echo.Context) error {
+	return Success(c, constant.Permissions)
+}
+
+func (api RoleApi) RoleCreateEndpoint(c echo.Context) error {
+	var item dto.Role
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+	account, _ := GetCurrentAccount(c)
+
+	role, err := service.RoleService.Create(account, item)
+	if err != nil {
+		return err
+	}
+	return Success(c, role)
+}
+
+func (api RoleApi) RoleUpdateEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	var item dto.Role
+	if err := c.Bind(&item); err != nil {
+		return err
+	}
+	account, _ := GetCurrentAccount(c)
+
+	if err := service.RoleService.Update(account, id, item); err != nil {
+		return err
+	}
+	return Success(c, "")
+}
+
+func (api RoleApi) RoleDeleteEndpoint(c echo.Context) error {
+	ids := c.Param("id")
+	account, _ := GetCurrentAccount(c)
+	split := strings.Split(ids, ",")
+	for i := range split {
+		if err := service.RoleService.DeleteById(account, split[i]); err != nil {
+			return err
+		}
+	}
+	return Success(c, nil)
+}
+
+func (api RoleApi) RoleGetEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	ctx := context.TODO()
+	role, err := repository.RoleRepository.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	users, err := repository.RoleMemberRepository.FindUserIdsByRoleId(ctx, id)
+	if err != nil {
+		return err
+	}
+	userGroups, err := repository.RoleMemberRepository.FindUserGroupIdsByRoleId(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var permissions []string
+	if role.Permissions != "" {
+		permissions = strings.Split(role.Permissions, ",")
+	}
+	return Success(c, dto.Role{
+		Id:          role.ID,
+		Name:        role.Name,
+		Permissions: permissions,
+		Users:       users,
+		UserGroups:  userGroups,
+	})
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"html"
+	"net/http"
+
+	"next-terminal/server/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SamlApi struct{}
+
+func rootUrl(c echo.Context) string {
+	return c.Scheme() + "://" + c.Request().Host
+}
+
+func (api SamlApi) SamlMetadataEndpoint(c echo.Context) error {
+	metadata, err := service.SamlService.Metadata(c.Request().Context(), rootUrl(c))
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return c.Blob(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+func (api SamlApi) SamlLoginEndpoint(c echo.Context) error {
+	redirectUrl, err := service.SamlService.AuthnRequestURL(c.Request().Context(), rootUrl(c))
+	if err != nil {
+		return Fail(c, -1, err.Error())
+	}
+	return c.Redirect(http.StatusFound, redirectUrl)
+}
+
+func (api SamlApi) SamlAcsEndpoint(c echo.Context) error {
+	user, err := service.SamlService.Login(c.Request().Context(), rootUrl(c), c.Request())
+	if err != nil {
+		// 保存登录日志
+		if err := service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), user.Username, false, false, "", "单点登录失败"); err != nil {
+			return err
+		}
+		return c.HTML(http.StatusUnauthorized, "单点登录失败: "+html.EscapeString(err.Error()))
+	}
+
+	return AccountApi{}.ssoLogin(c, user)
+}
+
+func (api SamlApi) SamlSloEndpoint(c echo.Context) error {
+	redirectUrl, err := service.SamlService.Logout(c.Request().Context(), rootUrl(c), c.Request())
+	if err != nil {
+		return c.HTML(http.StatusBadRequest, "单点登出失败: "+html.EscapeString(err.Error()))
+	}
+	return c.Redirect(http.StatusFound, redirectUrl)
+}
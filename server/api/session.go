@@ -12,6 +12,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	"next-terminal/server/constant"
 	"next-terminal/server/global/session"
@@ -69,6 +70,43 @@ func (api SessionApi) SessionPagingEndpoint(c echo.Context) error {
 	})
 }
 
+// SessionCommandPagingEndpoint 查询 SSH 会话中执行的命令，start 与 end 的格式为 2006-01-02 15:04:05
+func (api SessionApi) SessionCommandPagingEndpoint(c echo.Context) error {
+	pageIndex, _ := strconv.Atoi(c.QueryParam("pageIndex"))
+	pageSize, _ := strconv.Atoi(c.QueryParam("pageSize"))
+	sessionId := c.QueryParam("sessionId")
+	username := c.QueryParam("username")
+	assetName := c.QueryParam("assetName")
+	command := c.QueryParam("command")
+	cwd := c.QueryParam("cwd")
+
+	var start, end time.Time
+	if c.QueryParam("start") != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", c.QueryParam("start"), time.Local)
+		if err != nil {
+			return Fail(c, -1, "开始时间格式不正确")
+		}
+		start = t
+	}
+	if c.QueryParam("end") != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", c.QueryParam("end"), time.Local)
+		if err != nil {
+			return Fail(c, -1, "结束时间格式不正确")
+		}
+		end = t
+	}
+
+	items, total, err := repository.SessionCommandRepository.Find(context.TODO(), pageIndex, pageSize, sessionId, username, assetName, command, cwd, start, end)
+	if err != nil {
+		return err
+	}
+
+	return Success(c, Map{
+		"total": total,
+		"items": items,
+	})
+}
+
 func (api SessionApi) SessionDeleteEndpoint(c echo.Context) error {
 	sessionIds := strings.Split(c.Param("id"), ",")
 	err := repository.SessionRepository.DeleteByIds(context.TODO(), sessionIds)
@@ -113,19 +151,26 @@ func (api SessionApi) SessionReviewedAllEndpoint(c echo.Context) error {
 func (api SessionApi) SessionConnectEndpoint(c echo.Context) error {
 	sessionId := c.Param("id")
 
+	o, err := repository.SessionRepository.FindById(context.TODO(), sessionId)
+	if err != nil {
+		return err
+	}
+	if err := service.ConcurrentLimitService.CheckConnect(o); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+
 	s := model.Session{}
 	s.ID = sessionId
 	s.Status = constant.Connected
-	s.ConnectedTime = utils.NowJsonTime()
+	// 重新连接时保留首次连接的时间，会话的最长时长从首次连接开始计算
+	if o.ConnectedTime.IsZero() {
+		s.ConnectedTime = utils.NowJsonTime()
+	}
 
 	if err := repository.SessionRepository.UpdateById(context.TODO(), &s, sessionId); err != nil {
 		return err
 	}
 
-	o, err := repository.SessionRepository.FindById(context.TODO(), sessionId)
-	if err != nil {
-		return err
-	}
 	asset, err := repository.AssetRepository.FindById(context.TODO(), o.AssetId)
 	if err != nil {
 		return err
@@ -178,7 +223,7 @@ func (api SessionApi) SessionCreateEndpoint(c echo.Context) error {
 
 	user, _ := GetCurrentAccount(c)
 
-	s, err := service.SessionService.Create(c.RealIP(), assetId, mode, user)
+	s, err := service.SessionService.Create(c.RealIP(), assetId, c.QueryParam("accountId"), mode, user)
 	if err != nil {
 		return err
 	}
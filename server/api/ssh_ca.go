@@ -0,0 +1,22 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"next-terminal/server/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SshCAApi struct{}
+
+// SshCAPublicKeyEndpoint 下载 SSH CA 公钥，目标资产的 sshd 通过 TrustedUserCAKeys 信任该公钥后即可使用证书登录
+func (api SshCAApi) SshCAPublicKeyEndpoint(c echo.Context) error {
+	publicKey, err := service.SshCAService.PublicKey(context.TODO())
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set("Content-Disposition", "attachment; filename=next-terminal-ca.pub")
+	return c.String(http.StatusOK, publicKey+"\n")
+}
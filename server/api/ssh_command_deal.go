@@ -1,6 +0,0 @@
-package api
-
-func DealCommand(enterKeys []rune) {
-	println(string(enterKeys))
-
-}
@@ -147,7 +147,7 @@ func (api StorageApi) PermissionCheck(c echo.Context, id string) error {
 		return err
 	}
 	account, _ := GetCurrentAccount(c)
-	if account.Type != constant.TypeAdmin {
+	if !service.RoleService.HasPermission(context.TODO(), account, constant.PermissionStorageEdit) {
 		if storage.Owner != account.ID {
 			return errors.New("您没有权限访问此地址 :(")
 		}
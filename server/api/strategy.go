@@ -2,10 +2,12 @@ package api
 
 import (
 	"context"
+	"errors"
 
 	"strconv"
 	"strings"
 
+	"next-terminal/server/constant"
 	"next-terminal/server/model"
 	"next-terminal/server/repository"
 	"next-terminal/server/utils"
@@ -47,6 +49,9 @@ func (api StrategyApi) StrategyCreateEndpoint(c echo.Context) error {
 	if err := c.Bind(&item); err != nil {
 		return err
 	}
+	if err := api.validate(item); err != nil {
+		return Fail(c, -1, err.Error())
+	}
 	item.ID = utils.UUID()
 	item.Created = utils.NowJsonTime()
 
@@ -74,9 +79,24 @@ func (api StrategyApi) StrategyUpdateEndpoint(c echo.Context) error {
 	if err := c.Bind(&item); err != nil {
 		return err
 	}
+	if err := api.validate(item); err != nil {
+		return Fail(c, -1, err.Error())
+	}
 
 	if err := repository.StrategyRepository.UpdateById(context.TODO(), &item, id); err != nil {
 		return err
 	}
 	return Success(c, "")
 }
+
+func (api StrategyApi) validate(item model.Strategy) error {
+	if item.MaxDuration < 0 || item.IdleTimeout < 0 {
+		return errors.New("会话时长限制不能小于0")
+	}
+	switch item.Clipboard {
+	case "", constant.ClipboardIn, constant.ClipboardOut, constant.ClipboardBoth:
+		return nil
+	default:
+		return errors.New("不支持的剪贴板方向：" + item.Clipboard)
+	}
+}
@@ -32,6 +32,11 @@ const (
 	Ping      = 4
 )
 
+const (
+	DualControlWaitingMessage   = "\r\n此会话需要审批人在线监控，请等待监控人加入后再操作\r\n"
+	DualControlTerminatedReason = "审批人已退出监控，会话已断开"
+)
+
 type WebTerminalApi struct {
 }
 
@@ -59,6 +64,9 @@ func (api WebTerminalApi) SshEndpoint(c echo.Context) error {
 	if err := api.permissionCheck(c, s.AssetId); err != nil {
 		return WriteMessage(ws, dto.NewMessage(Closed, err.Error()))
 	}
+	if err := service.ConcurrentLimitService.CheckConnect(s); err != nil {
+		return WriteMessage(ws, dto.NewMessage(Closed, err.Error()))
+	}
 
 	var (
 		username   = s.Username
@@ -89,7 +97,7 @@ func (api WebTerminalApi) SshEndpoint(c echo.Context) error {
 	recording := ""
 	var isRecording = false
 	property, err := repository.PropertyRepository.FindByName(ctx, guacd.EnableRecording)
-	if err == nil && property.Value == "true" {
+	if (err == nil && property.Value == "true") || s.ForceRecording == "1" {
 		isRecording = true
 	}
 
@@ -151,11 +159,20 @@ func (api WebTerminalApi) SshEndpoint(c echo.Context) error {
 		GuacdTunnel:  nil,
 		NextTerminal: nextTerminal,
 		Observer:     session.NewObserver(s.ID),
+		Supervisor:   session.NewSupervisor(attributes[constant.DualControl]),
+		Limit:        session.NewLimit(s.ConnectedTime.Time, s.MaxDuration, s.IdleTimeout),
 	}
 	go nextSession.Observer.Start()
 	session.GlobalSessionManager.Add <- nextSession
+	// 双人复核的会话在监控人加入前不转发输入，暂停时只提示一次
+	paused := false
+	if !nextSession.InputAllowed() {
+		paused = true
+		_ = WriteMessage(ws, dto.NewMessage(Data, DualControlWaitingMessage))
+	}
 
-	termHandler := NewTermHandler(sessionId, isRecording, ws, nextTerminal)
+	commandFilter := service.CommandRuleService.NewCommandFilter(s, nextTerminal.Recorder)
+	termHandler := NewTermHandler(sessionId, isRecording, ws, nextTerminal, commandFilter, nextSession.Limit)
 	termHandler.Start()
 	defer termHandler.Stop()
 
@@ -192,7 +209,19 @@ func (api WebTerminalApi) SshEndpoint(c echo.Context) error {
 			}
 			_ = repository.SessionRepository.UpdateWindowSizeById(ctx, winSize.Rows, winSize.Cols, sessionId)
 		case Data:
-			input := []byte(msg.Content)
+			if !nextSession.InputAllowed() {
+				if !paused {
+					paused = true
+					_ = WriteMessage(ws, dto.NewMessage(Data, DualControlWaitingMessage))
+				}
+				continue
+			}
+			paused = false
+			nextSession.Limit.Active()
+			input, message := commandFilter.Filter([]byte(msg.Content))
+			if message != "" {
+				_ = WriteMessage(ws, dto.NewMessage(Data, message))
+			}
 			_, err := nextTerminal.Write(input)
 			if err != nil {
 				service.SessionService.CloseSessionById(sessionId, TunnelClosed, "远程连接已关闭")
@@ -232,6 +261,9 @@ func (api WebTerminalApi) SshMonitorEndpoint(c echo.Context) error {
 	if nextSession == nil {
 		return WriteMessage(ws, dto.NewMessage(Closed, "会话已离线"))
 	}
+	if !service.SessionService.MonitorAllowed(s) {
+		return WriteMessage(ws, dto.NewMessage(Closed, "授权策略禁止监控此会话"))
+	}
 
 	obId := utils.UUID()
 	obSession := &session.Session{
@@ -242,12 +274,17 @@ func (api WebTerminalApi) SshMonitorEndpoint(c echo.Context) error {
 	}
 	nextSession.Observer.Add <- obSession
 	log.Debugf("会话 %v 观察者 %v 进入", sessionId, obId)
+	user, _ := GetCurrentAccount(c)
+	service.SessionService.SupervisorJoin(sessionId, obId, user)
 
 	for {
 		_, _, err := ws.ReadMessage()
 		if err != nil {
 			log.Debugf("会话 %v 观察者 %v 退出", sessionId, obId)
 			nextSession.Observer.Del <- obId
+			if service.SessionService.SupervisorLeave(sessionId, obId) {
+				service.SessionService.CloseSessionById(sessionId, ForcedDisconnect, DualControlTerminatedReason)
+			}
 			break
 		}
 	}
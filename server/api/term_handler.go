@@ -7,34 +7,39 @@ import (
 
 	"next-terminal/server/dto"
 	"next-terminal/server/global/session"
+	"next-terminal/server/service"
 	"next-terminal/server/term"
 
 	"github.com/gorilla/websocket"
 )
 
 type TermHandler struct {
-	sessionId    string
-	isRecording  bool
-	ws           *websocket.Conn
-	nextTerminal *term.NextTerminal
-	ctx          context.Context
-	cancel       context.CancelFunc
-	dataChan     chan rune
-	tick         *time.Ticker
+	sessionId     string
+	isRecording   bool
+	ws            *websocket.Conn
+	nextTerminal  *term.NextTerminal
+	commandFilter *service.CommandFilter
+	limit         *session.Limit
+	ctx           context.Context
+	cancel        context.CancelFunc
+	dataChan      chan rune
+	tick          *time.Ticker
 }
 
-func NewTermHandler(sessionId string, isRecording bool, ws *websocket.Conn, nextTerminal *term.NextTerminal) *TermHandler {
+func NewTermHandler(sessionId string, isRecording bool, ws *websocket.Conn, nextTerminal *term.NextTerminal, commandFilter *service.CommandFilter, limit *session.Limit) *TermHandler {
 	ctx, cancel := context.WithCancel(context.Background())
 	tick := time.NewTicker(time.Millisecond * time.Duration(60))
 	return &TermHandler{
-		sessionId:    sessionId,
-		isRecording:  isRecording,
-		ws:           ws,
-		nextTerminal: nextTerminal,
-		ctx:          ctx,
-		cancel:       cancel,
-		dataChan:     make(chan rune),
-		tick:         tick,
+		sessionId:     sessionId,
+		isRecording:   isRecording,
+		ws:            ws,
+		nextTerminal:  nextTerminal,
+		commandFilter: commandFilter,
+		limit:         limit,
+		ctx:           ctx,
+		cancel:        cancel,
+		dataChan:      make(chan rune),
+		tick:          tick,
 	}
 }
 
@@ -72,11 +77,22 @@ func (r TermHandler) writeToWebsocket() {
 		case <-r.ctx.Done():
 			return
 		case <-r.tick.C:
+			// 授权策略限制的会话时长
+			warning, reason := r.limit.Check(time.Now())
+			if reason != "" {
+				service.SessionService.CloseSessionById(r.sessionId, SessionLimitExceeded, reason)
+				return
+			}
+			if warning != "" {
+				_ = WriteMessage(r.ws, dto.NewMessage(Data, "\r\n"+warning+"\r\n"))
+			}
 			if len(buf) > 0 {
 				s := string(buf)
 				if err := WriteMessage(r.ws, dto.NewMessage(Data, s)); err != nil {
 					return
 				}
+				// 解析当前目录及命令退出码
+				r.commandFilter.Output(s)
 				// 录屏
 				if r.isRecording {
 					_ = r.nextTerminal.Recorder.WriteData(s)
@@ -20,7 +20,7 @@ func (userGroupApi UserGroupApi) UserGroupCreateEndpoint(c echo.Context) error {
 		return err
 	}
 
-	if _, err := service.UserGroupService.Create(context.TODO(), item.Name, item.Members); err != nil {
+	if _, err := service.UserGroupService.Create(context.TODO(), item.Name, item.MfaMethod, item.Members); err != nil {
 		return err
 	}
 
@@ -54,7 +54,12 @@ func (userGroupApi UserGroupApi) UserGroupUpdateEndpoint(c echo.Context) error {
 		return err
 	}
 
-	if err := service.UserGroupService.Update(id, item.Name, item.Members); err != nil {
+	account, _ := GetCurrentAccount(c)
+	if err := service.RoleService.CheckUserGroup(context.TODO(), account, id); err != nil {
+		return err
+	}
+
+	if err := service.UserGroupService.Update(id, item.Name, item.MfaMethod, item.Members); err != nil {
 		return err
 	}
 
@@ -63,9 +68,13 @@ func (userGroupApi UserGroupApi) UserGroupUpdateEndpoint(c echo.Context) error {
 
 func (userGroupApi UserGroupApi) UserGroupDeleteEndpoint(c echo.Context) error {
 	ids := c.Param("id")
+	account, _ := GetCurrentAccount(c)
 	split := strings.Split(ids, ",")
 	for i := range split {
 		userId := split[i]
+		if err := service.RoleService.CheckUserGroup(context.TODO(), account, userId); err != nil {
+			return err
+		}
 		if err := service.UserGroupService.DeleteById(userId); err != nil {
 			return err
 		}
@@ -2,14 +2,15 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
+	"next-terminal/server/constant"
 	"next-terminal/server/model"
 	"next-terminal/server/repository"
 	"next-terminal/server/service"
-	"next-terminal/server/utils"
 
 	"github.com/labstack/echo/v4"
 )
@@ -22,6 +23,10 @@ func (userApi UserApi) UserCreateEndpoint(c echo.Context) (err error) {
 		return err
 	}
 
+	if err := userApi.AdminCheck(c, item.Type); err != nil {
+		return err
+	}
+
 	if err := service.UserService.CreateUser(item); err != nil {
 		return err
 	}
@@ -63,6 +68,13 @@ func (userApi UserApi) UserUpdateEndpoint(c echo.Context) error {
 		return err
 	}
 
+	if err := userApi.PermissionCheck(c, id); err != nil {
+		return err
+	}
+	if err := userApi.AdminCheck(c, item.Type); err != nil {
+		return err
+	}
+
 	if err := service.UserService.UpdateUser(id, item); err != nil {
 		return err
 	}
@@ -77,6 +89,9 @@ func (userApi UserApi) UserUpdateStatusEndpoint(c echo.Context) error {
 	if account.ID == id {
 		return Fail(c, -1, "不能操作自身账户")
 	}
+	if err := userApi.PermissionCheck(c, id); err != nil {
+		return err
+	}
 
 	if err := service.UserService.UpdateStatusById(id, status); err != nil {
 		return err
@@ -97,6 +112,9 @@ func (userApi UserApi) UserDeleteEndpoint(c echo.Context) error {
 		if account.ID == userId {
 			return Fail(c, -1, "不允许删除自身账户")
 		}
+		if err := userApi.PermissionCheck(c, userId); err != nil {
+			return err
+		}
 		if err := service.UserService.DeleteUserById(userId); err != nil {
 			return err
 		}
@@ -127,19 +145,18 @@ func (userApi UserApi) UserChangePasswordEndpoint(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-
-	passwd, err := utils.Encoder.Encode([]byte(password))
-	if err != nil {
-		return err
-	}
-	u := &model.User{
-		Password: string(passwd),
-		ID:       id,
+	if user.Source == constant.SourceLdap {
+		return Fail(c, -1, "LDAP用户请到目录服务器修改密码")
 	}
-	if err := repository.UserRepository.Update(context.TODO(), u); err != nil {
+	if err := userApi.PermissionCheck(c, id); err != nil {
 		return err
 	}
 
+	// 管理员设置的密码需要用户在下次登录时修改
+	if err := service.PasswordPolicyService.ChangePassword(context.TODO(), user, password, true); err != nil {
+		return Fail(c, -1, err.Error())
+	}
+
 	if user.Mail != "" {
 		subject := "密码修改通知"
 		text := fmt.Sprintf(`您好，%s。
@@ -153,6 +170,9 @@ func (userApi UserApi) UserChangePasswordEndpoint(c echo.Context) error {
 
 func (userApi UserApi) UserResetTotpEndpoint(c echo.Context) error {
 	id := c.Param("id")
+	if err := userApi.PermissionCheck(c, id); err != nil {
+		return err
+	}
 	u := &model.User{
 		TOTPSecret: "-",
 		ID:         id,
@@ -160,5 +180,35 @@ func (userApi UserApi) UserResetTotpEndpoint(c echo.Context) error {
 	if err := repository.UserRepository.Update(context.TODO(), u); err != nil {
 		return err
 	}
+	if err := repository.RecoveryCodeRepository.DeleteByUserId(context.TODO(), id); err != nil {
+		return err
+	}
 	return Success(c, "")
 }
+
+// UserUnlockEndpoint 解除因登录失败次数过多导致的账户锁定
+func (userApi UserApi) UserUnlockEndpoint(c echo.Context) error {
+	id := c.Param("id")
+	if err := userApi.PermissionCheck(c, id); err != nil {
+		return err
+	}
+	if err := service.LoginLockService.Unlock(context.TODO(), id); err != nil {
+		return err
+	}
+	return Success(c, "")
+}
+
+// PermissionCheck 只有管理员可以操作管理员账户，非管理员不能操作拥有更多权限的用户
+func (userApi UserApi) PermissionCheck(c echo.Context, id string) error {
+	account, _ := GetCurrentAccount(c)
+	return service.RoleService.CheckUser(context.TODO(), account, id)
+}
+
+// AdminCheck 只有管理员可以将账户设置为管理员
+func (userApi UserApi) AdminCheck(c echo.Context, userType string) error {
+	account, _ := GetCurrentAccount(c)
+	if userType == constant.TypeAdmin && account.Type != constant.TypeAdmin {
+		return errors.New("您没有权限设置管理员账户")
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"next-terminal/server/constant"
 	"next-terminal/server/service"
 	"next-terminal/server/sshd"
+	"next-terminal/server/task"
 
 	"github.com/labstack/echo/v4"
 )
@@ -98,6 +99,7 @@ func Run() error {
 		panic(err)
 	}
 	app.Server = setupRoutes()
+	task.NewTicker().SetupTicker()
 
 	if config.GlobalCfg.Debug {
 		jsonBytes, err := json.MarshalIndent(config.GlobalCfg, "", "    ")
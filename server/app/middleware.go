@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"next-terminal/server/dto"
 	"next-terminal/server/global/cache"
 	"next-terminal/server/global/security"
+	"next-terminal/server/service"
 	"next-terminal/server/utils"
 
 	"github.com/labstack/echo/v4"
@@ -86,7 +88,7 @@ func TcpWall(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
-var anonymousUrls = []string{"/login", "/static", "/favicon.ico", "/logo.svg", "/asciinema"}
+var anonymousUrls = []string{"/login", "/saml", "/static", "/favicon.ico", "/logo.svg", "/asciinema"}
 
 func Auth(next echo.HandlerFunc) echo.HandlerFunc {
 
@@ -108,14 +110,35 @@ func Auth(next echo.HandlerFunc) echo.HandlerFunc {
 			return api.Fail(c, 401, "您的登录信息已失效，请重新登录后再试。")
 		}
 
-		v, found := cache.TokenManager.Get(token)
-		if !found {
-			return api.Fail(c, 401, "您的登录信息已失效，请重新登录后再试。")
+		var authorization dto.Authorization
+		if v, found := cache.TokenManager.Get(token); found {
+			authorization = v.(dto.Authorization)
+		} else {
+			// 授权令牌只保存了摘要，不在缓存中时需要重新校验
+			accessToken, err := service.AccessTokenService.Authenticate(token)
+			if err != nil {
+				return api.Fail(c, 401, "您的登录信息已失效，请重新登录后再试。")
+			}
+			authorization = *accessToken
 		}
 
-		authorization := v.(dto.Authorization)
+		if strings.EqualFold(constant.AccessToken, authorization.Type) {
+			if !allowedByScopes(authorization.Scopes, c.Request().Method, c.Path()) {
+				return api.Fail(c, 403, "授权令牌的权限范围不允许访问该接口")
+			}
+			if err := service.LoginPolicyService.Check(context.TODO(), *authorization.User, c.RealIP()); err != nil {
+				return api.Fail(c, 403, err.Error())
+			}
+			service.AccessTokenService.Used(token, c.RealIP())
+		}
 
 		if strings.EqualFold(constant.LoginToken, authorization.Type) {
+			// 离开允许登录的时间段或来源IP发生变化时强制下线
+			if err := service.LoginPolicyService.Check(context.TODO(), *authorization.User, c.RealIP()); err != nil {
+				service.UserService.Logout(token)
+				_ = service.UserService.SaveLoginLog(c.RealIP(), c.Request().UserAgent(), authorization.User.Username, false, authorization.Remember, "", err.Error())
+				return api.Fail(c, 401, err.Error())
+			}
 			if authorization.Remember {
 				// 记住登录有效期两周
 				cache.TokenManager.Set(token, authorization, cache.RememberMeExpiration)
@@ -128,18 +151,21 @@ func Auth(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
-func Admin(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
+// Permission 校验当前用户是否拥有指定权限，管理员拥有全部权限
+func Permission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
 
-		account, found := api.GetCurrentAccount(c)
-		if !found {
-			return api.Fail(c, 401, "您的登录信息已失效，请重新登录后再试。")
-		}
+			account, found := api.GetCurrentAccount(c)
+			if !found {
+				return api.Fail(c, 401, "您的登录信息已失效，请重新登录后再试。")
+			}
 
-		if account.Type != constant.TypeAdmin {
-			return api.Fail(c, 403, "permission denied")
-		}
+			if !service.RoleService.HasPermission(context.TODO(), account, permission) {
+				return api.Fail(c, 403, "permission denied")
+			}
 
-		return next(c)
+			return next(c)
+		}
 	}
 }
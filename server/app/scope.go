@@ -0,0 +1,51 @@
+package app
+
+import (
+	"next-terminal/server/constant"
+	"next-terminal/server/utils"
+)
+
+// scopeRoutes 授权令牌的权限范围允许访问的接口，使用 请求方法+路由定义 匹配
+var scopeRoutes = map[string][]string{
+	constant.ScopeAssetRead: {
+		"GET /assets", "GET /assets/paging", "GET /assets/:id", "POST /assets/:id/tcping",
+		"GET /account/assets", "GET /tags",
+	},
+	constant.ScopeAssetWrite: {
+		"POST /assets", "POST /assets/import", "PUT /assets/:id", "DELETE /assets/:id",
+	},
+	constant.ScopeSessionCreate: {
+		"GET /account/assets",
+		"POST /sessions", "POST /sessions/:id/connect", "GET /sessions/:id/tunnel", "GET /sessions/:id/ssh",
+		"POST /sessions/:id/resize", "GET /sessions/:id/stats",
+	},
+	constant.ScopeSessionRead: {
+		"GET /sessions/paging", "GET /sessions/commands", "GET /sessions/:id", "GET /sessions/:id/recording",
+	},
+	constant.ScopeCredentialRead: {
+		"GET /credentials", "GET /credentials/paging",
+	},
+	constant.ScopeUserRead: {
+		"GET /users/paging", "GET /users/:id", "GET /user-groups/paging", "GET /user-groups/:id",
+	},
+	constant.ScopeJobExec: {
+		"GET /jobs/paging", "GET /jobs/:id", "POST /jobs/:id/exec", "GET /jobs/:id/logs",
+	},
+}
+
+// allowedByScopes 未设置权限范围时不限制，令牌自身的信息始终允许访问
+func allowedByScopes(scopes []string, method, path string) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+	route := method + " " + path
+	if route == "GET /account/info" {
+		return true
+	}
+	for _, scope := range scopes {
+		if utils.Contains(scopeRoutes[scope], route) {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 
 	"next-terminal/server/api"
 	"next-terminal/server/config"
+	"next-terminal/server/constant"
 	"next-terminal/server/log"
 	"next-terminal/server/resource"
 
@@ -74,16 +75,38 @@ func setupRoutes() *echo.Echo {
 	ResourceSharerApi := new(api.ResourceSharerApi)
 	LoginLogApi := new(api.LoginLogApi)
 	PropertyApi := new(api.PropertyApi)
+	SshCAApi := new(api.SshCAApi)
 	OverviewApi := new(api.OverviewApi)
 	JobApi := new(api.JobApi)
 	SecurityApi := new(api.SecurityApi)
 	StorageApi := new(api.StorageApi)
 	StrategyApi := new(api.StrategyApi)
+	AssetAccountApi := new(api.AssetAccountApi)
+	RoleApi := new(api.RoleApi)
+	LoginPolicyApi := new(api.LoginPolicyApi)
+	AccessRequestApi := new(api.AccessRequestApi)
+	CredentialCheckoutApi := new(api.CredentialCheckoutApi)
+	CommandRuleApi := new(api.CommandRuleApi)
 	AccessGatewayApi := new(api.AccessGatewayApi)
 	BackupApi := new(api.BackupApi)
+	SamlApi := new(api.SamlApi)
 
 	e.POST("/login", accountApi.LoginEndpoint)
 	e.POST("/loginWithTotp", accountApi.LoginWithTotpEndpoint)
+	e.POST("/login/webauthn", accountApi.WebAuthnLoginBeginEndpoint)
+	e.POST("/login/email-otp", accountApi.EmailOtpSendEndpoint)
+	e.POST("/login/change-password", accountApi.ChangeExpiredPasswordEndpoint)
+	e.GET("/login/oidc", accountApi.OidcLoginEndpoint)
+	e.GET("/login/oidc/callback", accountApi.OidcCallbackEndpoint)
+
+	saml := e.Group("/saml")
+	{
+		saml.GET("/metadata", SamlApi.SamlMetadataEndpoint)
+		saml.GET("/login", SamlApi.SamlLoginEndpoint)
+		saml.POST("/acs", SamlApi.SamlAcsEndpoint)
+		saml.GET("/slo", SamlApi.SamlSloEndpoint)
+		saml.POST("/slo", SamlApi.SamlSloEndpoint)
+	}
 
 	account := e.Group("/account")
 	{
@@ -95,42 +118,60 @@ func setupRoutes() *echo.Echo {
 		account.GET("/reload-totp", accountApi.ReloadTOTPEndpoint)
 		account.POST("/reset-totp", accountApi.ResetTOTPEndpoint)
 		account.POST("/confirm-totp", accountApi.ConfirmTOTPEndpoint)
-		account.GET("/access-token", accountApi.AccessTokenGetEndpoint)
-		account.POST("/access-token", accountApi.AccessTokenGenEndpoint)
+		account.POST("/email-otp", accountApi.EmailOtpEndpoint)
+		account.GET("/recovery-codes", accountApi.RecoveryCodeCountEndpoint)
+		account.POST("/recovery-codes", accountApi.RecoveryCodeGenEndpoint)
+		account.GET("/access-tokens", accountApi.AccessTokenGetEndpoint)
+		account.GET("/access-tokens/scopes", accountApi.AccessTokenScopesEndpoint)
+		account.POST("/access-tokens", accountApi.AccessTokenGenEndpoint)
+		account.DELETE("/access-tokens/:id", accountApi.AccessTokenDeleteEndpoint)
+		account.GET("/authorized-keys", accountApi.AuthorizedKeyGetEndpoint)
+		account.POST("/authorized-keys", accountApi.AuthorizedKeyCreateEndpoint)
+		account.DELETE("/authorized-keys/:id", accountApi.AuthorizedKeyDeleteEndpoint)
+		account.GET("/webauthn-credentials", accountApi.WebAuthnCredentialGetEndpoint)
+		account.POST("/webauthn-credentials/begin", accountApi.WebAuthnRegistrationBeginEndpoint)
+		account.POST("/webauthn-credentials/finish", accountApi.WebAuthnRegistrationFinishEndpoint)
+		account.DELETE("/webauthn-credentials/:id", accountApi.WebAuthnCredentialDeleteEndpoint)
 	}
 
-	users := e.Group("/users", Admin)
+	users := e.Group("/users")
 	{
-		users.POST("", UserApi.UserCreateEndpoint)
-		users.GET("/paging", UserApi.UserPagingEndpoint)
-		users.PUT("/:id", UserApi.UserUpdateEndpoint)
-		users.PATCH("/:id/status", UserApi.UserUpdateStatusEndpoint)
-		users.DELETE("/:id", UserApi.UserDeleteEndpoint)
-		users.GET("/:id", UserApi.UserGetEndpoint)
-		users.POST("/:id/change-password", UserApi.UserChangePasswordEndpoint)
-		users.POST("/:id/reset-totp", UserApi.UserResetTotpEndpoint)
+		users.POST("", UserApi.UserCreateEndpoint, Permission(constant.PermissionUserEdit))
+		users.GET("/paging", UserApi.UserPagingEndpoint, Permission(constant.PermissionUserRead))
+		users.PUT("/:id", UserApi.UserUpdateEndpoint, Permission(constant.PermissionUserEdit))
+		users.PATCH("/:id/status", UserApi.UserUpdateStatusEndpoint, Permission(constant.PermissionUserEdit))
+		users.DELETE("/:id", UserApi.UserDeleteEndpoint, Permission(constant.PermissionUserEdit))
+		users.GET("/:id", UserApi.UserGetEndpoint, Permission(constant.PermissionUserRead))
+		users.POST("/:id/change-password", UserApi.UserChangePasswordEndpoint, Permission(constant.PermissionUserEdit))
+		users.POST("/:id/reset-totp", UserApi.UserResetTotpEndpoint, Permission(constant.PermissionUserEdit))
+		users.POST("/:id/unlock", UserApi.UserUnlockEndpoint, Permission(constant.PermissionUserEdit))
 	}
 
-	userGroups := e.Group("/user-groups", Admin)
+	userGroups := e.Group("/user-groups")
 	{
-		userGroups.POST("", UserGroupApi.UserGroupCreateEndpoint)
-		userGroups.GET("/paging", UserGroupApi.UserGroupPagingEndpoint)
-		userGroups.PUT("/:id", UserGroupApi.UserGroupUpdateEndpoint)
-		userGroups.DELETE("/:id", UserGroupApi.UserGroupDeleteEndpoint)
-		userGroups.GET("/:id", UserGroupApi.UserGroupGetEndpoint)
+		userGroups.POST("", UserGroupApi.UserGroupCreateEndpoint, Permission(constant.PermissionUserGroupEdit))
+		userGroups.GET("/paging", UserGroupApi.UserGroupPagingEndpoint, Permission(constant.PermissionUserGroupRead))
+		userGroups.PUT("/:id", UserGroupApi.UserGroupUpdateEndpoint, Permission(constant.PermissionUserGroupEdit))
+		userGroups.DELETE("/:id", UserGroupApi.UserGroupDeleteEndpoint, Permission(constant.PermissionUserGroupEdit))
+		userGroups.GET("/:id", UserGroupApi.UserGroupGetEndpoint, Permission(constant.PermissionUserGroupRead))
 	}
 
-	assets := e.Group("/assets", Admin)
+	assets := e.Group("/assets")
 	{
-		assets.GET("", AssetApi.AssetAllEndpoint)
-		assets.POST("", AssetApi.AssetCreateEndpoint)
-		assets.POST("/import", AssetApi.AssetImportEndpoint)
-		assets.GET("/paging", AssetApi.AssetPagingEndpoint)
-		assets.POST("/:id/tcping", AssetApi.AssetTcpingEndpoint)
-		assets.PUT("/:id", AssetApi.AssetUpdateEndpoint)
-		assets.GET("/:id", AssetApi.AssetGetEndpoint)
-		assets.DELETE("/:id", AssetApi.AssetDeleteEndpoint)
-		assets.POST("/:id/change-owner", AssetApi.AssetChangeOwnerEndpoint)
+		assets.GET("", AssetApi.AssetAllEndpoint, Permission(constant.PermissionAssetRead))
+		assets.POST("", AssetApi.AssetCreateEndpoint, Permission(constant.PermissionAssetEdit))
+		assets.POST("/import", AssetApi.AssetImportEndpoint, Permission(constant.PermissionAssetEdit))
+		assets.GET("/paging", AssetApi.AssetPagingEndpoint, Permission(constant.PermissionAssetRead))
+		assets.POST("/:id/tcping", AssetApi.AssetTcpingEndpoint, Permission(constant.PermissionAssetRead))
+		assets.PUT("/:id", AssetApi.AssetUpdateEndpoint, Permission(constant.PermissionAssetEdit))
+		assets.GET("/:id", AssetApi.AssetGetEndpoint, Permission(constant.PermissionAssetRead))
+		assets.DELETE("/:id", AssetApi.AssetDeleteEndpoint, Permission(constant.PermissionAssetEdit))
+		assets.POST("/:id/change-owner", AssetApi.AssetChangeOwnerEndpoint, Permission(constant.PermissionAssetEdit))
+		assets.GET("/:id/accounts", AssetAccountApi.AssetAccountAllEndpoint, Permission(constant.PermissionAssetRead))
+		assets.POST("/:id/accounts", AssetAccountApi.AssetAccountCreateEndpoint, Permission(constant.PermissionAssetEdit))
+		assets.GET("/:id/accounts/:accountId", AssetAccountApi.AssetAccountGetEndpoint, Permission(constant.PermissionAssetRead))
+		assets.PUT("/:id/accounts/:accountId", AssetAccountApi.AssetAccountUpdateEndpoint, Permission(constant.PermissionAssetEdit))
+		assets.DELETE("/:id/accounts/:accountId", AssetAccountApi.AssetAccountDeleteEndpoint, Permission(constant.PermissionAssetEdit))
 	}
 
 	e.GET("/tags", AssetApi.AssetTagsEndpoint)
@@ -143,38 +184,44 @@ func setupRoutes() *echo.Echo {
 		commands.PUT("/:id", CommandApi.CommandUpdateEndpoint)
 		commands.DELETE("/:id", CommandApi.CommandDeleteEndpoint)
 		commands.GET("/:id", CommandApi.CommandGetEndpoint)
-		commands.POST("/:id/change-owner", CommandApi.CommandChangeOwnerEndpoint, Admin)
+		commands.POST("/:id/change-owner", CommandApi.CommandChangeOwnerEndpoint, Permission(constant.PermissionCommandEdit))
 	}
 
-	credentials := e.Group("/credentials", Admin)
+	credentials := e.Group("/credentials")
 	{
-		credentials.GET("", CredentialApi.CredentialAllEndpoint)
-		credentials.GET("/paging", CredentialApi.CredentialPagingEndpoint)
-		credentials.POST("", CredentialApi.CredentialCreateEndpoint)
-		credentials.PUT("/:id", CredentialApi.CredentialUpdateEndpoint)
-		credentials.DELETE("/:id", CredentialApi.CredentialDeleteEndpoint)
-		credentials.GET("/:id", CredentialApi.CredentialGetEndpoint)
-		credentials.POST("/:id/change-owner", CredentialApi.CredentialChangeOwnerEndpoint)
+		credentials.GET("", CredentialApi.CredentialAllEndpoint, Permission(constant.PermissionCredentialRead))
+		credentials.GET("/paging", CredentialApi.CredentialPagingEndpoint, Permission(constant.PermissionCredentialRead))
+		credentials.POST("", CredentialApi.CredentialCreateEndpoint, Permission(constant.PermissionCredentialEdit))
+		credentials.POST("/generate", CredentialApi.CredentialGenerateEndpoint, Permission(constant.PermissionCredentialEdit))
+		credentials.PUT("/:id", CredentialApi.CredentialUpdateEndpoint, Permission(constant.PermissionCredentialEdit))
+		credentials.DELETE("/:id", CredentialApi.CredentialDeleteEndpoint, Permission(constant.PermissionCredentialEdit))
+		credentials.GET("/:id", CredentialApi.CredentialGetEndpoint, Permission(constant.PermissionCredentialView))
+		credentials.POST("/:id/change-owner", CredentialApi.CredentialChangeOwnerEndpoint, Permission(constant.PermissionCredentialEdit))
+		credentials.GET("/:id/public-key", CredentialApi.CredentialPublicKeyEndpoint, Permission(constant.PermissionCredentialView))
+		credentials.POST("/:id/deploy", CredentialApi.CredentialDeployEndpoint, Permission(constant.PermissionCredentialEdit))
+		credentials.POST("/:id/revoke", CredentialApi.CredentialRevokeEndpoint, Permission(constant.PermissionCredentialEdit))
 	}
 
 	sessions := e.Group("/sessions")
 	{
-		sessions.GET("/paging", Admin(SessionApi.SessionPagingEndpoint))
-		sessions.POST("/:id/disconnect", Admin(SessionApi.SessionDisconnectEndpoint))
-		sessions.DELETE("/:id", Admin(SessionApi.SessionDeleteEndpoint))
-		sessions.GET("/:id/recording", Admin(SessionApi.SessionRecordingEndpoint))
-		sessions.GET("/:id", Admin(SessionApi.SessionGetEndpoint))
-		sessions.POST("/:id/reviewed", Admin(SessionApi.SessionReviewedEndpoint))
-		sessions.POST("/:id/unreviewed", Admin(SessionApi.SessionUnViewedEndpoint))
-		sessions.POST("/clear", Admin(SessionApi.SessionClearEndpoint))
-		sessions.POST("/reviewed", Admin(SessionApi.SessionReviewedAllEndpoint))
+		sessions.GET("/paging", SessionApi.SessionPagingEndpoint, Permission(constant.PermissionSessionRead))
+		sessions.GET("/commands", SessionApi.SessionCommandPagingEndpoint, Permission(constant.PermissionSessionRead))
+		sessions.POST("/:id/disconnect", SessionApi.SessionDisconnectEndpoint, Permission(constant.PermissionSessionDisconnect))
+		sessions.DELETE("/:id", SessionApi.SessionDeleteEndpoint, Permission(constant.PermissionSessionDelete))
+		sessions.GET("/:id/recording", SessionApi.SessionRecordingEndpoint, Permission(constant.PermissionSessionRead))
+		sessions.GET("/:id", SessionApi.SessionGetEndpoint, Permission(constant.PermissionSessionRead))
+		sessions.POST("/:id/reviewed", SessionApi.SessionReviewedEndpoint, Permission(constant.PermissionSessionReview))
+		sessions.POST("/:id/unreviewed", SessionApi.SessionUnViewedEndpoint, Permission(constant.PermissionSessionReview))
+		sessions.POST("/clear", SessionApi.SessionClearEndpoint, Permission(constant.PermissionSessionDelete))
+		sessions.POST("/reviewed", SessionApi.SessionReviewedAllEndpoint, Permission(constant.PermissionSessionReview))
 
 		sessions.POST("", SessionApi.SessionCreateEndpoint)
+		sessions.GET("/accounts", AssetAccountApi.AssetAccountAllowedEndpoint)
 		sessions.POST("/:id/connect", SessionApi.SessionConnectEndpoint)
 		sessions.GET("/:id/tunnel", guacamoleApi.Guacamole)
-		sessions.GET("/:id/tunnel-monitor", guacamoleApi.GuacamoleMonitor)
+		sessions.GET("/:id/tunnel-monitor", guacamoleApi.GuacamoleMonitor, Permission(constant.PermissionSessionMonitor))
 		sessions.GET("/:id/ssh", webTerminalApi.SshEndpoint)
-		sessions.GET("/:id/ssh-monitor", webTerminalApi.SshMonitorEndpoint)
+		sessions.GET("/:id/ssh-monitor", webTerminalApi.SshMonitorEndpoint, Permission(constant.PermissionSessionMonitor))
 		sessions.POST("/:id/resize", SessionApi.SessionResizeEndpoint)
 		sessions.GET("/:id/stats", SessionApi.SessionStatsEndpoint)
 
@@ -187,63 +234,124 @@ func setupRoutes() *echo.Echo {
 		sessions.POST("/:id/rename", SessionApi.SessionRenameEndpoint)
 	}
 
-	resourceSharers := e.Group("/resource-sharers", Admin)
+	roles := e.Group("/roles")
+	{
+		roles.GET("", RoleApi.RoleAllEndpoint, Permission(constant.PermissionRoleRead))
+		roles.GET("/paging", RoleApi.RolePagingEndpoint, Permission(constant.PermissionRoleRead))
+		roles.GET("/permissions", RoleApi.RolePermissionsEndpoint, Permission(constant.PermissionRoleRead))
+		roles.POST("", RoleApi.RoleCreateEndpoint, Permission(constant.PermissionRoleEdit))
+		roles.PUT("/:id", RoleApi.RoleUpdateEndpoint, Permission(constant.PermissionRoleEdit))
+		roles.DELETE("/:id", RoleApi.RoleDeleteEndpoint, Permission(constant.PermissionRoleEdit))
+		roles.GET("/:id", RoleApi.RoleGetEndpoint, Permission(constant.PermissionRoleRead))
+	}
+
+	resourceSharers := e.Group("/resource-sharers", Permission(constant.PermissionAssetAuthorize))
 	{
 		resourceSharers.GET("", ResourceSharerApi.RSGetSharersEndPoint)
 		resourceSharers.POST("/remove-resources", ResourceSharerApi.ResourceRemoveByUserIdAssignEndPoint)
 		resourceSharers.POST("/add-resources", ResourceSharerApi.ResourceAddByUserIdAssignEndPoint)
 	}
 
-	loginLogs := e.Group("login-logs", Admin)
+	loginLogs := e.Group("login-logs")
 	{
-		loginLogs.GET("/paging", LoginLogApi.LoginLogPagingEndpoint)
-		loginLogs.DELETE("/:id", LoginLogApi.LoginLogDeleteEndpoint)
-		loginLogs.POST("/clear", LoginLogApi.LoginLogClearEndpoint)
+		loginLogs.GET("/paging", LoginLogApi.LoginLogPagingEndpoint, Permission(constant.PermissionLoginLogRead))
+		loginLogs.DELETE("/:id", LoginLogApi.LoginLogDeleteEndpoint, Permission(constant.PermissionLoginLogDelete))
+		loginLogs.POST("/clear", LoginLogApi.LoginLogClearEndpoint, Permission(constant.PermissionLoginLogDelete))
 	}
 
-	properties := e.Group("properties", Admin)
+	properties := e.Group("properties")
 	{
-		properties.GET("", PropertyApi.PropertyGetEndpoint)
-		properties.PUT("", PropertyApi.PropertyUpdateEndpoint)
+		properties.GET("", PropertyApi.PropertyGetEndpoint, Permission(constant.PermissionPropertyRead))
+		properties.PUT("", PropertyApi.PropertyUpdateEndpoint, Permission(constant.PermissionPropertyEdit))
 	}
 
-	overview := e.Group("overview", Admin)
+	sshCA := e.Group("/ssh-ca")
+	{
+		sshCA.GET("/public-key", SshCAApi.SshCAPublicKeyEndpoint, Permission(constant.PermissionAssetRead))
+	}
+
+	overview := e.Group("overview", Permission(constant.PermissionOverviewRead))
 	{
 		overview.GET("/counter", OverviewApi.OverviewCounterEndPoint)
 		overview.GET("/asset", OverviewApi.OverviewAssetEndPoint)
 		overview.GET("/access", OverviewApi.OverviewAccessEndPoint)
 	}
 
-	jobs := e.Group("/jobs", Admin)
+	jobs := e.Group("/jobs")
+	{
+		jobs.POST("", JobApi.JobCreateEndpoint, Permission(constant.PermissionJobEdit))
+		jobs.GET("/paging", JobApi.JobPagingEndpoint, Permission(constant.PermissionJobRead))
+		jobs.PUT("/:id", JobApi.JobUpdateEndpoint, Permission(constant.PermissionJobEdit))
+		jobs.POST("/:id/change-status", JobApi.JobChangeStatusEndpoint, Permission(constant.PermissionJobEdit))
+		jobs.POST("/:id/exec", JobApi.JobExecEndpoint, Permission(constant.PermissionJobExec))
+		jobs.DELETE("/:id", JobApi.JobDeleteEndpoint, Permission(constant.PermissionJobEdit))
+		jobs.GET("/:id", JobApi.JobGetEndpoint, Permission(constant.PermissionJobRead))
+		jobs.GET("/:id/logs", JobApi.JobGetLogsEndpoint, Permission(constant.PermissionJobRead))
+		jobs.DELETE("/:id/logs", JobApi.JobDeleteLogsEndpoint, Permission(constant.PermissionJobEdit))
+	}
+
+	securities := e.Group("/securities")
+	{
+		securities.POST("", SecurityApi.SecurityCreateEndpoint, Permission(constant.PermissionSecurityEdit))
+		securities.GET("/paging", SecurityApi.SecurityPagingEndpoint, Permission(constant.PermissionSecurityRead))
+		securities.PUT("/:id", SecurityApi.SecurityUpdateEndpoint, Permission(constant.PermissionSecurityEdit))
+		securities.DELETE("/:id", SecurityApi.SecurityDeleteEndpoint, Permission(constant.PermissionSecurityEdit))
+		securities.GET("/:id", SecurityApi.SecurityGetEndpoint, Permission(constant.PermissionSecurityRead))
+	}
+
+	loginPolicies := e.Group("/login-policies")
+	{
+		loginPolicies.GET("/paging", LoginPolicyApi.LoginPolicyPagingEndpoint, Permission(constant.PermissionSecurityRead))
+		loginPolicies.POST("", LoginPolicyApi.LoginPolicyCreateEndpoint, Permission(constant.PermissionSecurityEdit))
+		loginPolicies.PUT("/:id", LoginPolicyApi.LoginPolicyUpdateEndpoint, Permission(constant.PermissionSecurityEdit))
+		loginPolicies.DELETE("/:id", LoginPolicyApi.LoginPolicyDeleteEndpoint, Permission(constant.PermissionSecurityEdit))
+		loginPolicies.GET("/:id", LoginPolicyApi.LoginPolicyGetEndpoint, Permission(constant.PermissionSecurityRead))
+	}
+
+	commandRules := e.Group("/command-rules")
+	{
+		commandRules.GET("/paging", CommandRuleApi.CommandRulePagingEndpoint, Permission(constant.PermissionCommandRuleRead))
+		commandRules.GET("/records/paging", CommandRuleApi.CommandRuleRecordPagingEndpoint, Permission(constant.PermissionCommandRuleRead))
+		commandRules.POST("", CommandRuleApi.CommandRuleCreateEndpoint, Permission(constant.PermissionCommandRuleEdit))
+		commandRules.PUT("/:id", CommandRuleApi.CommandRuleUpdateEndpoint, Permission(constant.PermissionCommandRuleEdit))
+		commandRules.DELETE("/:id", CommandRuleApi.CommandRuleDeleteEndpoint, Permission(constant.PermissionCommandRuleEdit))
+		commandRules.GET("/:id", CommandRuleApi.CommandRuleGetEndpoint, Permission(constant.PermissionCommandRuleRead))
+	}
+
+	// 审批权限由资产所有者及审批用户组决定，在服务中校验
+	accessRequests := e.Group("/access-requests")
 	{
-		jobs.POST("", JobApi.JobCreateEndpoint)
-		jobs.GET("/paging", JobApi.JobPagingEndpoint)
-		jobs.PUT("/:id", JobApi.JobUpdateEndpoint)
-		jobs.POST("/:id/change-status", JobApi.JobChangeStatusEndpoint)
-		jobs.POST("/:id/exec", JobApi.JobExecEndpoint)
-		jobs.DELETE("/:id", JobApi.JobDeleteEndpoint)
-		jobs.GET("/:id", JobApi.JobGetEndpoint)
-		jobs.GET("/:id/logs", JobApi.JobGetLogsEndpoint)
-		jobs.DELETE("/:id/logs", JobApi.JobDeleteLogsEndpoint)
+		accessRequests.GET("/paging", AccessRequestApi.AccessRequestPagingEndpoint)
+		accessRequests.GET("/assets", AccessRequestApi.AccessRequestAssetsEndpoint)
+		accessRequests.POST("", AccessRequestApi.AccessRequestCreateEndpoint)
+		accessRequests.POST("/:id/approve", AccessRequestApi.AccessRequestApproveEndpoint)
+		accessRequests.POST("/:id/deny", AccessRequestApi.AccessRequestDenyEndpoint)
+		accessRequests.POST("/:id/cancel", AccessRequestApi.AccessRequestCancelEndpoint)
+		accessRequests.POST("/:id/revoke", AccessRequestApi.AccessRequestRevokeEndpoint)
+		accessRequests.GET("/:id/logs", AccessRequestApi.AccessRequestLogsEndpoint)
 	}
 
-	securities := e.Group("/securities", Admin)
+	// 借出权限由凭证所有者、授权及审批用户组决定，在服务中校验
+	credentialCheckouts := e.Group("/credential-checkouts")
 	{
-		securities.POST("", SecurityApi.SecurityCreateEndpoint)
-		securities.GET("/paging", SecurityApi.SecurityPagingEndpoint)
-		securities.PUT("/:id", SecurityApi.SecurityUpdateEndpoint)
-		securities.DELETE("/:id", SecurityApi.SecurityDeleteEndpoint)
-		securities.GET("/:id", SecurityApi.SecurityGetEndpoint)
+		credentialCheckouts.GET("/paging", CredentialCheckoutApi.CredentialCheckoutPagingEndpoint)
+		credentialCheckouts.POST("", CredentialCheckoutApi.CredentialCheckoutCreateEndpoint)
+		credentialCheckouts.POST("/:id/approve", CredentialCheckoutApi.CredentialCheckoutApproveEndpoint)
+		credentialCheckouts.POST("/:id/deny", CredentialCheckoutApi.CredentialCheckoutDenyEndpoint)
+		credentialCheckouts.POST("/:id/cancel", CredentialCheckoutApi.CredentialCheckoutCancelEndpoint)
+		credentialCheckouts.POST("/:id/reveal", CredentialCheckoutApi.CredentialCheckoutRevealEndpoint)
+		credentialCheckouts.POST("/:id/checkin", CredentialCheckoutApi.CredentialCheckoutCheckInEndpoint)
+		credentialCheckouts.GET("/:id/logs", CredentialCheckoutApi.CredentialCheckoutLogsEndpoint)
 	}
 
 	storages := e.Group("/storages")
 	{
-		storages.GET("/paging", StorageApi.StoragePagingEndpoint, Admin)
-		storages.POST("", StorageApi.StorageCreateEndpoint, Admin)
-		storages.DELETE("/:id", StorageApi.StorageDeleteEndpoint, Admin)
-		storages.PUT("/:id", StorageApi.StorageUpdateEndpoint, Admin)
-		storages.GET("/shares", StorageApi.StorageSharesEndpoint, Admin)
-		storages.GET("/:id", StorageApi.StorageGetEndpoint, Admin)
+		storages.GET("/paging", StorageApi.StoragePagingEndpoint, Permission(constant.PermissionStorageRead))
+		storages.POST("", StorageApi.StorageCreateEndpoint, Permission(constant.PermissionStorageEdit))
+		storages.DELETE("/:id", StorageApi.StorageDeleteEndpoint, Permission(constant.PermissionStorageEdit))
+		storages.PUT("/:id", StorageApi.StorageUpdateEndpoint, Permission(constant.PermissionStorageEdit))
+		storages.GET("/shares", StorageApi.StorageSharesEndpoint, Permission(constant.PermissionStorageRead))
+		storages.GET("/:id", StorageApi.StorageGetEndpoint, Permission(constant.PermissionStorageRead))
 
 		storages.POST("/:storageId/ls", StorageApi.StorageLsEndpoint)
 		storages.GET("/:storageId/download", StorageApi.StorageDownloadEndpoint)
@@ -254,30 +362,30 @@ func setupRoutes() *echo.Echo {
 		storages.POST("/:storageId/edit", StorageApi.StorageEditEndpoint)
 	}
 
-	strategies := e.Group("/strategies", Admin)
+	strategies := e.Group("/strategies")
 	{
-		strategies.GET("", StrategyApi.StrategyAllEndpoint)
-		strategies.GET("/paging", StrategyApi.StrategyPagingEndpoint)
-		strategies.POST("", StrategyApi.StrategyCreateEndpoint)
-		strategies.DELETE("/:id", StrategyApi.StrategyDeleteEndpoint)
-		strategies.PUT("/:id", StrategyApi.StrategyUpdateEndpoint)
+		strategies.GET("", StrategyApi.StrategyAllEndpoint, Permission(constant.PermissionStrategyRead))
+		strategies.GET("/paging", StrategyApi.StrategyPagingEndpoint, Permission(constant.PermissionStrategyRead))
+		strategies.POST("", StrategyApi.StrategyCreateEndpoint, Permission(constant.PermissionStrategyEdit))
+		strategies.DELETE("/:id", StrategyApi.StrategyDeleteEndpoint, Permission(constant.PermissionStrategyEdit))
+		strategies.PUT("/:id", StrategyApi.StrategyUpdateEndpoint, Permission(constant.PermissionStrategyEdit))
 	}
 
-	accessGateways := e.Group("/access-gateways", Admin)
+	accessGateways := e.Group("/access-gateways")
 	{
-		accessGateways.GET("", AccessGatewayApi.AccessGatewayAllEndpoint)
-		accessGateways.POST("", AccessGatewayApi.AccessGatewayCreateEndpoint)
-		accessGateways.GET("/paging", AccessGatewayApi.AccessGatewayPagingEndpoint)
-		accessGateways.PUT("/:id", AccessGatewayApi.AccessGatewayUpdateEndpoint)
-		accessGateways.DELETE("/:id", AccessGatewayApi.AccessGatewayDeleteEndpoint)
-		accessGateways.GET("/:id", AccessGatewayApi.AccessGatewayGetEndpoint)
-		accessGateways.POST("/:id/reconnect", AccessGatewayApi.AccessGatewayReconnectEndpoint)
+		accessGateways.GET("", AccessGatewayApi.AccessGatewayAllEndpoint, Permission(constant.PermissionAccessGatewayRead))
+		accessGateways.POST("", AccessGatewayApi.AccessGatewayCreateEndpoint, Permission(constant.PermissionAccessGatewayEdit))
+		accessGateways.GET("/paging", AccessGatewayApi.AccessGatewayPagingEndpoint, Permission(constant.PermissionAccessGatewayRead))
+		accessGateways.PUT("/:id", AccessGatewayApi.AccessGatewayUpdateEndpoint, Permission(constant.PermissionAccessGatewayEdit))
+		accessGateways.DELETE("/:id", AccessGatewayApi.AccessGatewayDeleteEndpoint, Permission(constant.PermissionAccessGatewayEdit))
+		accessGateways.GET("/:id", AccessGatewayApi.AccessGatewayGetEndpoint, Permission(constant.PermissionAccessGatewayRead))
+		accessGateways.POST("/:id/reconnect", AccessGatewayApi.AccessGatewayReconnectEndpoint, Permission(constant.PermissionAccessGatewayEdit))
 	}
 
-	backup := e.Group("/backup", Admin)
+	backup := e.Group("/backup")
 	{
-		backup.GET("/export", BackupApi.BackupExportEndpoint)
-		backup.POST("/import", BackupApi.BackupImportEndpoint)
+		backup.GET("/export", BackupApi.BackupExportEndpoint, Permission(constant.PermissionBackupExport))
+		backup.POST("/import", BackupApi.BackupImportEndpoint, Permission(constant.PermissionBackupImport))
 	}
 
 	return e
@@ -28,19 +28,17 @@ func (cli Cli) ResetPassword(username string) error {
 	if err != nil {
 		return err
 	}
-	password := "next-terminal"
-	passwd, err := utils.Encoder.Encode([]byte(password))
+	// 生成满足密码策略的随机密码，用户下次登录时需要修改
+	password, err := utils.GenPasswordWithPolicy(service.PasswordPolicyService.Policy())
 	if err != nil {
 		return err
 	}
-	u := &model.User{
-		Password: string(passwd),
-		ID:       user.ID,
-	}
-	if err := repository.UserRepository.Update(context.TODO(), u); err != nil {
+	if err := service.PasswordPolicyService.ChangePassword(context.TODO(), user, password, true); err != nil {
 		return err
 	}
-	log.Debugf("用户「%v」密码初始化为: %v", user.Username, password)
+	// 明文密码只输出到控制台，不写入日志文件
+	log.Infof("用户「%v」密码已重置", user.Username)
+	fmt.Printf("用户「%v」密码初始化为: %v\n", user.Username, password)
 	return nil
 }
 
@@ -56,6 +54,9 @@ func (cli Cli) ResetTotp(username string) error {
 	if err := repository.UserRepository.Update(context.TODO(), u); err != nil {
 		return err
 	}
+	if err := repository.RecoveryCodeRepository.DeleteByUserId(context.TODO(), user.ID); err != nil {
+		return err
+	}
 	log.Debugf("用户「%v」已重置TOTP", user.Username)
 	return nil
 }
@@ -37,19 +37,120 @@ const (
 	Custom     = "custom"      // 密码
 	PrivateKey = "private-key" // 密钥
 
+	AccountCredential  = "credential"  // 资产账号使用授权凭证
+	AccountCertificate = "certificate" // 资产账号使用 SSH CA 为每个会话签发的证书，用户名作为证书的 principal
+	DefaultAccount     = "default"     // 资产自身配置的账号
+
 	JobStatusRunning        = "running"                // 计划任务运行状态
 	JobStatusNotRunning     = "not-running"            // 计划任务未运行状态
 	FuncCheckAssetStatusJob = "check-asset-status-job" // 检测资产是否在线
 	FuncShellJob            = "shell-job"              // 执行Shell脚本
+	FuncLdapUserSyncJob     = "ldap-user-sync-job"     // 同步LDAP用户
+	FuncRotatePasswordJob   = "rotate-password-job"    // 轮换SSH资产密码
 	JobModeAll              = "all"                    // 全部资产
 	JobModeCustom           = "custom"                 // 自定义选择资产
 
+	RotateTargetAsset      = "asset"      // 轮换资产账号的密码
+	RotateTargetCredential = "credential" // 轮换授权凭证的密码，同时修改所有使用该凭证的SSH资产
+
 	SshMode      = "ssh-mode"      // ssh模式
+	DualControl  = "dual-control"  // 双人复核：需要审批人在线监控才能操作会话，值为监控人离开后的处理方式
 	MailHost     = "mail-host"     // 邮件服务器地址
 	MailPort     = "mail-port"     // 邮件服务器端口
 	MailUsername = "mail-username" // 邮件服务账号
 	MailPassword = "mail-password" // 邮件服务密码
 
+	EnableLdap            = "enable-ldap"             // 是否开启LDAP认证
+	LdapUrl               = "ldap-url"                // LDAP服务地址，例如 ldap://127.0.0.1:389
+	LdapBindDN            = "ldap-bind-dn"            // 查询目录使用的账号
+	LdapBindPassword      = "ldap-bind-password"      // 查询目录使用的密码
+	LdapBaseDN            = "ldap-base-dn"            // 用户查询的根节点
+	LdapUserFilter        = "ldap-user-filter"        // 用户过滤条件
+	LdapUserAttribute     = "ldap-user-attribute"     // 登录账号对应的属性
+	LdapNicknameAttribute = "ldap-nickname-attribute" // 昵称对应的属性
+	LdapMailAttribute     = "ldap-mail-attribute"     // 邮箱对应的属性
+	LdapGroupAttribute    = "ldap-group-attribute"    // 用户所属组对应的属性
+	LdapSkipVerify        = "ldap-skip-verify"        // 是否跳过TLS证书校验
+
+	EnableOidc        = "enable-oidc"         // 是否开启OIDC单点登录
+	OidcIssuer        = "oidc-issuer"         // 认证服务器地址
+	OidcClientId      = "oidc-client-id"      // 客户端ID
+	OidcClientSecret  = "oidc-client-secret"  // 客户端密钥
+	OidcRedirectUrl   = "oidc-redirect-url"   // 回调地址，为空时根据请求地址生成
+	OidcScopes        = "oidc-scopes"         // 申请的权限范围，多个使用空格分隔
+	OidcUsernameClaim = "oidc-username-claim" // 登录账号对应的声明
+	OidcNicknameClaim = "oidc-nickname-claim" // 昵称对应的声明
+	OidcMailClaim     = "oidc-mail-claim"     // 邮箱对应的声明
+	OidcGroupsClaim   = "oidc-groups-claim"   // 用户组对应的声明
+
+	EnableSaml            = "enable-saml"             // 是否开启SAML单点登录
+	SamlEntityId          = "saml-entity-id"          // 本服务的实体ID，为空时使用元数据地址
+	SamlRootUrl           = "saml-root-url"           // 本服务对外访问的地址，为空时根据请求地址生成
+	SamlIdpMetadata       = "saml-idp-metadata"       // 认证服务器元数据的地址或XML内容
+	SamlSpKey             = "saml-sp-key"             // 本服务签名使用的私钥，为空时自动生成
+	SamlSpCert            = "saml-sp-cert"            // 本服务签名使用的证书，为空时自动生成
+	SamlUsernameAttribute = "saml-username-attribute" // 登录账号对应的属性，为空时使用 NameID
+	SamlNicknameAttribute = "saml-nickname-attribute" // 昵称对应的属性
+	SamlMailAttribute     = "saml-mail-attribute"     // 邮箱对应的属性
+	SamlGroupsAttribute   = "saml-groups-attribute"   // 用户组对应的属性
+
+	SsoTrustIdpMfa = "sso-trust-idp-mfa" // 单点登录时信任认证服务器完成的双因素认证，为 false 时用户组要求双因素认证的用户无法使用单点登录
+
+	PasswordMinLength        = "password-min-length"        // 密码最小长度
+	PasswordRequireUppercase = "password-require-uppercase" // 密码必须包含大写字母
+	PasswordRequireLowercase = "password-require-lowercase" // 密码必须包含小写字母
+	PasswordRequireDigit     = "password-require-digit"     // 密码必须包含数字
+	PasswordRequireSpecial   = "password-require-special"   // 密码必须包含特殊字符
+	PasswordBannedList       = "password-banned-list"       // 禁止使用的密码，多个使用逗号或换行分隔
+	PasswordHistoryCount     = "password-history-count"     // 不允许与最近N次使用过的密码相同，0为不限制
+	PasswordMaxAge           = "password-max-age"           // 密码有效期（天），0为永不过期
+
+	LoginLockFailedCount = "login-lock-failed-count" // 连续登录失败多少次后锁定账户，0为不锁定
+	LoginLockDuration    = "login-lock-duration"     // 账户锁定时长（分钟），0为需要管理员手动解锁
+
+	LoginMaxConcurrent    = "login-max-concurrent"    // 每个用户同时在线的登录数，0为不限制
+	LoginConcurrentPolicy = "login-concurrent-policy" // 超出同时在线登录数时的处理方式：reject 拒绝新的登录，evict 下线最早的登录
+	SessionMaxPerUser     = "session-max-per-user"    // 每个用户同时连接的会话数，0为不限制
+	SessionMaxPerAsset    = "session-max-per-asset"   // 每个资产同时连接的会话数，0为不限制
+	LoginConcurrentReject = "reject"
+	LoginConcurrentEvict  = "evict"
+
+	ResourceSharerExpiryNotice = "resource-sharer-expiry-notice" // 授权到期前多少小时提醒资源所有者，0为不提醒
+
+	AccessRequestApproverGroup = "access-request-approver-group" // 可以审批访问申请的用户组ID，资产所有者及管理员始终可以审批
+	AccessRequestMaxDuration   = "access-request-max-duration"   // 访问申请的最长时长（分钟）
+
+	CredentialCheckoutApproval    = "credential-checkout-approval"     // 借出授权凭证是否需要审批，凭证所有者及管理员无需审批
+	CredentialCheckoutMaxDuration = "credential-checkout-max-duration" // 借出授权凭证的最长时长（分钟）
+
+	SshCAPrivateKey        = "ssh-ca-private-key"       // SSH CA 的私钥，加密存储，首次使用时生成
+	SshCertificateValidity = "ssh-certificate-validity" // SSH 证书的有效期（分钟），证书只用于建立连接
+
+	DualControlPause     = "pause"     // 双人复核：监控人离开后暂停操作，等待监控人重新加入
+	DualControlTerminate = "terminate" // 双人复核：监控人离开后断开会话
+
+	ClipboardIn   = "in"   // 授权策略：只允许从本地粘贴到远程
+	ClipboardOut  = "out"  // 授权策略：只允许从远程复制到本地
+	ClipboardBoth = "both" // 授权策略：允许双向复制粘贴
+
+	CommandAllow = "allow" // 命令拦截规则：放行
+	CommandDeny  = "deny"  // 命令拦截规则：拒绝执行
+	CommandAlert = "alert" // 命令拦截规则：放行并告警
+
+	AccessRequestPending   = "pending"   // 访问申请：待审批
+	AccessRequestApproved  = "approved"  // 访问申请：已批准
+	AccessRequestDenied    = "denied"    // 访问申请：已拒绝
+	AccessRequestCancelled = "cancelled" // 访问申请：已撤回
+	AccessRequestExpired   = "expired"   // 访问申请：授权已到期
+	AccessRequestRevoked   = "revoked"   // 访问申请：授权已被收回
+
+	CheckoutPending   = "pending"     // 凭证借出：待审批
+	CheckoutActive    = "checked-out" // 凭证借出：已借出，有效期内可以查看密码
+	CheckoutDenied    = "denied"      // 凭证借出：已拒绝
+	CheckoutCancelled = "cancelled"   // 凭证借出：已撤回
+	CheckoutReturned  = "returned"    // 凭证借出：已归还
+	CheckoutExpired   = "expired"     // 凭证借出：已到期
+
 	NoConnect    = "no_connect"   // 会话状态：未连接
 	Connecting   = "connecting"   // 会话状态：连接中
 	Connected    = "connected"    // 会话状态：已连接
@@ -63,10 +164,16 @@ const (
 	TypeAdmin = "admin" // 管理员
 
 	SourceLdap = "ldap" // 从LDAP同步的用户
+	SourceOidc = "oidc" // 通过OIDC登录自动创建的用户
+	SourceSaml = "saml" // 通过SAML登录自动创建的用户
 
 	StatusEnabled  = "enabled"
 	StatusDisabled = "disabled"
 
+	MfaTotp     = "totp"     // 双因素认证：认证器应用授权码
+	MfaEmail    = "email"    // 双因素认证：邮件验证码
+	MfaWebAuthn = "webauthn" // 双因素认证：WebAuthn认证器
+
 	SocksProxyEnable   = "socks-proxy-enable"
 	SocksProxyHost     = "socks-proxy-host"
 	SocksProxyPort     = "socks-proxy-port"
@@ -80,8 +187,8 @@ const (
 	Anonymous = "anonymous"
 )
 
-var SSHParameterNames = []string{guacd.FontName, guacd.FontSize, guacd.ColorScheme, guacd.Backspace, guacd.TerminalType, SshMode, SocksProxyEnable, SocksProxyHost, SocksProxyPort, SocksProxyUsername, SocksProxyPassword}
-var RDPParameterNames = []string{guacd.Domain, guacd.RemoteApp, guacd.RemoteAppDir, guacd.RemoteAppArgs, guacd.EnableDrive, guacd.DrivePath, guacd.ColorDepth, guacd.ForceLossless, guacd.PreConnectionId, guacd.PreConnectionBlob}
-var VNCParameterNames = []string{guacd.ColorDepth, guacd.Cursor, guacd.SwapRedBlue, guacd.DestHost, guacd.DestPort}
-var TelnetParameterNames = []string{guacd.FontName, guacd.FontSize, guacd.ColorScheme, guacd.Backspace, guacd.TerminalType, guacd.UsernameRegex, guacd.PasswordRegex, guacd.LoginSuccessRegex, guacd.LoginFailureRegex}
-var KubernetesParameterNames = []string{guacd.FontName, guacd.FontSize, guacd.ColorScheme, guacd.Backspace, guacd.TerminalType, guacd.Namespace, guacd.Pod, guacd.Container, guacd.UesSSL, guacd.ClientCert, guacd.ClientKey, guacd.CaCert, guacd.IgnoreCert}
+var SSHParameterNames = []string{guacd.FontName, guacd.FontSize, guacd.ColorScheme, guacd.Backspace, guacd.TerminalType, SshMode, SocksProxyEnable, SocksProxyHost, SocksProxyPort, SocksProxyUsername, SocksProxyPassword, DualControl}
+var RDPParameterNames = []string{guacd.Domain, guacd.RemoteApp, guacd.RemoteAppDir, guacd.RemoteAppArgs, guacd.EnableDrive, guacd.DrivePath, guacd.ColorDepth, guacd.ForceLossless, guacd.PreConnectionId, guacd.PreConnectionBlob, DualControl}
+var VNCParameterNames = []string{guacd.ColorDepth, guacd.Cursor, guacd.SwapRedBlue, guacd.DestHost, guacd.DestPort, DualControl}
+var TelnetParameterNames = []string{guacd.FontName, guacd.FontSize, guacd.ColorScheme, guacd.Backspace, guacd.TerminalType, guacd.UsernameRegex, guacd.PasswordRegex, guacd.LoginSuccessRegex, guacd.LoginFailureRegex, DualControl}
+var KubernetesParameterNames = []string{guacd.FontName, guacd.FontSize, guacd.ColorScheme, guacd.Backspace, guacd.TerminalType, guacd.Namespace, guacd.Pod, guacd.Container, guacd.UesSSL, guacd.ClientCert, guacd.ClientKey, guacd.CaCert, guacd.IgnoreCert, DualControl}
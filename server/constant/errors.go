@@ -3,5 +3,18 @@ package constant
 import "errors"
 
 var (
-	ErrNameAlreadyUsed = errors.New("name already used")
+	ErrNameAlreadyUsed     = errors.New("name already used")
+	ErrUsernameAlreadyUsed = errors.New("username already used by another source")
+	ErrLoginIpNotAllowed   = errors.New("不允许从当前IP登录")
+	ErrLoginTimeNotAllowed = errors.New("当前时间段不允许登录")
+
+	ErrAccessRequestNotPending  = errors.New("访问申请已处理")
+	ErrAccessRequestNotApproved = errors.New("访问申请未处于已批准状态")
+	ErrAccessRequestForbidden   = errors.New("没有审批该访问申请的权限")
+
+	ErrAccountNotAllowed = errors.New("您没有权限使用此资产账号")
+
+	ErrCheckoutNotPending = errors.New("借出申请已处理")
+	ErrCheckoutNotActive  = errors.New("授权凭证未处于借出状态")
+	ErrCheckoutForbidden  = errors.New("没有操作该借出记录的权限")
 )
@@ -0,0 +1,84 @@
+package constant
+
+// 权限标识，格式为 资源:操作，管理员拥有全部权限
+const (
+	PermissionUserRead          = "user:read"
+	PermissionUserEdit          = "user:edit"
+	PermissionUserGroupRead     = "user-group:read"
+	PermissionUserGroupEdit     = "user-group:edit"
+	PermissionRoleRead          = "role:read"
+	PermissionRoleEdit          = "role:edit"
+	PermissionAssetRead         = "asset:read"
+	PermissionAssetEdit         = "asset:edit"
+	PermissionAssetAuthorize    = "asset:authorize" // 授权用户或用户组访问资产
+	PermissionCommandEdit       = "command:edit"    // 管理其他用户的指令
+	PermissionCommandRuleRead   = "command-rule:read"
+	PermissionCommandRuleEdit   = "command-rule:edit"
+	PermissionCredentialRead    = "credential:read"
+	PermissionCredentialView    = "credential:view" // 查看授权凭证的密码、私钥等敏感信息
+	PermissionCredentialEdit    = "credential:edit"
+	PermissionSessionRead       = "session:read" // 查看会话及录屏
+	PermissionSessionMonitor    = "session:monitor"
+	PermissionSessionDisconnect = "session:disconnect"
+	PermissionSessionReview     = "session:review"
+	PermissionSessionDelete     = "session:delete"
+	PermissionLoginLogRead      = "login-log:read"
+	PermissionLoginLogDelete    = "login-log:delete"
+	PermissionPropertyRead      = "property:read"
+	PermissionPropertyEdit      = "property:edit"
+	PermissionOverviewRead      = "overview:read"
+	PermissionJobRead           = "job:read"
+	PermissionJobEdit           = "job:edit"
+	PermissionJobExec           = "job:exec"
+	PermissionSecurityRead      = "security:read"
+	PermissionSecurityEdit      = "security:edit"
+	PermissionStorageRead       = "storage:read"
+	PermissionStorageEdit       = "storage:edit" // 管理所有用户的磁盘空间
+	PermissionStrategyRead      = "strategy:read"
+	PermissionStrategyEdit      = "strategy:edit"
+	PermissionAccessGatewayRead = "access-gateway:read"
+	PermissionAccessGatewayEdit = "access-gateway:edit"
+	PermissionBackupExport      = "backup:export"
+	PermissionBackupImport      = "backup:import"
+)
+
+// Permissions 可以分配给角色的全部权限
+var Permissions = []string{
+	PermissionUserRead, PermissionUserEdit,
+	PermissionUserGroupRead, PermissionUserGroupEdit,
+	PermissionRoleRead, PermissionRoleEdit,
+	PermissionAssetRead, PermissionAssetEdit, PermissionAssetAuthorize,
+	PermissionCommandEdit,
+	PermissionCommandRuleRead, PermissionCommandRuleEdit,
+	PermissionCredentialRead, PermissionCredentialView, PermissionCredentialEdit,
+	PermissionSessionRead, PermissionSessionMonitor, PermissionSessionDisconnect, PermissionSessionReview, PermissionSessionDelete,
+	PermissionLoginLogRead, PermissionLoginLogDelete,
+	PermissionPropertyRead, PermissionPropertyEdit,
+	PermissionOverviewRead,
+	PermissionJobRead, PermissionJobEdit, PermissionJobExec,
+	PermissionSecurityRead, PermissionSecurityEdit,
+	PermissionStorageRead, PermissionStorageEdit,
+	PermissionStrategyRead, PermissionStrategyEdit,
+	PermissionAccessGatewayRead, PermissionAccessGatewayEdit,
+	PermissionBackupExport, PermissionBackupImport,
+}
+
+// 授权令牌的权限范围，未设置权限范围的令牌拥有用户的全部权限
+const (
+	ScopeAssetRead      = "asset:read"     // 只读访问资产
+	ScopeAssetWrite     = "asset:write"    // 新增、修改、删除资产
+	ScopeSessionCreate  = "session:create" // 创建并连接会话
+	ScopeSessionRead    = "session:read"   // 查看会话及录屏
+	ScopeCredentialRead = "credential:read"
+	ScopeUserRead       = "user:read"
+	ScopeJobExec        = "job:exec"
+)
+
+// AccessTokenScopes 可以分配给授权令牌的全部权限范围
+var AccessTokenScopes = []string{
+	ScopeAssetRead, ScopeAssetWrite,
+	ScopeSessionCreate, ScopeSessionRead,
+	ScopeCredentialRead,
+	ScopeUserRead,
+	ScopeJobExec,
+}
@@ -1,19 +1,26 @@
 package dto
 
-import "next-terminal/server/model"
+import (
+	"encoding/json"
+
+	"next-terminal/server/model"
+	"next-terminal/server/utils"
+)
 
 type Authorization struct {
 	Token    string
 	Remember bool
 	Type     string // LoginToken: 登录令牌, AccessToken: 授权令牌, ShareSession: 会话分享, AccessSession: 只允许访问特定的会话
 	User     *model.User
+	Scopes   []string // 授权令牌的权限范围，为空时不限制
 }
 
 type LoginAccount struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Remember bool   `json:"remember"`
-	TOTP     string `json:"totp"`
+	Username string          `json:"username"`
+	Password string          `json:"password"`
+	Remember bool            `json:"remember"`
+	TOTP     string          `json:"totp"`
+	WebAuthn json.RawMessage `json:"webauthn"` // 使用 WebAuthn 认证器代替双因素认证授权码
 }
 
 type ConfirmTOTP struct {
@@ -25,3 +32,24 @@ type ChangePassword struct {
 	NewPassword string `json:"newPassword"`
 	OldPassword string `json:"oldPassword"`
 }
+
+// ChangeExpiredPassword 密码过期时未登录状态下修改密码
+type ChangeExpiredPassword struct {
+	Username    string `json:"username"`
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+	TOTP        string `json:"totp"`
+}
+
+type AuthorizedKey struct {
+	Name        string          `json:"name"`
+	PublicKey   string          `json:"publicKey"`
+	Fingerprint string          `json:"fingerprint"` // 可选，填写后必须与公钥的指纹一致
+	Expired     *utils.JsonTime `json:"expired"`
+}
+
+type AccessToken struct {
+	Name    string          `json:"name"`
+	Scopes  []string        `json:"scopes"`
+	Expired *utils.JsonTime `json:"expired"` // 为空时永不过期
+}
@@ -1,7 +1,29 @@
 package dto
 
+import "next-terminal/server/utils"
+
 type UserGroup struct {
-	Id      string   `json:"id"`
-	Name    string   `json:"name"`
-	Members []string `json:"members"`
+	Id        string   `json:"id"`
+	Name      string   `json:"name"`
+	MfaMethod string   `json:"mfaMethod"`
+	Members   []string `json:"members"`
+}
+
+type Role struct {
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+	Users       []string `json:"users"`
+	UserGroups  []string `json:"userGroups"`
+}
+
+type LoginPolicy struct {
+	Id          string             `json:"id"`
+	Name        string             `json:"name"`
+	IPs         []string           `json:"ips"`
+	TimeZone    string             `json:"timeZone"`
+	TimePeriods []utils.TimePeriod `json:"timePeriods"`
+	Enabled     bool               `json:"enabled"`
+	Users       []string           `json:"users"`
+	UserGroups  []string           `json:"userGroups"`
 }
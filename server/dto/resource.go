@@ -1,6 +1,9 @@
 package dto
 
-import "next-terminal/server/model"
+import (
+	"next-terminal/server/model"
+	"next-terminal/server/utils"
+)
 
 type RU struct {
 	UserGroupId  string   `json:"userGroupId"`
@@ -8,6 +11,10 @@ type RU struct {
 	StrategyId   string   `json:"strategyId"`
 	ResourceType string   `json:"resourceType"`
 	ResourceIds  []string `json:"resourceIds"`
+	AccountIds   []string `json:"accountIds"` // 允许使用的资产账号，为空时允许使用全部账号
+
+	ValidFrom  *utils.JsonTime `json:"validFrom"`  // 为空时立即生效
+	ValidUntil *utils.JsonTime `json:"validUntil"` // 为空时永不过期
 }
 
 type UR struct {
@@ -27,6 +34,58 @@ type Backup struct {
 	Commands         []model.Command          `json:"commands"`
 	Credentials      []model.Credential       `json:"credentials"`
 	Assets           []map[string]interface{} `json:"assets"`
+	AssetAccounts    []model.AssetAccount     `json:"asset_accounts"`
 	ResourceSharers  []model.ResourceSharer   `json:"resource_sharers"`
 	Jobs             []model.Job              `json:"jobs"`
 }
+
+type AccessRequest struct {
+	AssetId  string `json:"assetId"`
+	Reason   string `json:"reason"`
+	Duration int    `json:"duration"` // 分钟
+}
+
+type AccessRequestReview struct {
+	Comment    string `json:"comment"`
+	StrategyId string `json:"strategyId"` // 批准时可选，限制授权的文件操作
+}
+
+type CredentialCheckout struct {
+	CredentialId string `json:"credentialId"`
+	Reason       string `json:"reason"`
+	Duration     int    `json:"duration"` // 分钟
+}
+
+type CredentialCheckoutReview struct {
+	Comment string `json:"comment"`
+}
+
+// CredentialSecret 借出期间查看的凭证内容
+type CredentialSecret struct {
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	PrivateKey string `json:"privateKey"`
+	Passphrase string `json:"passphrase"`
+}
+
+// CredentialGenerate 在服务端生成密钥类型的授权凭证
+type CredentialGenerate struct {
+	Name       string `json:"name"`
+	Username   string `json:"username"`
+	KeyType    string `json:"keyType"` // ed25519, rsa, ecdsa
+	Bits       int    `json:"bits"`    // 为0时使用默认长度
+	Passphrase string `json:"passphrase"`
+}
+
+// CredentialDeploy 将凭证的公钥部署到资产或从资产上撤销
+type CredentialDeploy struct {
+	AssetIds        []string `json:"assetIds"`
+	DisablePassword bool     `json:"disablePassword"` // 部署成功后资产改为使用该凭证登录，并清除资产保存的密码
+}
+
+type CredentialDeployResult struct {
+	AssetId   string `json:"assetId"`
+	AssetName string `json:"assetName"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+}
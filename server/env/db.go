@@ -50,7 +50,12 @@ func setupDB() *gorm.DB {
 	if err := db.AutoMigrate(&model.User{}, &model.Asset{}, &model.AssetAttribute{}, &model.Session{}, &model.Command{},
 		&model.Credential{}, &model.Property{}, &model.ResourceSharer{}, &model.UserGroup{}, &model.UserGroupMember{},
 		&model.LoginLog{}, &model.Job{}, &model.JobLog{}, &model.AccessSecurity{}, &model.AccessGateway{},
-		&model.Storage{}, &model.Strategy{}, &model.AccessToken{}); err != nil {
+		&model.Storage{}, &model.Strategy{}, &model.AccessToken{}, &model.AuthorizedKey{}, &model.WebAuthnCredential{}, &model.PasswordHistory{},
+		&model.Role{}, &model.RoleMember{}, &model.RecoveryCode{},
+		&model.LoginPolicy{}, &model.LoginPolicyMember{},
+		&model.AccessRequest{}, &model.AccessRequestLog{},
+		&model.CommandRule{}, &model.CommandRuleRecord{}, &model.SessionCommand{},
+		&model.AssetAccount{}, &model.CredentialCheckout{}, &model.CredentialCheckoutLog{}); err != nil {
 		panic(fmt.Errorf("初始化数据库表结构异常: %v", err.Error()))
 	}
 	return db
@@ -7,16 +7,28 @@ import (
 )
 
 const (
-	NoExpiration          = -1
-	RememberMeExpiration  = time.Hour * time.Duration(24*14)
-	NotRememberExpiration = time.Hour * time.Duration(2)
-	LoginLockExpiration   = time.Minute * time.Duration(5)
+	NoExpiration              = -1
+	RememberMeExpiration      = time.Hour * time.Duration(24*14)
+	NotRememberExpiration     = time.Hour * time.Duration(2)
+	LoginLockExpiration       = time.Minute * time.Duration(5)
+	OidcStateExpiration       = time.Minute * time.Duration(10)
+	SamlRequestExpiration     = time.Minute * time.Duration(10)
+	WebAuthnSessionExpiration = time.Minute * time.Duration(5)
+	EmailOtpExpiration        = time.Minute * time.Duration(5)
 )
 
 var TokenManager *cache.Cache
 var LoginFailedKeyManager *cache.Cache
+var OidcStateManager *cache.Cache
+var SamlRequestManager *cache.Cache
+var WebAuthnSessionManager *cache.Cache
+var EmailOtpManager *cache.Cache
 
 func init() {
 	TokenManager = cache.New(5*time.Minute, 10*time.Minute)
 	LoginFailedKeyManager = cache.New(5*time.Minute, 10*time.Minute)
+	OidcStateManager = cache.New(5*time.Minute, 10*time.Minute)
+	SamlRequestManager = cache.New(5*time.Minute, 10*time.Minute)
+	WebAuthnSessionManager = cache.New(5*time.Minute, 10*time.Minute)
+	EmailOtpManager = cache.New(5*time.Minute, 10*time.Minute)
 }
@@ -0,0 +1,88 @@
+package session
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limit 会话的最长时长及空闲超时限制，即将超出限制时提示一次
+type Limit struct {
+	maxDuration time.Duration
+	idleTimeout time.Duration
+	started     time.Time
+
+	mutex          sync.Mutex
+	lastActive     time.Time
+	durationWarned bool
+	idleWarned     bool
+}
+
+// NewLimit 时长参数的单位为分钟，均未设置限制时返回 nil。
+// connected 为会话首次连接的时间，重新连接时最长时长仍从首次连接开始计算，为零值时从现在开始计算
+func NewLimit(connected time.Time, maxDuration, idleTimeout int) *Limit {
+	if maxDuration <= 0 && idleTimeout <= 0 {
+		return nil
+	}
+	now := time.Now()
+	started := connected
+	if started.IsZero() {
+		started = now
+	}
+	return &Limit{
+		maxDuration: time.Duration(maxDuration) * time.Minute,
+		idleTimeout: time.Duration(idleTimeout) * time.Minute,
+		started:     started,
+		lastActive:  now,
+	}
+}
+
+// Active 记录用户的操作，重新计算空闲时间
+func (l *Limit) Active() {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.lastActive = time.Now()
+	l.idleWarned = false
+}
+
+// Check 检查会话是否超出限制，超出时返回断开的原因，即将超出时返回提示消息
+func (l *Limit) Check(now time.Time) (warning string, reason string) {
+	if l == nil {
+		return "", ""
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.maxDuration > 0 {
+		remaining := l.started.Add(l.maxDuration).Sub(now)
+		if remaining <= 0 {
+			return "", "会话已超出最长时长限制"
+		}
+		if remaining <= warningBefore(l.maxDuration) && !l.durationWarned {
+			l.durationWarned = true
+			return fmt.Sprintf("会话即将达到最长时长限制，将在 %d 秒后断开", int(remaining.Seconds())), ""
+		}
+	}
+	if l.idleTimeout > 0 {
+		remaining := l.lastActive.Add(l.idleTimeout).Sub(now)
+		if remaining <= 0 {
+			return "", "会话长时间无操作，已自动断开"
+		}
+		if remaining <= warningBefore(l.idleTimeout) && !l.idleWarned {
+			l.idleWarned = true
+			return fmt.Sprintf("会话长时间无操作，将在 %d 秒后断开", int(remaining.Seconds())), ""
+		}
+	}
+	return "", ""
+}
+
+// warningBefore 提前一分钟提示，限制时长较短时提前一半的时长
+func warningBefore(d time.Duration) time.Duration {
+	if d < 2*time.Minute {
+		return d / 2
+	}
+	return time.Minute
+}
@@ -17,6 +17,13 @@ type Session struct {
 	GuacdTunnel  *guacd.Tunnel
 	NextTerminal *term.NextTerminal
 	Observer     *Manager
+	Supervisor   *Supervisor // 未开启双人复核时为 nil
+	Limit        *Limit      // 授权策略未限制会话时长时为 nil
+}
+
+// InputAllowed 开启了双人复核的会话只有在监控人在线时才允许输入
+func (s *Session) InputAllowed() bool {
+	return s.Supervisor == nil || s.Supervisor.Present()
 }
 
 type Manager struct {
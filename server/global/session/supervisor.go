@@ -0,0 +1,60 @@
+package session
+
+import (
+	"sync"
+
+	"next-terminal/server/model"
+	"next-terminal/server/utils"
+)
+
+// Supervisor 双人复核会话的监控人，只有监控人在线时才允许操作会话
+type Supervisor struct {
+	LeaveAction string // 监控人全部离开后的处理方式，pause 或 terminate
+
+	mutex   sync.Mutex
+	online  map[string]int // 观察者ID -> 在线记录的下标
+	records []model.SessionSupervisor
+}
+
+// NewSupervisor 资产未开启双人复核时返回 nil
+func NewSupervisor(leaveAction string) *Supervisor {
+	if leaveAction == "" || leaveAction == "-" {
+		return nil
+	}
+	return &Supervisor{
+		LeaveAction: leaveAction,
+		online:      map[string]int{},
+	}
+}
+
+// Join 监控人加入，返回全部在线记录
+func (s *Supervisor) Join(observerId, userId, username string) []model.SessionSupervisor {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.records = append(s.records, model.SessionSupervisor{
+		UserId:   userId,
+		Username: username,
+		Joined:   utils.NowJsonTime(),
+	})
+	s.online[observerId] = len(s.records) - 1
+	return append([]model.SessionSupervisor{}, s.records...)
+}
+
+// Leave 监控人离开，返回全部在线记录以及是否还有监控人在线，observerId 不是监控人时 records 为 nil
+func (s *Supervisor) Leave(observerId string) (records []model.SessionSupervisor, present bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	i, ok := s.online[observerId]
+	if !ok {
+		return nil, len(s.online) > 0
+	}
+	s.records[i].Left = utils.NowJsonTime()
+	delete(s.online, observerId)
+	return append([]model.SessionSupervisor{}, s.records...), len(s.online) > 0
+}
+
+func (s *Supervisor) Present() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.online) > 0
+}
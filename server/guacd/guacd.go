@@ -38,6 +38,9 @@ const (
 	DisableGlyphCaching = "disable-glyph-caching"
 	ForceLossless       = "force-lossless"
 
+	DisableCopy  = "disable-copy"
+	DisablePaste = "disable-paste"
+
 	Domain        = "domain"
 	RemoteApp     = "remote-app"
 	RemoteAppDir  = "remote-app-dir"
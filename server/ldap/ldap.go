@@ -0,0 +1,198 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	ldap_t "github.com/go-ldap/ldap/v3"
+)
+
+var (
+	ErrUserNotFound    = errors.New("ldap: user not found")
+	ErrInvalidPassword = errors.New("ldap: invalid credentials")
+)
+
+type Config struct {
+	Url                string // ldap://host:389 或 ldaps://host:636
+	BindDN             string // 用于查询目录的服务账号
+	BindPassword       string
+	BaseDN             string
+	UserFilter         string // 用户过滤条件，例如 (objectClass=person)
+	UsernameAttribute  string // 登录账号属性，OpenLDAP 一般为 uid，AD 一般为 sAMAccountName
+	NicknameAttribute  string
+	MailAttribute      string
+	GroupAttribute     string // 用户所属组属性，一般为 memberOf
+	InsecureSkipVerify bool
+}
+
+// Entry 目录中的一个用户
+type Entry struct {
+	DN       string
+	Username string
+	Nickname string
+	Mail     string
+	Groups   []string // 组的名称（组DN的第一个RDN值，一般为CN）
+}
+
+type Client struct {
+	config Config
+}
+
+func NewClient(config Config) *Client {
+	if config.UserFilter == "" {
+		config.UserFilter = "(objectClass=person)"
+	}
+	if config.UsernameAttribute == "" {
+		config.UsernameAttribute = "uid"
+	}
+	return &Client{config: config}
+}
+
+func (c *Client) dial() (*ldap_t.Conn, error) {
+	conn, err := ldap_t.DialURL(c.config.Url, ldap_t.DialWithTLSConfig(&tls.Config{
+		InsecureSkipVerify: c.config.InsecureSkipVerify,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(10 * time.Second)
+	if c.config.BindDN != "" {
+		if err := conn.Bind(c.config.BindDN, c.config.BindPassword); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (c *Client) attributes() []string {
+	attributes := []string{"dn", c.config.UsernameAttribute}
+	for _, attribute := range []string{c.config.NicknameAttribute, c.config.MailAttribute, c.config.GroupAttribute} {
+		if attribute != "" {
+			attributes = append(attributes, attribute)
+		}
+	}
+	return attributes
+}
+
+func (c *Client) search(conn *ldap_t.Conn, filter string) ([]Entry, error) {
+	request := ldap_t.NewSearchRequest(
+		c.config.BaseDN,
+		ldap_t.ScopeWholeSubtree, ldap_t.NeverDerefAliases, 0, 0, false,
+		filter,
+		c.attributes(),
+		nil,
+	)
+	result, err := conn.SearchWithPaging(request, 500)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, item := range result.Entries {
+		entry := Entry{
+			DN:       item.DN,
+			Username: item.GetAttributeValue(c.config.UsernameAttribute),
+		}
+		if entry.Username == "" {
+			continue
+		}
+		if c.config.NicknameAttribute != "" {
+			entry.Nickname = item.GetAttributeValue(c.config.NicknameAttribute)
+		}
+		if c.config.MailAttribute != "" {
+			entry.Mail = item.GetAttributeValue(c.config.MailAttribute)
+		}
+		if c.config.GroupAttribute != "" {
+			for _, groupDN := range item.GetAttributeValues(c.config.GroupAttribute) {
+				if name := GroupName(groupDN); name != "" {
+					entry.Groups = append(entry.Groups, name)
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Authenticate 使用服务账号找到用户的DN，再使用用户的密码进行绑定
+func (c *Client) Authenticate(username, password string) (*Entry, error) {
+	// 空密码会被服务端当作匿名绑定处理，必须在此拒绝
+	if username == "" || password == "" {
+		return nil, ErrInvalidPassword
+	}
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	filter := fmt.Sprintf("(&%s(%s=%s))", c.config.UserFilter, c.config.UsernameAttribute, ldap_t.EscapeFilter(username))
+	entries, err := c.search(conn, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrUserNotFound
+	}
+	if len(entries) > 1 {
+		return nil, fmt.Errorf("ldap: username %s matches %d entries", username, len(entries))
+	}
+
+	entry := entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap_t.IsErrorWithCode(err, ldap_t.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidPassword
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Users 查询目录中所有符合过滤条件的用户
+func (c *Client) Users() ([]Entry, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return c.search(conn, c.config.UserFilter)
+}
+
+// GroupName 取组DN的第一个RDN值作为组名称，例如 cn=dev,ou=groups,dc=example,dc=com => dev
+func GroupName(dn string) string {
+	parsed, err := ldap_t.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return strings.TrimSpace(dn)
+	}
+	return parsed.RDNs[0].Attributes[0].Value
+}
+
+// LocalUser 本地已同步的LDAP用户
+type LocalUser struct {
+	Username       string
+	Disabled       bool
+	DisabledBySync bool // 因目录中已不存在而被同步停用，管理员手动停用的用户为 false
+}
+
+// SyncActions 根据目录中的用户计算需要重新启用及停用的本地用户：
+// 被同步停用的用户重新出现在目录中时重新启用，管理员手动停用的用户保持停用；目录中已不存在的用户停用
+func SyncActions(entries []Entry, users []LocalUser) (enable, disable []string) {
+	exist := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		exist[entry.Username] = true
+	}
+	for _, user := range users {
+		switch {
+		case exist[user.Username] && user.Disabled && user.DisabledBySync:
+			enable = append(enable, user.Username)
+		case !exist[user.Username] && !user.Disabled:
+			disable = append(disable, user.Username)
+		}
+	}
+	return enable, disable
+}
@@ -0,0 +1,207 @@
+package ldap_test
+
+import (
+	"net"
+	"regexp"
+	"testing"
+
+	"next-terminal/server/ldap"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	ldap_t "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEntry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+// fakeDirectory 一个只支持简单绑定与查询的进程内LDAP服务
+type fakeDirectory struct {
+	listener net.Listener
+	entries  []fakeEntry
+}
+
+var uidFilter = regexp.MustCompile(`\(uid=([^)]*)\)`)
+
+func newFakeDirectory(t *testing.T, entries []fakeEntry) *fakeDirectory {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	d := &fakeDirectory{listener: listener, entries: entries}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go d.serve(conn)
+		}
+	}()
+	return d
+}
+
+func (d *fakeDirectory) Url() string {
+	return "ldap://" + d.listener.Addr().String()
+}
+
+func (d *fakeDirectory) Close() {
+	_ = d.listener.Close()
+}
+
+func (d *fakeDirectory) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		messageId := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+		switch request.Tag {
+		case ldap_t.ApplicationBindRequest:
+			dn := request.Children[1].Value.(string)
+			password := request.Children[2].Data.String()
+			code := ldap_t.LDAPResultInvalidCredentials
+			for _, entry := range d.entries {
+				if entry.dn == dn && entry.password == password {
+					code = ldap_t.LDAPResultSuccess
+				}
+			}
+			d.write(conn, messageId, result(ldap_t.ApplicationBindResponse, code))
+		case ldap_t.ApplicationSearchRequest:
+			filter, _ := ldap_t.DecompileFilter(request.Children[6])
+			match := uidFilter.FindStringSubmatch(filter)
+			for _, entry := range d.entries {
+				if match != nil && (len(entry.attributes["uid"]) == 0 || entry.attributes["uid"][0] != match[1]) {
+					continue
+				}
+				d.write(conn, messageId, searchEntry(entry))
+			}
+			d.write(conn, messageId, result(ldap_t.ApplicationSearchResultDone, ldap_t.LDAPResultSuccess))
+		case ldap_t.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func (d *fakeDirectory) write(conn net.Conn, messageId int64, op *ber.Packet) {
+	envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageId, "MessageID"))
+	envelope.AppendChild(op)
+	_, _ = conn.Write(envelope.Bytes())
+}
+
+func result(tag ber.Tag, code int) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "resultCode"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	return op
+}
+
+func searchEntry(entry fakeEntry) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap_t.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, "objectName"))
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, values := range entry.attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "value"))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+	op.AppendChild(attributes)
+	return op
+}
+
+func setup(t *testing.T) (*fakeDirectory, *ldap.Client) {
+	directory := newFakeDirectory(t, []fakeEntry{
+		{dn: "cn=admin,dc=example,dc=com", password: "secret"},
+		{
+			dn:       "uid=alice,ou=people,dc=example,dc=com",
+			password: "alice-pass",
+			attributes: map[string][]string{
+				"uid":      {"alice"},
+				"cn":       {"Alice"},
+				"mail":     {"alice@example.com"},
+				"memberOf": {"cn=dev,ou=groups,dc=example,dc=com", "cn=ops,ou=groups,dc=example,dc=com"},
+			},
+		},
+		{
+			dn:         "uid=bob,ou=people,dc=example,dc=com",
+			password:   "bob-pass",
+			attributes: map[string][]string{"uid": {"bob"}, "cn": {"Bob"}},
+		},
+	})
+	client := ldap.NewClient(ldap.Config{
+		Url:               directory.Url(),
+		BindDN:            "cn=admin,dc=example,dc=com",
+		BindPassword:      "secret",
+		BaseDN:            "dc=example,dc=com",
+		UsernameAttribute: "uid",
+		NicknameAttribute: "cn",
+		MailAttribute:     "mail",
+		GroupAttribute:    "memberOf",
+	})
+	return directory, client
+}
+
+func TestAuthenticate(t *testing.T) {
+	directory, client := setup(t)
+	defer directory.Close()
+
+	entry, err := client.Authenticate("alice", "alice-pass")
+	assert.NoError(t, err)
+	assert.Equal(t, "uid=alice,ou=people,dc=example,dc=com", entry.DN)
+	assert.Equal(t, "Alice", entry.Nickname)
+	assert.Equal(t, "alice@example.com", entry.Mail)
+	assert.Equal(t, []string{"dev", "ops"}, entry.Groups)
+
+	_, err = client.Authenticate("alice", "wrong")
+	assert.ErrorIs(t, err, ldap.ErrInvalidPassword)
+
+	_, err = client.Authenticate("alice", "")
+	assert.ErrorIs(t, err, ldap.ErrInvalidPassword)
+
+	_, err = client.Authenticate("carol", "carol-pass")
+	assert.ErrorIs(t, err, ldap.ErrUserNotFound)
+}
+
+func TestUsers(t *testing.T) {
+	directory, client := setup(t)
+	defer directory.Close()
+
+	entries, err := client.Users()
+	assert.NoError(t, err)
+	var usernames []string
+	for _, entry := range entries {
+		usernames = append(usernames, entry.Username)
+	}
+	assert.Equal(t, []string{"alice", "bob"}, usernames)
+}
+
+func TestGroupName(t *testing.T) {
+	assert.Equal(t, "dev", ldap.GroupName("cn=dev,ou=groups,dc=example,dc=com"))
+	assert.Equal(t, "ops", ldap.GroupName("ops"))
+}
+
+func TestSyncActions(t *testing.T) {
+	entries := []ldap.Entry{{Username: "alice"}, {Username: "bob"}, {Username: "carol"}}
+	users := []ldap.LocalUser{
+		{Username: "alice"},
+		// 管理员手动停用，同步后保持停用
+		{Username: "bob", Disabled: true},
+		// 之前同步时目录中已不存在而被停用
+		{Username: "carol", Disabled: true, DisabledBySync: true},
+		{Username: "dave"},
+		{Username: "erin", Disabled: true, DisabledBySync: true},
+	}
+	enable, disable := ldap.SyncActions(entries, users)
+	assert.Equal(t, []string{"carol"}, enable)
+	assert.Equal(t, []string{"dave"}, disable)
+}
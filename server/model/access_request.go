@@ -0,0 +1,55 @@
+package model
+
+import (
+	"next-terminal/server/utils"
+)
+
+// AccessRequest 用户申请临时访问资产，审批通过后会创建一条到期自动收回的授权
+type AccessRequest struct {
+	ID               string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	UserId           string         `gorm:"index,type:varchar(36)" json:"userId"`
+	AssetId          string         `gorm:"index,type:varchar(36)" json:"assetId"`
+	Reason           string         `gorm:"type:varchar(500)" json:"reason"`
+	Duration         int            `json:"duration"` // 申请的访问时长（分钟）
+	Status           string         `gorm:"index,type:varchar(20)" json:"status"`
+	ApproverId       string         `gorm:"type:varchar(36)" json:"approverId"`
+	Comment          string         `gorm:"type:varchar(500)" json:"comment"`         // 审批意见
+	ResourceSharerId string         `gorm:"type:varchar(36)" json:"resourceSharerId"` // 审批通过后创建的授权
+	ExpiresAt        utils.JsonTime `json:"expiresAt"`
+	Created          utils.JsonTime `json:"created"`
+}
+
+func (r *AccessRequest) TableName() string {
+	return "access_requests"
+}
+
+type AccessRequestForPage struct {
+	ID           string         `json:"id"`
+	UserId       string         `json:"userId"`
+	Username     string         `json:"username"`
+	AssetId      string         `json:"assetId"`
+	AssetName    string         `json:"assetName"`
+	Reason       string         `json:"reason"`
+	Duration     int            `json:"duration"`
+	Status       string         `json:"status"`
+	ApproverId   string         `json:"approverId"`
+	ApproverName string         `json:"approverName"`
+	Comment      string         `json:"comment"`
+	ExpiresAt    utils.JsonTime `json:"expiresAt"`
+	Created      utils.JsonTime `json:"created"`
+}
+
+// AccessRequestLog 访问申请的审计记录，记录申请从创建到授权收回的每一步操作
+type AccessRequestLog struct {
+	ID         string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	RequestId  string         `gorm:"index,type:varchar(36)" json:"requestId"`
+	Action     string         `gorm:"type:varchar(20)" json:"action"` // create, approve, deny, cancel, expire, revoke
+	OperatorId string         `gorm:"type:varchar(36)" json:"operatorId"`
+	ClientIP   string         `gorm:"type:varchar(200)" json:"clientIp"`
+	Comment    string         `gorm:"type:varchar(500)" json:"comment"`
+	Created    utils.JsonTime `json:"created"`
+}
+
+func (r *AccessRequestLog) TableName() string {
+	return "access_request_logs"
+}
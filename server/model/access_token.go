@@ -3,10 +3,15 @@ package model
 import "next-terminal/server/utils"
 
 type AccessToken struct {
-	ID      string         `gorm:"primary_key,type:varchar(36)" json:"id"`
-	UserId  string         `gorm:"index,type:varchar(200)" json:"userId"`
-	Token   string         `gorm:"index,type:varchar(128)" json:"token"`
-	Created utils.JsonTime `json:"created"`
+	ID         string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	UserId     string         `gorm:"index,type:varchar(200)" json:"userId"`
+	Name       string         `gorm:"type:varchar(500)" json:"name"`
+	Token      string         `gorm:"index,type:varchar(128)" json:"-"` // 只保存令牌的 SHA256 摘要
+	Scopes     string         `gorm:"type:varchar(500)" json:"scopes"`  // 多个权限范围使用逗号分隔，为空时不限制
+	Expired    utils.JsonTime `json:"expired"`
+	LastUsed   utils.JsonTime `json:"lastUsed"`
+	LastUsedIp string         `gorm:"type:varchar(200)" json:"lastUsedIp"`
+	Created    utils.JsonTime `json:"created"`
 }
 
 func (r *AccessToken) TableName() string {
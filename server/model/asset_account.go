@@ -0,0 +1,24 @@
+package model
+
+import (
+	"next-terminal/server/utils"
+)
+
+// AssetAccount 资产的登录账号，同一个资产可以配置多个账号。资产自身配置的账号作为默认账号，ID 为 default
+type AssetAccount struct {
+	ID           string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	AssetId      string         `gorm:"index,type:varchar(36)" json:"assetId"`
+	Name         string         `gorm:"type:varchar(200)" json:"name"`
+	AccountType  string         `gorm:"type:varchar(20)" json:"accountType"` // custom, private-key, credential
+	Username     string         `gorm:"type:varchar(200)" json:"username"`
+	Password     string         `gorm:"type:varchar(500)" json:"password"`
+	CredentialId string         `gorm:"index,type:varchar(36)" json:"credentialId"`
+	PrivateKey   string         `gorm:"type:text" json:"privateKey"`
+	Passphrase   string         `gorm:"type:varchar(500)" json:"passphrase"`
+	Encrypted    bool           `json:"encrypted"`
+	Created      utils.JsonTime `json:"created"`
+}
+
+func (r *AssetAccount) TableName() string {
+	return "asset_accounts"
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"next-terminal/server/utils"
+)
+
+// AuthorizedKey 用户登录内置SSH服务使用的公钥
+type AuthorizedKey struct {
+	ID          string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	UserId      string         `gorm:"index,type:varchar(36)" json:"userId"`
+	Name        string         `gorm:"type:varchar(500)" json:"name"`
+	PublicKey   string         `gorm:"type:text" json:"publicKey"`
+	Fingerprint string         `gorm:"index,type:varchar(100)" json:"fingerprint"` // SHA256指纹
+	Expired     utils.JsonTime `json:"expired"`                                    // 为空时永不过期
+	LastUsed    utils.JsonTime `json:"lastUsed"`
+	Created     utils.JsonTime `json:"created"`
+}
+
+func (r *AuthorizedKey) TableName() string {
+	return "authorized_keys"
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"next-terminal/server/utils"
+)
+
+// CommandRule 会话中执行命令的拦截规则，按优先级从小到大匹配，命中第一条规则后不再继续匹配。
+// 未匹配任何规则的命令默认放行，白名单需要在放行规则之后添加一条 Pattern 为 .* 的拒绝规则作为默认动作。
+// 规则只对 Shell 中输入的命令行生效，vim、less、top 等全屏程序运行期间（远程终端切换到备用屏幕）的输入不会被拦截
+type CommandRule struct {
+	ID           string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	Name         string         `gorm:"type:varchar(500)" json:"name"`
+	Pattern      string         `gorm:"type:varchar(1000)" json:"pattern"` // 正则表达式
+	Action       string         `gorm:"type:varchar(20)" json:"action"`    // allow 放行, deny 拒绝执行, alert 放行并告警
+	Priority     int            `json:"priority"`
+	AssetIds     string         `gorm:"type:text" json:"assetIds"`     // 生效的资产，多个使用逗号分隔，为空时对全部资产生效
+	UserIds      string         `gorm:"type:text" json:"userIds"`      // 生效的用户，为空且用户组也为空时对全部用户生效
+	UserGroupIds string         `gorm:"type:text" json:"userGroupIds"` // 生效的用户组
+	Enabled      bool           `json:"enabled"`
+	Created      utils.JsonTime `json:"created"`
+}
+
+func (r *CommandRule) TableName() string {
+	return "command_rules"
+}
+
+// CommandRuleRecord 命令匹配到拦截规则的审计记录
+type CommandRuleRecord struct {
+	ID        string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	RuleId    string         `gorm:"index,type:varchar(36)" json:"ruleId"`
+	RuleName  string         `gorm:"type:varchar(500)" json:"ruleName"`
+	Action    string         `gorm:"type:varchar(20)" json:"action"`
+	SessionId string         `gorm:"index,type:varchar(36)" json:"sessionId"`
+	UserId    string         `gorm:"index,type:varchar(36)" json:"userId"`
+	Username  string         `gorm:"type:varchar(200)" json:"username"`
+	AssetId   string         `gorm:"index,type:varchar(36)" json:"assetId"`
+	AssetName string         `gorm:"type:varchar(500)" json:"assetName"`
+	ClientIP  string         `gorm:"type:varchar(200)" json:"clientIp"`
+	Command   string         `gorm:"type:text" json:"command"`
+	Created   utils.JsonTime `json:"created"`
+}
+
+func (r *CommandRuleRecord) TableName() string {
+	return "command_rule_records"
+}
@@ -5,16 +5,17 @@ import (
 )
 
 type Credential struct {
-	ID         string         `gorm:"primary_key,type:varchar(36)" json:"id"`
-	Name       string         `gorm:"type:varchar(500)" json:"name"`
-	Type       string         `gorm:"type:varchar(50)" json:"type"`
-	Username   string         `gorm:"type:varchar(200)" json:"username"`
-	Password   string         `gorm:"type:varchar(500)" json:"password"`
-	PrivateKey string         `gorm:"type:text" json:"privateKey"`
-	Passphrase string         `gorm:"type:varchar(500)" json:"passphrase"`
-	Created    utils.JsonTime `json:"created"`
-	Owner      string         `gorm:"index,type:varchar(36)" json:"owner"`
-	Encrypted  bool           `json:"encrypted"`
+	ID            string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	Name          string         `gorm:"type:varchar(500)" json:"name"`
+	Type          string         `gorm:"type:varchar(50)" json:"type"`
+	Username      string         `gorm:"type:varchar(200)" json:"username"`
+	Password      string         `gorm:"type:varchar(500)" json:"password"`
+	PrivateKey    string         `gorm:"type:text" json:"privateKey"`
+	Passphrase    string         `gorm:"type:varchar(500)" json:"passphrase"`
+	Created       utils.JsonTime `json:"created"`
+	Owner         string         `gorm:"index,type:varchar(36)" json:"owner"`
+	Encrypted     bool           `json:"encrypted"`
+	NeedsRotation bool           `json:"needsRotation"` // 借出后已归还，需要修改密码或密钥
 }
 
 func (r *Credential) TableName() string {
@@ -22,14 +23,15 @@ func (r *Credential) TableName() string {
 }
 
 type CredentialForPage struct {
-	ID          string         `json:"id"`
-	Name        string         `json:"name"`
-	Type        string         `json:"type"`
-	Username    string         `json:"username"`
-	Created     utils.JsonTime `json:"created"`
-	Owner       string         `json:"owner"`
-	OwnerName   string         `json:"ownerName"`
-	SharerCount int64          `json:"sharerCount"`
+	ID            string         `json:"id"`
+	Name          string         `json:"name"`
+	Type          string         `json:"type"`
+	Username      string         `json:"username"`
+	Created       utils.JsonTime `json:"created"`
+	Owner         string         `json:"owner"`
+	OwnerName     string         `json:"ownerName"`
+	SharerCount   int64          `json:"sharerCount"`
+	NeedsRotation bool           `json:"needsRotation"`
 }
 
 type CredentialSimpleVo struct {
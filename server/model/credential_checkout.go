@@ -0,0 +1,56 @@
+package model
+
+import (
+	"next-terminal/server/utils"
+)
+
+// CredentialCheckout 用户借出授权凭证，借出期间可以查看凭证的密码或密钥，归还或到期后凭证需要轮换
+type CredentialCheckout struct {
+	ID           string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	CredentialId string         `gorm:"index,type:varchar(36)" json:"credentialId"`
+	UserId       string         `gorm:"index,type:varchar(36)" json:"userId"`
+	Reason       string         `gorm:"type:varchar(500)" json:"reason"`
+	Duration     int            `json:"duration"` // 借出时长（分钟）
+	Status       string         `gorm:"index,type:varchar(20)" json:"status"`
+	ApproverId   string         `gorm:"type:varchar(36)" json:"approverId"`
+	Comment      string         `gorm:"type:varchar(500)" json:"comment"` // 审批意见
+	ExpiresAt    utils.JsonTime `json:"expiresAt"`
+	CheckedIn    utils.JsonTime `json:"checkedIn"` // 归还时间
+	Created      utils.JsonTime `json:"created"`
+}
+
+func (r *CredentialCheckout) TableName() string {
+	return "credential_checkouts"
+}
+
+type CredentialCheckoutForPage struct {
+	ID             string         `json:"id"`
+	CredentialId   string         `json:"credentialId"`
+	CredentialName string         `json:"credentialName"`
+	UserId         string         `json:"userId"`
+	Username       string         `json:"username"`
+	Reason         string         `json:"reason"`
+	Duration       int            `json:"duration"`
+	Status         string         `json:"status"`
+	ApproverId     string         `json:"approverId"`
+	ApproverName   string         `json:"approverName"`
+	Comment        string         `json:"comment"`
+	ExpiresAt      utils.JsonTime `json:"expiresAt"`
+	CheckedIn      utils.JsonTime `json:"checkedIn"`
+	Created        utils.JsonTime `json:"created"`
+}
+
+// CredentialCheckoutLog 凭证借出的审计记录，每一次查看密码或密钥都会记录
+type CredentialCheckoutLog struct {
+	ID         string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	CheckoutId string         `gorm:"index,type:varchar(36)" json:"checkoutId"`
+	Action     string         `gorm:"type:varchar(20)" json:"action"` // create, approve, deny, cancel, reveal, checkin, expire
+	OperatorId string         `gorm:"type:varchar(36)" json:"operatorId"`
+	ClientIP   string         `gorm:"type:varchar(200)" json:"clientIp"`
+	Comment    string         `gorm:"type:varchar(500)" json:"comment"`
+	Created    utils.JsonTime `json:"created"`
+}
+
+func (r *CredentialCheckoutLog) TableName() string {
+	return "credential_checkout_logs"
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"next-terminal/server/utils"
+)
+
+// LoginPolicy 限制用户登录的来源IP及时间段，可以分配给用户或用户组
+type LoginPolicy struct {
+	ID          string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	Name        string         `gorm:"type:varchar(500)" json:"name"`
+	IPs         string         `gorm:"type:text" json:"ips"`              // 允许登录的来源IP，多个使用逗号分隔，为空时不限制
+	TimeZone    string         `gorm:"type:varchar(100)" json:"timeZone"` // 例如 Asia/Shanghai，为空时使用服务器时区
+	TimePeriods string         `gorm:"type:text" json:"timePeriods"`      // JSON 格式的 []utils.TimePeriod，为空时不限制
+	Enabled     bool           `json:"enabled"`
+	Created     utils.JsonTime `json:"created"`
+}
+
+func (r *LoginPolicy) TableName() string {
+	return "login_policies"
+}
+
+// LoginPolicyMember 登录策略与用户或用户组的关系，UserId 与 UserGroupId 只会有一个不为空
+type LoginPolicyMember struct {
+	ID            string `gorm:"primary_key" json:"id"`
+	LoginPolicyId string `gorm:"index" json:"loginPolicyId"`
+	UserId        string `gorm:"index" json:"userId"`
+	UserGroupId   string `gorm:"index" json:"userGroupId"`
+}
+
+func (r *LoginPolicyMember) TableName() string {
+	return "login_policy_members"
+}
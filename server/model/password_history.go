@@ -0,0 +1,17 @@
+package model
+
+import (
+	"next-terminal/server/utils"
+)
+
+// PasswordHistory 用户使用过的密码，用于限制重复使用
+type PasswordHistory struct {
+	ID       string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	UserId   string         `gorm:"index,type:varchar(36)" json:"userId"`
+	Password string         `gorm:"type:varchar(500)" json:"-"`
+	Created  utils.JsonTime `json:"created"`
+}
+
+func (r *PasswordHistory) TableName() string {
+	return "password_histories"
+}
@@ -0,0 +1,15 @@
+package model
+
+import "next-terminal/server/utils"
+
+// RecoveryCode 丢失双因素认证设备时使用的一次性恢复码，使用后即删除
+type RecoveryCode struct {
+	ID      string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	UserId  string         `gorm:"index,type:varchar(36)" json:"userId"`
+	Code    string         `gorm:"type:varchar(64)" json:"-"` // 只保存恢复码的 SHA256 摘要
+	Created utils.JsonTime `json:"created"`
+}
+
+func (r *RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
@@ -1,14 +1,49 @@
 package model
 
+import (
+	"strings"
+	"time"
+
+	"next-terminal/server/utils"
+)
+
 type ResourceSharer struct {
-	ID           string `gorm:"primary_key,type:varchar(36)" json:"id"`
-	ResourceId   string `gorm:"index,type:varchar(36)" json:"resourceId"`
-	ResourceType string `gorm:"index,type:varchar(36)" json:"resourceType"`
-	StrategyId   string `gorm:"index,type:varchar(36)" json:"strategyId"`
-	UserId       string `gorm:"index,type:varchar(36)" json:"userId"`
-	UserGroupId  string `gorm:"index,type:varchar(36)" json:"userGroupId"`
+	ID           string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	ResourceId   string         `gorm:"index,type:varchar(36)" json:"resourceId"`
+	ResourceType string         `gorm:"index,type:varchar(36)" json:"resourceType"`
+	StrategyId   string         `gorm:"index,type:varchar(36)" json:"strategyId"`
+	UserId       string         `gorm:"index,type:varchar(36)" json:"userId"`
+	UserGroupId  string         `gorm:"index,type:varchar(36)" json:"userGroupId"`
+	ValidFrom    utils.JsonTime `json:"validFrom"`               // 为空时立即生效
+	ValidUntil   utils.JsonTime `gorm:"index" json:"validUntil"` // 为空时永不过期
+	Notified     bool           `json:"notified"`                // 是否已经发送即将到期的提醒
+	AccountIds   string         `json:"accountIds"`              // 允许使用的资产账号ID，多个使用逗号分隔，为空时允许使用全部账号
 }
 
 func (r *ResourceSharer) TableName() string {
 	return "resource_sharers"
 }
+
+// Valid 授权在指定时间是否处于有效期内
+func (r *ResourceSharer) Valid(t time.Time) bool {
+	if !r.ValidFrom.IsZero() && t.Before(r.ValidFrom.Time) {
+		return false
+	}
+	if !r.ValidUntil.IsZero() && !t.Before(r.ValidUntil.Time) {
+		return false
+	}
+	return true
+}
+
+// AllowAccount 授权是否允许使用资产的指定账号
+func (r *ResourceSharer) AllowAccount(accountId string) bool {
+	if r.AccountIds == "" {
+		return true
+	}
+	for _, id := range strings.Split(r.AccountIds, ",") {
+		if id == accountId {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"next-terminal/server/utils"
+)
+
+// Role 角色由一组权限组成，可以分配给用户或用户组
+type Role struct {
+	ID          string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	Name        string         `gorm:"type:varchar(500)" json:"name"`
+	Permissions string         `gorm:"type:text" json:"permissions"` // 多个权限使用逗号分隔
+	Created     utils.JsonTime `json:"created"`
+}
+
+func (r *Role) TableName() string {
+	return "roles"
+}
+
+// RoleMember 角色与用户或用户组的关系，UserId 与 UserGroupId 只会有一个不为空
+type RoleMember struct {
+	ID          string `gorm:"primary_key" json:"id"`
+	RoleId      string `gorm:"index" json:"roleId"`
+	UserId      string `gorm:"index" json:"userId"`
+	UserGroupId string `gorm:"index" json:"userGroupId"`
+}
+
+func (r *RoleMember) TableName() string {
+	return "role_members"
+}
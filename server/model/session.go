@@ -11,6 +11,7 @@ type Session struct {
 	Port             int            `json:"port"`
 	ConnectionId     string         `gorm:"type:varchar(50)" json:"connectionId"`
 	AssetId          string         `gorm:"index,type:varchar(36)" json:"assetId"`
+	AccountId        string         `gorm:"type:varchar(36)" json:"accountId"` // 使用的资产账号，default 为资产自身配置的账号
 	Username         string         `gorm:"type:varchar(200)" json:"username"`
 	Password         string         `gorm:"type:varchar(500)" json:"password"`
 	Creator          string         `gorm:"index,type:varchar(36)" json:"creator"`
@@ -35,15 +36,28 @@ type Session struct {
 	CreateDir        string         `gorm:"type:varchar(1)" json:"createDir"`
 	Copy             string         `gorm:"type:varchar(1)" json:"copy"`
 	Paste            string         `gorm:"type:varchar(1)" json:"paste"`
+	MaxDuration      int            `json:"maxDuration"`                           // 会话最长时长（分钟），0为不限制
+	IdleTimeout      int            `json:"idleTimeout"`                           // 无操作自动断开的时长（分钟），0为不限制
+	ForceRecording   string         `gorm:"type:varchar(1)" json:"forceRecording"` // 1 = 全局关闭录屏时仍然录屏
+	Monitor          string         `gorm:"type:varchar(1)" json:"monitor"`        // 1 = 允许监控会话
 	StorageId        string         `gorm:"type:varchar(36)" json:"storageId"`
 	AccessGatewayId  string         `gorm:"type:varchar(36)" json:"accessGatewayId"`
 	Reviewed         bool           `gorm:"type:tinyint(1)" json:"reviewed"`
+	Supervisors      string         `gorm:"type:text" json:"supervisors"` // 双人复核会话的监控人记录，JSON 格式的 []SessionSupervisor
 }
 
 func (r *Session) TableName() string {
 	return "sessions"
 }
 
+// SessionSupervisor 双人复核会话中监控人的在线记录
+type SessionSupervisor struct {
+	UserId   string         `json:"userId"`
+	Username string         `json:"username"`
+	Joined   utils.JsonTime `json:"joined"`
+	Left     utils.JsonTime `json:"left"`
+}
+
 type SessionForPage struct {
 	ID               string         `json:"id"`
 	Protocol         string         `json:"protocol"`
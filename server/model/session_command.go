@@ -0,0 +1,25 @@
+package model
+
+import (
+	"next-terminal/server/utils"
+)
+
+// SessionCommand 从 SSH 会话的输入中还原出的命令
+type SessionCommand struct {
+	ID              string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	SessionId       string         `gorm:"index,type:varchar(36)" json:"sessionId"`
+	UserId          string         `gorm:"index,type:varchar(36)" json:"userId"`
+	Username        string         `gorm:"type:varchar(200)" json:"username"`
+	AssetId         string         `gorm:"index,type:varchar(36)" json:"assetId"`
+	AssetName       string         `gorm:"type:varchar(500)" json:"assetName"`
+	ClientIP        string         `gorm:"type:varchar(200)" json:"clientIp"`
+	Command         string         `gorm:"type:text" json:"command"`
+	Cwd             string         `gorm:"type:varchar(1000)" json:"cwd"` // 执行命令时的目录，无法解析时为空
+	ExitCode        *int           `json:"exitCode"`                      // 远程 Shell 开启了 Shell 集成时才能获取
+	RecordingOffset float64        `json:"recordingOffset"`               // 命令在录屏中的位置（秒），未录屏时为 -1
+	Created         utils.JsonTime `gorm:"index" json:"created"`
+}
+
+func (r *SessionCommand) TableName() string {
+	return "session_commands"
+}
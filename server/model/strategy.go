@@ -3,17 +3,23 @@ package model
 import "next-terminal/server/utils"
 
 type Strategy struct {
-	ID        string         `gorm:"primary_key,type:varchar(36)" json:"id"`
-	Name      string         `gorm:"type:varchar(500)" json:"name"`
-	Upload    string         `gorm:"type:varchar(1)" json:"upload"` // 1 = true, 0 = false
-	Download  string         `gorm:"type:varchar(1)" json:"download"`
-	Delete    string         `gorm:"type:varchar(1)" json:"delete"`
-	Rename    string         `gorm:"type:varchar(1)" json:"rename"`
-	Edit      string         `gorm:"type:varchar(1)" json:"edit"`
-	CreateDir string         `gorm:"type:varchar(1)" json:"createDir"`
-	Copy      string         `gorm:"type:varchar(1)" json:"copy"`
-	Paste     string         `gorm:"type:varchar(1)" json:"paste"`
-	Created   utils.JsonTime `json:"created"`
+	ID        string `gorm:"primary_key,type:varchar(36)" json:"id"`
+	Name      string `gorm:"type:varchar(500)" json:"name"`
+	Upload    string `gorm:"type:varchar(1)" json:"upload"` // 1 = true, 0 = false
+	Download  string `gorm:"type:varchar(1)" json:"download"`
+	Delete    string `gorm:"type:varchar(1)" json:"delete"`
+	Rename    string `gorm:"type:varchar(1)" json:"rename"`
+	Edit      string `gorm:"type:varchar(1)" json:"edit"`
+	CreateDir string `gorm:"type:varchar(1)" json:"createDir"`
+	Copy      string `gorm:"type:varchar(1)" json:"copy"`
+	Paste     string `gorm:"type:varchar(1)" json:"paste"`
+	// 会话限制
+	MaxDuration    int            `json:"maxDuration"`                              // 会话最长时长（分钟），0为不限制
+	IdleTimeout    int            `json:"idleTimeout"`                              // 无操作自动断开的时长（分钟），0为不限制
+	Clipboard      string         `gorm:"type:varchar(10)" json:"clipboard"`        // 剪贴板方向 in、out、both，为空时使用 Copy 及 Paste
+	ForceRecording string         `gorm:"type:varchar(1)" json:"forceRecording"`    // 1 = 全局关闭录屏时仍然录屏
+	Monitor        string         `gorm:"type:varchar(1);default:1" json:"monitor"` // 1 = 允许监控会话
+	Created        utils.JsonTime `json:"created"`
 }
 
 func (r *Strategy) TableName() string {
@@ -16,6 +16,15 @@ type User struct {
 	Type       string         `gorm:"type:varchar(20)" json:"type"`
 	Mail       string         `gorm:"type:varchar(500)" json:"mail"`
 	Source     string         `gorm:"type:varchar(20)" json:"source"`
+
+	PasswordUpdated     utils.JsonTime `json:"passwordUpdated"`     // 最后一次修改密码的时间
+	ForceChangePassword bool           `json:"forceChangePassword"` // 下次登录时需要修改密码
+	LoginFailedCount    int            `json:"loginFailedCount"`    // 连续登录失败次数
+	LockedAt            utils.JsonTime `json:"lockedAt"`            // 因登录失败次数过多被锁定的时间
+	EmailOtp            bool           `json:"emailOtp"`            // 使用邮件验证码作为双因素认证
+
+	ExternalId     string `gorm:"index,type:varchar(500)" json:"-"` // 外部身份源中用户的标识，SAML 用户为最近一次登录的 NameID，OIDC 用户为 iss 与 sub
+	DisabledBySync bool   `json:"-"`                                // 因LDAP目录中已不存在而被同步停用，重新出现在目录中时自动启用
 }
 
 type UserForPage struct {
@@ -29,6 +38,8 @@ type UserForPage struct {
 	Created          utils.JsonTime `json:"created"`
 	Type             string         `json:"type"`
 	Source           string         `json:"source"`
+	LoginFailedCount int            `json:"loginFailedCount"`
+	LockedAt         utils.JsonTime `json:"lockedAt"`
 	SharerAssetCount int64          `json:"sharerAssetCount"`
 }
 
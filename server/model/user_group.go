@@ -5,16 +5,19 @@ import (
 )
 
 type UserGroup struct {
-	ID      string         `gorm:"primary_key,type:varchar(36)" json:"id"`
-	Name    string         `gorm:"type:varchar(500)" json:"name"`
-	Created utils.JsonTime `json:"created"`
-	Members []string       `gorm:"-" json:"members"`
+	ID        string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	Name      string         `gorm:"type:varchar(500)" json:"name"`
+	Created   utils.JsonTime `json:"created"`
+	Source    string         `gorm:"type:varchar(20)" json:"source"`
+	MfaMethod string         `gorm:"type:varchar(20)" json:"mfaMethod"` // 要求组内用户使用的双因素认证方式，为空时不限制
+	Members   []string       `gorm:"-" json:"members"`
 }
 
 type UserGroupForPage struct {
 	ID         string         `json:"id"`
 	Name       string         `json:"name"`
 	Created    utils.JsonTime `json:"created"`
+	MfaMethod  string         `json:"mfaMethod"`
 	AssetCount int64          `json:"assetCount"`
 }
 
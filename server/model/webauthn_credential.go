@@ -0,0 +1,23 @@
+package model
+
+import (
+	"next-terminal/server/utils"
+)
+
+// WebAuthnCredential 用户注册的 WebAuthn 认证器，如安全密钥、指纹识别等
+type WebAuthnCredential struct {
+	ID              string         `gorm:"primary_key,type:varchar(36)" json:"id"`
+	UserId          string         `gorm:"index,type:varchar(36)" json:"userId"`
+	Name            string         `gorm:"type:varchar(500)" json:"name"`
+	CredentialId    string         `gorm:"index,type:varchar(500)" json:"-"` // base64url 编码
+	PublicKey       string         `gorm:"type:text" json:"-"`               // base64url 编码的 COSE 公钥
+	AttestationType string         `gorm:"type:varchar(50)" json:"attestationType"`
+	AAGUID          string         `gorm:"type:varchar(100)" json:"aaguid"`
+	SignCount       uint32         `json:"signCount"`
+	LastUsed        utils.JsonTime `json:"lastUsed"`
+	Created         utils.JsonTime `json:"created"`
+}
+
+func (r *WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
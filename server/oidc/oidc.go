@@ -0,0 +1,166 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	oidc_t "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+type Config struct {
+	Issuer        string
+	ClientId      string
+	ClientSecret  string
+	RedirectUrl   string
+	Scopes        []string
+	UsernameClaim string // 登录账号对应的声明，默认为 preferred_username
+	NicknameClaim string
+	MailClaim     string
+	GroupsClaim   string
+}
+
+// Identity 从 ID Token 中解析出的用户信息
+type Identity struct {
+	Issuer   string
+	Subject  string
+	Username string
+	Nickname string
+	Mail     string
+	Groups   []string
+}
+
+type Client struct {
+	config   Config
+	verifier *oidc_t.IDTokenVerifier
+	oauth2   oauth2.Config
+}
+
+// NewClient 通过 Issuer 的 /.well-known/openid-configuration 获取认证服务器的配置
+func NewClient(ctx context.Context, config Config) (*Client, error) {
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "preferred_username"
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{oidc_t.ScopeOpenID, "profile", "email"}
+	}
+	provider, err := oidc_t.NewProvider(ctx, config.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		config:   config,
+		verifier: provider.Verifier(&oidc_t.Config{ClientID: config.ClientId}),
+		oauth2: oauth2.Config{
+			ClientID:     config.ClientId,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectUrl,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       config.Scopes,
+		},
+	}, nil
+}
+
+// AuthCodeURL 生成跳转到认证服务器的地址，codeVerifier 需要保存下来在回调时使用
+func (c *Client) AuthCodeURL(state, nonce, codeVerifier string) string {
+	return c.oauth2.AuthCodeURL(state,
+		oidc_t.Nonce(nonce),
+		oauth2.SetAuthURLParam("code_challenge", CodeChallenge(codeVerifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+}
+
+// Exchange 使用授权码换取 ID Token 并校验签名、受众、过期时间及 nonce
+func (c *Client) Exchange(ctx context.Context, code, nonce, codeVerifier string) (*Identity, error) {
+	token, err := c.oauth2.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", codeVerifier))
+	if err != nil {
+		return nil, err
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("oidc: no id_token in token response")
+	}
+	idToken, err := c.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("oidc: nonce did not match")
+	}
+
+	claims := map[string]interface{}{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Issuer:   idToken.Issuer,
+		Subject:  idToken.Subject,
+		Username: stringClaim(claims, c.config.UsernameClaim),
+		Nickname: stringClaim(claims, c.config.NicknameClaim),
+		Mail:     stringClaim(claims, c.config.MailClaim),
+		Groups:   stringsClaim(claims, c.config.GroupsClaim),
+	}
+	if identity.Username == "" {
+		return nil, fmt.Errorf("oidc: claim %s is empty", c.config.UsernameClaim)
+	}
+	return identity, nil
+}
+
+// ExternalId 用户在认证服务器中不可变的唯一标识，用户名等声明可以被用户修改，不能用来关联本地用户
+func (i Identity) ExternalId() string {
+	return i.Issuer + "#" + i.Subject
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	if name == "" {
+		return ""
+	}
+	if v, ok := claims[name].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func stringsClaim(claims map[string]interface{}, name string) []string {
+	if name == "" {
+		return nil
+	}
+	switch v := claims[name].(type) {
+	case string:
+		// 部分认证服务器使用逗号分隔的字符串
+		var values []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		return values
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// NewCodeVerifier 生成 PKCE 使用的随机字符串
+func NewCodeVerifier() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,143 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"next-terminal/server/oidc"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// mockProvider 一个只实现了授权码模式的认证服务器
+type mockProvider struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	claims    map[string]interface{}
+	code      string
+	challenge string
+	nonce     string
+}
+
+func newMockProvider(t *testing.T, claims map[string]interface{}) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	p := &mockProvider{key: key, claims: claims}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/authorize",
+			"token_endpoint":                        p.server.URL + "/token",
+			"jwks_uri":                              p.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != p.code || oidc.CodeChallenge(r.FormValue("code_verifier")) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     p.sign(t),
+		})
+	})
+	p.server = httptest.NewServer(mux)
+	return p
+}
+
+// authorize 模拟用户在认证服务器登录后携带授权码跳转回来
+func (p *mockProvider) authorize(t *testing.T, authCodeURL string) (code, state string) {
+	u, err := url.Parse(authCodeURL)
+	assert.NoError(t, err)
+	query := u.Query()
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	p.challenge = query.Get("code_challenge")
+	p.nonce = query.Get("nonce")
+	p.code = "authorization-code"
+	return p.code, query.Get("state")
+}
+
+func (p *mockProvider) sign(t *testing.T) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: p.key}, (&jose.SignerOptions{}).WithHeader("kid", "test"))
+	assert.NoError(t, err)
+	claims := map[string]interface{}{
+		"iss":   p.server.URL,
+		"aud":   "next-terminal",
+		"sub":   "user-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": p.nonce,
+	}
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+	payload, _ := json.Marshal(claims)
+	object, err := signer.Sign(payload)
+	assert.NoError(t, err)
+	token, err := object.CompactSerialize()
+	assert.NoError(t, err)
+	return token
+}
+
+func TestExchange(t *testing.T) {
+	provider := newMockProvider(t, map[string]interface{}{
+		"preferred_username": "alice",
+		"name":               "Alice",
+		"email":              "alice@example.com",
+		"groups":             []string{"dev", "ops"},
+	})
+	defer provider.server.Close()
+
+	client, err := oidc.NewClient(context.TODO(), oidc.Config{
+		Issuer:        provider.server.URL,
+		ClientId:      "next-terminal",
+		ClientSecret:  "secret",
+		RedirectUrl:   "http://localhost/login/oidc/callback",
+		NicknameClaim: "name",
+		MailClaim:     "email",
+		GroupsClaim:   "groups",
+	})
+	assert.NoError(t, err)
+
+	codeVerifier := oidc.NewCodeVerifier()
+	code, state := provider.authorize(t, client.AuthCodeURL("state-1", "nonce-1", codeVerifier))
+	assert.Equal(t, "state-1", state)
+
+	identity, err := client.Exchange(context.TODO(), code, "nonce-1", codeVerifier)
+	assert.NoError(t, err)
+	assert.Equal(t, provider.server.URL, identity.Issuer)
+	assert.Equal(t, "user-1", identity.Subject)
+	assert.Equal(t, provider.server.URL+"#user-1", identity.ExternalId())
+	assert.Equal(t, "alice", identity.Username)
+	assert.Equal(t, "Alice", identity.Nickname)
+	assert.Equal(t, "alice@example.com", identity.Mail)
+	assert.Equal(t, []string{"dev", "ops"}, identity.Groups)
+
+	// 错误的 code_verifier 会被认证服务器拒绝
+	_, err = client.Exchange(context.TODO(), code, "nonce-1", oidc.NewCodeVerifier())
+	assert.Error(t, err)
+
+	// nonce 不匹配
+	_, err = client.Exchange(context.TODO(), code, "nonce-2", codeVerifier)
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/model"
+)
+
+type accessRequestRepository struct {
+	baseRepository
+}
+
+// Find 分页查询访问申请，userId 不为空时只查询该用户提交的申请，ownerId 不为空时只查询该用户名下资产的申请
+func (r accessRequestRepository) Find(c context.Context, pageIndex, pageSize int, userId, ownerId, status string) (o []model.AccessRequestForPage, total int64, err error) {
+	db := r.GetDB(c).Table("access_requests").
+		Select("access_requests.id, access_requests.user_id, users.username, access_requests.asset_id, assets.name as asset_name, access_requests.reason, access_requests.duration, access_requests.status, access_requests.approver_id, approvers.username as approver_name, access_requests.comment, access_requests.expires_at, access_requests.created").
+		Joins("left join users on access_requests.user_id = users.id").
+		Joins("left join assets on access_requests.asset_id = assets.id").
+		Joins("left join users as approvers on access_requests.approver_id = approvers.id")
+	dbCounter := r.GetDB(c).Table("access_requests").Joins("left join assets on access_requests.asset_id = assets.id")
+
+	if len(userId) > 0 {
+		db = db.Where("access_requests.user_id = ?", userId)
+		dbCounter = dbCounter.Where("access_requests.user_id = ?", userId)
+	}
+
+	if len(ownerId) > 0 {
+		db = db.Where("assets.owner = ?", ownerId)
+		dbCounter = dbCounter.Where("assets.owner = ?", ownerId)
+	}
+
+	if len(status) > 0 {
+		db = db.Where("access_requests.status = ?", status)
+		dbCounter = dbCounter.Where("access_requests.status = ?", status)
+	}
+
+	err = dbCounter.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = db.Order("access_requests.created desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&o).Error
+	if o == nil {
+		o = make([]model.AccessRequestForPage, 0)
+	}
+	return
+}
+
+func (r accessRequestRepository) FindById(c context.Context, id string) (o model.AccessRequest, err error) {
+	err = r.GetDB(c).Where("id = ?", id).First(&o).Error
+	return
+}
+
+// FindApprovedExpiredBefore 查询授权已经到期但尚未收回的申请
+func (r accessRequestRepository) FindApprovedExpiredBefore(c context.Context, t time.Time) (o []model.AccessRequest, err error) {
+	err = r.GetDB(c).Where("status = ? and expires_at < ?", constant.AccessRequestApproved, t).Find(&o).Error
+	return
+}
+
+func (r accessRequestRepository) ExistPendingByUserIdAndAssetId(c context.Context, userId, assetId string) (bool, error) {
+	var count int64
+	err := r.GetDB(c).Table("access_requests").Where("user_id = ? and asset_id = ? and status = ?", userId, assetId, constant.AccessRequestPending).Count(&count).Error
+	return count > 0, err
+}
+
+func (r accessRequestRepository) Create(c context.Context, o *model.AccessRequest) error {
+	return r.GetDB(c).Create(o).Error
+}
+
+// UpdateStatusById 仅当申请处于 fromStatus 状态时才更新，返回是否更新成功，避免并发审批
+func (r accessRequestRepository) UpdateStatusById(c context.Context, o *model.AccessRequest, id, fromStatus string) (bool, error) {
+	db := r.GetDB(c).Model(&model.AccessRequest{}).Where("id = ? and status = ?", id, fromStatus).
+		Select("status", "approver_id", "comment", "resource_sharer_id", "expires_at").Updates(o)
+	return db.RowsAffected > 0, db.Error
+}
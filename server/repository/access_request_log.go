@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"next-terminal/server/model"
+)
+
+type accessRequestLogRepository struct {
+	baseRepository
+}
+
+func (r accessRequestLogRepository) FindByRequestId(c context.Context, requestId string) (o []model.AccessRequestLog, err error) {
+	err = r.GetDB(c).Where("request_id = ?", requestId).Order("created asc").Find(&o).Error
+	if o == nil {
+		o = make([]model.AccessRequestLog, 0)
+	}
+	return
+}
+
+func (r accessRequestLogRepository) Create(c context.Context, o *model.AccessRequestLog) error {
+	return r.GetDB(c).Create(o).Error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"next-terminal/server/model"
 )
@@ -10,8 +11,18 @@ type accessTokenRepository struct {
 	baseRepository
 }
 
-func (repo accessTokenRepository) FindByUserId(ctx context.Context, userId string) (o model.AccessToken, err error) {
-	err = repo.GetDB(ctx).Where("user_id = ?", userId).First(&o).Error
+func (repo accessTokenRepository) FindByUserId(ctx context.Context, userId string) (o []model.AccessToken, err error) {
+	err = repo.GetDB(ctx).Where("user_id = ?", userId).Order("created desc").Find(&o).Error
+	return
+}
+
+func (repo accessTokenRepository) FindByToken(ctx context.Context, token string) (o model.AccessToken, err error) {
+	err = repo.GetDB(ctx).Where("token = ?", token).First(&o).Error
+	return
+}
+
+func (repo accessTokenRepository) FindByIdAndUserId(ctx context.Context, id, userId string) (o model.AccessToken, err error) {
+	err = repo.GetDB(ctx).Where("id = ? and user_id = ?", id, userId).First(&o).Error
 	return
 }
 
@@ -19,6 +30,10 @@ func (repo accessTokenRepository) DeleteByUserId(ctx context.Context, userId str
 	return repo.GetDB(ctx).Where("user_id = ?", userId).Delete(&model.AccessToken{}).Error
 }
 
+func (repo accessTokenRepository) DeleteByIdAndUserId(ctx context.Context, id, userId string) error {
+	return repo.GetDB(ctx).Where("id = ? and user_id = ?", id, userId).Delete(&model.AccessToken{}).Error
+}
+
 func (repo accessTokenRepository) Create(ctx context.Context, o *model.AccessToken) error {
 	return repo.GetDB(ctx).Create(o).Error
 }
@@ -27,3 +42,15 @@ func (repo accessTokenRepository) FindAll(ctx context.Context) (o []model.Access
 	err = repo.GetDB(ctx).Find(&o).Error
 	return
 }
+
+func (repo accessTokenRepository) UpdateTokenById(ctx context.Context, token, id string) error {
+	return repo.GetDB(ctx).Model(&model.AccessToken{}).Where("id = ?", id).Update("token", token).Error
+}
+
+// UpdateLastUsedByToken 每分钟最多记录一次使用时间，来源IP变化时立即记录
+func (repo accessTokenRepository) UpdateLastUsedByToken(ctx context.Context, ip, token string) error {
+	now := time.Now()
+	return repo.GetDB(ctx).Model(&model.AccessToken{}).
+		Where("token = ? and (last_used is null or last_used < ? or last_used_ip <> ?)", token, now.Add(-time.Minute), ip).
+		Updates(map[string]interface{}{"last_used": now, "last_used_ip": ip}).Error
+}
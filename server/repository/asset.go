@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"next-terminal/server/config"
 	"next-terminal/server/constant"
@@ -37,22 +38,38 @@ func (r assetRepository) FindByProtocolAndIds(c context.Context, protocol string
 	return
 }
 
+// FindByProtocolAndCredentialId 查询使用授权凭证登录的资产
+func (r assetRepository) FindByProtocolAndCredentialId(c context.Context, protocol, credentialId string) (o []model.Asset, err error) {
+	err = r.GetDB(c).Where("protocol = ? and account_type = ? and credential_id = ?", protocol, constant.AccountCredential, credentialId).Find(&o).Error
+	return
+}
+
+// ownedOrShared 资产属于该用户，或者在有效期内授权给了该用户及其所在的用户组
+func (r assetRepository) ownedOrShared(c context.Context, userId string) (string, []interface{}, error) {
+	// 查询用户所在用户组列表
+	userGroupIds, err := UserGroupMemberRepository.FindUserGroupIdsByUserId(c, userId)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	if len(userGroupIds) > 0 {
+		query := "assets.owner = ? or ((resource_sharers.user_id = ? or resource_sharers.user_group_id in ?) and " + validSharerCondition + ")"
+		return query, []interface{}{userId, userId, userGroupIds, now, now}, nil
+	}
+	query := "assets.owner = ? or (resource_sharers.user_id = ? and " + validSharerCondition + ")"
+	return query, []interface{}{userId, userId, now, now}, nil
+}
+
 func (r assetRepository) FindByProtocolAndUser(c context.Context, protocol string, account model.User) (o []model.Asset, err error) {
 	db := r.GetDB(c).Table("assets").Select("assets.id,assets.name,assets.ip,assets.port,assets.protocol,assets.active,assets.owner,assets.created,assets.tags,assets.description, users.nickname as owner_name").Joins("left join users on assets.owner = users.id").Joins("left join resource_sharers on assets.id = resource_sharers.resource_id").Group("assets.id")
 
 	if constant.TypeUser == account.Type {
-		owner := account.ID
-		db = db.Where("assets.owner = ? or resource_sharers.user_id = ?", owner, owner)
-
-		// 查询用户所在用户组列表
-		userGroupIds, err := UserGroupMemberRepository.FindUserGroupIdsByUserId(c, account.ID)
+		query, args, err := r.ownedOrShared(c, account.ID)
 		if err != nil {
 			return nil, err
 		}
-
-		if len(userGroupIds) > 0 {
-			db = db.Or("resource_sharers.user_group_id in ?", userGroupIds)
-		}
+		db = db.Where(query, args...)
 	}
 
 	if len(protocol) > 0 {
@@ -67,20 +84,12 @@ func (r assetRepository) Find(c context.Context, pageIndex, pageSize int, name,
 	dbCounter := r.GetDB(c).Table("assets").Select("DISTINCT assets.id").Joins("left join resource_sharers on assets.id = resource_sharers.resource_id").Group("assets.id")
 
 	if constant.TypeUser == account.Type {
-		owner := account.ID
-		db = db.Where("assets.owner = ? or resource_sharers.user_id = ?", owner, owner)
-		dbCounter = dbCounter.Where("assets.owner = ? or resource_sharers.user_id = ?", owner, owner)
-
-		// 查询用户所在用户组列表
-		userGroupIds, err := UserGroupMemberRepository.FindUserGroupIdsByUserId(c, account.ID)
+		query, args, err := r.ownedOrShared(c, account.ID)
 		if err != nil {
 			return nil, 0, err
 		}
-
-		if len(userGroupIds) > 0 {
-			db = db.Or("resource_sharers.user_group_id in ?", userGroupIds)
-			dbCounter = dbCounter.Or("resource_sharers.user_group_id in ?", userGroupIds)
-		}
+		db = db.Where(query, args...)
+		dbCounter = dbCounter.Where(query, args...)
 	} else {
 		if len(owner) > 0 {
 			db = db.Where("assets.owner = ?", owner)
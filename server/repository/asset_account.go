@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/model"
+)
+
+type assetAccountRepository struct {
+	baseRepository
+}
+
+func (r assetAccountRepository) FindByAssetId(c context.Context, assetId string) (o []model.AssetAccount, err error) {
+	err = r.GetDB(c).Where("asset_id = ?", assetId).Order("name asc").Find(&o).Error
+	if o == nil {
+		o = make([]model.AssetAccount, 0)
+	}
+	return
+}
+
+func (r assetAccountRepository) FindById(c context.Context, id string) (o model.AssetAccount, err error) {
+	err = r.GetDB(c).Where("id = ?", id).First(&o).Error
+	return
+}
+
+func (r assetAccountRepository) FindByAssetIdAndName(c context.Context, assetId, name string) (o model.AssetAccount, err error) {
+	err = r.GetDB(c).Where("asset_id = ? and name = ?", assetId, name).First(&o).Error
+	return
+}
+
+func (r assetAccountRepository) ExistByAssetIdAndName(c context.Context, assetId, name, excludeId string) (bool, error) {
+	var count int64
+	err := r.GetDB(c).Table("asset_accounts").Where("asset_id = ? and name = ? and id <> ?", assetId, name, excludeId).Count(&count).Error
+	return count > 0, err
+}
+
+// FindByCredentialId 查询使用授权凭证的资产账号
+func (r assetAccountRepository) FindByCredentialId(c context.Context, credentialId string) (o []model.AssetAccount, err error) {
+	err = r.GetDB(c).Where("account_type = ? and credential_id = ?", constant.AccountCredential, credentialId).Find(&o).Error
+	return
+}
+
+func (r assetAccountRepository) FindAll(c context.Context) (o []model.AssetAccount, err error) {
+	err = r.GetDB(c).Find(&o).Error
+	return
+}
+
+func (r assetAccountRepository) Create(c context.Context, o *model.AssetAccount) error {
+	return r.GetDB(c).Create(o).Error
+}
+
+func (r assetAccountRepository) UpdateById(c context.Context, o *model.AssetAccount, id string) error {
+	o.ID = id
+	return r.GetDB(c).Updates(o).Error
+}
+
+func (r assetAccountRepository) DeleteById(c context.Context, id string) error {
+	return r.GetDB(c).Where("id = ?", id).Delete(&model.AssetAccount{}).Error
+}
+
+func (r assetAccountRepository) DeleteByAssetId(c context.Context, assetId string) error {
+	return r.GetDB(c).Where("asset_id = ?", assetId).Delete(&model.AssetAccount{}).Error
+}
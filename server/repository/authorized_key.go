@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"next-terminal/server/model"
+	"next-terminal/server/utils"
+)
+
+type authorizedKeyRepository struct {
+	baseRepository
+}
+
+func (r authorizedKeyRepository) FindByUserId(c context.Context, userId string) (o []model.AuthorizedKey, err error) {
+	err = r.GetDB(c).Where("user_id = ?", userId).Order("created desc").Find(&o).Error
+	if o == nil {
+		o = make([]model.AuthorizedKey, 0)
+	}
+	return
+}
+
+func (r authorizedKeyRepository) FindByUserIdAndFingerprint(c context.Context, userId, fingerprint string) (o model.AuthorizedKey, err error) {
+	err = r.GetDB(c).Where("user_id = ? and fingerprint = ?", userId, fingerprint).First(&o).Error
+	return
+}
+
+func (r authorizedKeyRepository) ExistByUserIdAndFingerprint(c context.Context, userId, fingerprint string) (exist bool, err error) {
+	var count int64
+	err = r.GetDB(c).Model(&model.AuthorizedKey{}).Where("user_id = ? and fingerprint = ?", userId, fingerprint).Count(&count).Error
+	return count > 0, err
+}
+
+func (r authorizedKeyRepository) Create(c context.Context, o *model.AuthorizedKey) error {
+	return r.GetDB(c).Create(o).Error
+}
+
+func (r authorizedKeyRepository) UpdateLastUsedById(c context.Context, id string) error {
+	return r.GetDB(c).Model(&model.AuthorizedKey{}).Where("id = ?", id).Update("last_used", utils.NowJsonTime()).Error
+}
+
+func (r authorizedKeyRepository) DeleteByIdAndUserId(c context.Context, id, userId string) error {
+	return r.GetDB(c).Where("id = ? and user_id = ?", id, userId).Delete(&model.AuthorizedKey{}).Error
+}
+
+func (r authorizedKeyRepository) DeleteByUserId(c context.Context, userId string) error {
+	return r.GetDB(c).Where("user_id = ?", userId).Delete(&model.AuthorizedKey{}).Error
+}
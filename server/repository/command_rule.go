@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+
+	"next-terminal/server/model"
+)
+
+type commandRuleRepository struct {
+	baseRepository
+}
+
+func (r commandRuleRepository) Find(c context.Context, pageIndex, pageSize int, name, action, order, field string) (o []model.CommandRule, total int64, err error) {
+	m := model.CommandRule{}
+	db := r.GetDB(c).Table(m.TableName())
+	dbCounter := r.GetDB(c).Table(m.TableName())
+
+	if len(name) > 0 {
+		db = db.Where("name like ?", "%"+name+"%")
+		dbCounter = dbCounter.Where("name like ?", "%"+name+"%")
+	}
+
+	if len(action) > 0 {
+		db = db.Where("action = ?", action)
+		dbCounter = dbCounter.Where("action = ?", action)
+	}
+
+	err = dbCounter.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if order == "descend" {
+		order = "desc"
+	} else {
+		order = "asc"
+	}
+
+	if field == "name" {
+		field = "name"
+	} else if field == "created" {
+		field = "created"
+	} else {
+		field = "priority"
+	}
+
+	err = db.Order(field + " " + order).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&o).Error
+	if o == nil {
+		o = make([]model.CommandRule, 0)
+	}
+	return
+}
+
+func (r commandRuleRepository) FindById(c context.Context, id string) (o model.CommandRule, err error) {
+	err = r.GetDB(c).Where("id = ?", id).First(&o).Error
+	return
+}
+
+// FindEnabled 按优先级从小到大查询已启用的规则
+func (r commandRuleRepository) FindEnabled(c context.Context) (o []model.CommandRule, err error) {
+	err = r.GetDB(c).Where("enabled = ?", true).Order("priority asc, created asc").Find(&o).Error
+	return
+}
+
+func (r commandRuleRepository) Create(c context.Context, o *model.CommandRule) error {
+	return r.GetDB(c).Create(o).Error
+}
+
+// UpdateById 使用 Select 更新，允许清空生效范围及禁用规则
+func (r commandRuleRepository) UpdateById(c context.Context, o *model.CommandRule, id string) error {
+	return r.GetDB(c).Model(&model.CommandRule{}).Where("id = ?", id).
+		Select("name", "pattern", "action", "priority", "asset_ids", "user_ids", "user_group_ids", "enabled").Updates(o).Error
+}
+
+func (r commandRuleRepository) DeleteById(c context.Context, id string) error {
+	return r.GetDB(c).Where("id = ?", id).Delete(&model.CommandRule{}).Error
+}
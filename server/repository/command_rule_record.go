@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+
+	"next-terminal/server/model"
+)
+
+type commandRuleRecordRepository struct {
+	baseRepository
+}
+
+func (r commandRuleRecordRepository) Find(c context.Context, pageIndex, pageSize int, sessionId, username, assetName, action, command string) (o []model.CommandRuleRecord, total int64, err error) {
+	m := model.CommandRuleRecord{}
+	db := r.GetDB(c).Table(m.TableName())
+	dbCounter := r.GetDB(c).Table(m.TableName())
+
+	if len(sessionId) > 0 {
+		db = db.Where("session_id = ?", sessionId)
+		dbCounter = dbCounter.Where("session_id = ?", sessionId)
+	}
+
+	if len(username) > 0 {
+		db = db.Where("username like ?", "%"+username+"%")
+		dbCounter = dbCounter.Where("username like ?", "%"+username+"%")
+	}
+
+	if len(assetName) > 0 {
+		db = db.Where("asset_name like ?", "%"+assetName+"%")
+		dbCounter = dbCounter.Where("asset_name like ?", "%"+assetName+"%")
+	}
+
+	if len(action) > 0 {
+		db = db.Where("action = ?", action)
+		dbCounter = dbCounter.Where("action = ?", action)
+	}
+
+	if len(command) > 0 {
+		db = db.Where("command like ?", "%"+command+"%")
+		dbCounter = dbCounter.Where("command like ?", "%"+command+"%")
+	}
+
+	err = dbCounter.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = db.Order("created desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&o).Error
+	if o == nil {
+		o = make([]model.CommandRuleRecord, 0)
+	}
+	return
+}
+
+func (r commandRuleRecordRepository) Create(c context.Context, o *model.CommandRuleRecord) error {
+	return r.GetDB(c).Create(o).Error
+}
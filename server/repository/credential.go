@@ -5,6 +5,8 @@ import (
 
 	"next-terminal/server/constant"
 	"next-terminal/server/model"
+
+	"gorm.io/gorm/clause"
 )
 
 type credentialRepository struct {
@@ -18,7 +20,7 @@ func (r credentialRepository) FindByUser(c context.Context) (o []model.Credentia
 }
 
 func (r credentialRepository) Find(c context.Context, pageIndex, pageSize int, name, order, field string, account *model.User) (o []model.CredentialForPage, total int64, err error) {
-	db := r.GetDB(c).Table("credentials").Select("credentials.id,credentials.name,credentials.type,credentials.username,credentials.owner,credentials.created,users.nickname as owner_name,COUNT(resource_sharers.user_id) as sharer_count").Joins("left join users on credentials.owner = users.id").Joins("left join resource_sharers on credentials.id = resource_sharers.resource_id").Group("credentials.id")
+	db := r.GetDB(c).Table("credentials").Select("credentials.id,credentials.name,credentials.type,credentials.username,credentials.owner,credentials.created,credentials.needs_rotation,users.nickname as owner_name,COUNT(resource_sharers.user_id) as sharer_count").Joins("left join users on credentials.owner = users.id").Joins("left join resource_sharers on credentials.id = resource_sharers.resource_id").Group("credentials.id")
 	dbCounter := r.GetDB(c).Table("credentials").Select("DISTINCT credentials.id").Joins("left join resource_sharers on credentials.id = resource_sharers.resource_id").Group("credentials.id")
 
 	if constant.TypeUser == account.Type {
@@ -65,11 +67,21 @@ func (r credentialRepository) FindById(c context.Context, id string) (o model.Cr
 	return
 }
 
+// FindByIdForUpdate 在事务中查询并锁定凭证，用于串行化同一凭证的并发操作
+func (r credentialRepository) FindByIdForUpdate(c context.Context, id string) (o model.Credential, err error) {
+	err = r.GetDB(c).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&o).Error
+	return
+}
+
 func (r credentialRepository) UpdateById(c context.Context, o *model.Credential, id string) error {
 	o.ID = id
 	return r.GetDB(c).Updates(o).Error
 }
 
+func (r credentialRepository) UpdateNeedsRotationById(c context.Context, needsRotation bool, id string) error {
+	return r.GetDB(c).Model(&model.Credential{}).Where("id = ?", id).Update("needs_rotation", needsRotation).Error
+}
+
 func (r credentialRepository) DeleteById(c context.Context, id string) error {
 	return r.GetDB(c).Where("id = ?", id).Delete(&model.Credential{}).Error
 }
@@ -101,3 +113,8 @@ func (r credentialRepository) FindAll(c context.Context) (o []model.Credential,
 	err = r.GetDB(c).Find(&o).Error
 	return
 }
+
+func (r credentialRepository) FindByIds(c context.Context, ids []string) (o []model.Credential, err error) {
+	err = r.GetDB(c).Where("id in ?", ids).Find(&o).Error
+	return
+}
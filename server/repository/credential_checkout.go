@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/model"
+)
+
+type credentialCheckoutRepository struct {
+	baseRepository
+}
+
+// Find 分页查询借出记录，userId 不为空时只查询该用户的借出，ownerId 不为空时只查询该用户名下凭证的借出
+func (r credentialCheckoutRepository) Find(c context.Context, pageIndex, pageSize int, userId, ownerId, status string) (o []model.CredentialCheckoutForPage, total int64, err error) {
+	db := r.GetDB(c).Table("credential_checkouts").
+		Select("credential_checkouts.id, credential_checkouts.credential_id, credentials.name as credential_name, credential_checkouts.user_id, users.username, credential_checkouts.reason, credential_checkouts.duration, credential_checkouts.status, credential_checkouts.approver_id, approvers.username as approver_name, credential_checkouts.comment, credential_checkouts.expires_at, credential_checkouts.checked_in, credential_checkouts.created").
+		Joins("left join users on credential_checkouts.user_id = users.id").
+		Joins("left join credentials on credential_checkouts.credential_id = credentials.id").
+		Joins("left join users as approvers on credential_checkouts.approver_id = approvers.id")
+	dbCounter := r.GetDB(c).Table("credential_checkouts").Joins("left join credentials on credential_checkouts.credential_id = credentials.id")
+
+	if len(userId) > 0 {
+		db = db.Where("credential_checkouts.user_id = ?", userId)
+		dbCounter = dbCounter.Where("credential_checkouts.user_id = ?", userId)
+	}
+
+	if len(ownerId) > 0 {
+		db = db.Where("credentials.owner = ?", ownerId)
+		dbCounter = dbCounter.Where("credentials.owner = ?", ownerId)
+	}
+
+	if len(status) > 0 {
+		db = db.Where("credential_checkouts.status = ?", status)
+		dbCounter = dbCounter.Where("credential_checkouts.status = ?", status)
+	}
+
+	err = dbCounter.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = db.Order("credential_checkouts.created desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&o).Error
+	if o == nil {
+		o = make([]model.CredentialCheckoutForPage, 0)
+	}
+	return
+}
+
+func (r credentialCheckoutRepository) FindById(c context.Context, id string) (o model.CredentialCheckout, err error) {
+	err = r.GetDB(c).Where("id = ?", id).First(&o).Error
+	return
+}
+
+// FindActiveExpiredBefore 查询已经到期但尚未归还的借出
+func (r credentialCheckoutRepository) FindActiveExpiredBefore(c context.Context, t time.Time) (o []model.CredentialCheckout, err error) {
+	err = r.GetDB(c).Where("status = ? and expires_at < ?", constant.CheckoutActive, t).Find(&o).Error
+	return
+}
+
+// FindActiveByCredentialId 查询凭证当前的借出，同一时间只能借出给一个用户
+func (r credentialCheckoutRepository) FindActiveByCredentialId(c context.Context, credentialId string) (o []model.CredentialCheckout, err error) {
+	err = r.GetDB(c).Where("credential_id = ? and status = ?", credentialId, constant.CheckoutActive).Find(&o).Error
+	return
+}
+
+func (r credentialCheckoutRepository) ExistOpenByUserIdAndCredentialId(c context.Context, userId, credentialId string) (bool, error) {
+	var count int64
+	err := r.GetDB(c).Table("credential_checkouts").
+		Where("user_id = ? and credential_id = ? and status in ?", userId, credentialId, []string{constant.CheckoutPending, constant.CheckoutActive}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r credentialCheckoutRepository) Create(c context.Context, o *model.CredentialCheckout) error {
+	return r.GetDB(c).Create(o).Error
+}
+
+// UpdateStatusById 仅当借出处于 fromStatus 状态时才更新，返回是否更新成功，避免并发操作
+func (r credentialCheckoutRepository) UpdateStatusById(c context.Context, o *model.CredentialCheckout, id, fromStatus string) (bool, error) {
+	db := r.GetDB(c).Model(&model.CredentialCheckout{}).Where("id = ? and status = ?", id, fromStatus).
+		Select("status", "approver_id", "comment", "expires_at", "checked_in").Updates(o)
+	return db.RowsAffected > 0, db.Error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"next-terminal/server/model"
+)
+
+type credentialCheckoutLogRepository struct {
+	baseRepository
+}
+
+func (r credentialCheckoutLogRepository) FindByCheckoutId(c context.Context, checkoutId string) (o []model.CredentialCheckoutLog, err error) {
+	err = r.GetDB(c).Where("checkout_id = ?", checkoutId).Order("created asc").Find(&o).Error
+	if o == nil {
+		o = make([]model.CredentialCheckoutLog, 0)
+	}
+	return
+}
+
+func (r credentialCheckoutLogRepository) Create(c context.Context, o *model.CredentialCheckoutLog) error {
+	return r.GetDB(c).Create(o).Error
+}
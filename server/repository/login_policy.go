@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"next-terminal/server/model"
+)
+
+type loginPolicyRepository struct {
+	baseRepository
+}
+
+func (r loginPolicyRepository) Find(c context.Context, pageIndex, pageSize int, name, order, field string) (o []model.LoginPolicy, total int64, err error) {
+	m := model.LoginPolicy{}
+	db := r.GetDB(c).Table(m.TableName())
+	dbCounter := r.GetDB(c).Table(m.TableName())
+
+	if len(name) > 0 {
+		db = db.Where("name like ?", "%"+name+"%")
+		dbCounter = dbCounter.Where("name like ?", "%"+name+"%")
+	}
+
+	err = dbCounter.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if order == "ascend" {
+		order = "asc"
+	} else {
+		order = "desc"
+	}
+
+	if field == "name" {
+		field = "name"
+	} else {
+		field = "created"
+	}
+
+	err = db.Order(field + " " + order).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&o).Error
+	if o == nil {
+		o = make([]model.LoginPolicy, 0)
+	}
+	return
+}
+
+func (r loginPolicyRepository) FindById(c context.Context, id string) (o model.LoginPolicy, err error) {
+	err = r.GetDB(c).Where("id = ?", id).First(&o).Error
+	return
+}
+
+// FindEnabledByUserId 查询直接分配给用户以及用户所在用户组的已启用策略
+func (r loginPolicyRepository) FindEnabledByUserId(c context.Context, userId string) (o []model.LoginPolicy, err error) {
+	userGroupIds := r.GetDB(c).Table("user_group_members").Select("user_group_id").Where("user_id = ?", userId)
+	policyIds := r.GetDB(c).Table("login_policy_members").Select("login_policy_id").Where("user_id = ? or user_group_id in (?)", userId, userGroupIds)
+	err = r.GetDB(c).Where("id in (?) and enabled = ?", policyIds, true).Find(&o).Error
+	return
+}
+
+func (r loginPolicyRepository) Create(c context.Context, o *model.LoginPolicy) error {
+	return r.GetDB(c).Create(o).Error
+}
+
+// UpdateById 使用 Select 更新，允许清空IP、时间段及禁用策略
+func (r loginPolicyRepository) UpdateById(c context.Context, o *model.LoginPolicy, id string) error {
+	return r.GetDB(c).Model(&model.LoginPolicy{}).Where("id = ?", id).
+		Select("name", "ips", "time_zone", "time_periods", "enabled").Updates(o).Error
+}
+
+func (r loginPolicyRepository) DeleteById(c context.Context, id string) error {
+	return r.GetDB(c).Where("id = ?", id).Delete(&model.LoginPolicy{}).Error
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"next-terminal/server/model"
+)
+
+type loginPolicyMemberRepository struct {
+	baseRepository
+}
+
+func (r loginPolicyMemberRepository) FindUserIdsByLoginPolicyId(c context.Context, loginPolicyId string) (o []string, err error) {
+	err = r.GetDB(c).Table("login_policy_members").Select("user_id").Where("login_policy_id = ? and user_id <> ''", loginPolicyId).Find(&o).Error
+	return
+}
+
+func (r loginPolicyMemberRepository) FindUserGroupIdsByLoginPolicyId(c context.Context, loginPolicyId string) (o []string, err error) {
+	err = r.GetDB(c).Table("login_policy_members").Select("user_group_id").Where("login_policy_id = ? and user_group_id <> ''", loginPolicyId).Find(&o).Error
+	return
+}
+
+func (r loginPolicyMemberRepository) Create(c context.Context, o *model.LoginPolicyMember) error {
+	return r.GetDB(c).Create(o).Error
+}
+
+func (r loginPolicyMemberRepository) DeleteByLoginPolicyId(c context.Context, loginPolicyId string) error {
+	return r.GetDB(c).Where("login_policy_id = ?", loginPolicyId).Delete(&model.LoginPolicyMember{}).Error
+}
+
+func (r loginPolicyMemberRepository) DeleteByUserId(c context.Context, userId string) error {
+	return r.GetDB(c).Where("user_id = ?", userId).Delete(&model.LoginPolicyMember{}).Error
+}
+
+func (r loginPolicyMemberRepository) DeleteByUserGroupId(c context.Context, userGroupId string) error {
+	return r.GetDB(c).Where("user_group_id = ?", userGroupId).Delete(&model.LoginPolicyMember{}).Error
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+
+	"next-terminal/server/model"
+)
+
+type passwordHistoryRepository struct {
+	baseRepository
+}
+
+func (r passwordHistoryRepository) FindLatestByUserId(c context.Context, userId string, limit int) (o []model.PasswordHistory, err error) {
+	err = r.GetDB(c).Where("user_id = ?", userId).Order("created desc").Limit(limit).Find(&o).Error
+	return
+}
+
+func (r passwordHistoryRepository) Create(c context.Context, o *model.PasswordHistory) error {
+	return r.GetDB(c).Create(o).Error
+}
+
+// DeleteByUserIdExcept 删除用户除 ids 以外的历史密码
+func (r passwordHistoryRepository) DeleteByUserIdExcept(c context.Context, userId string, ids []string) error {
+	db := r.GetDB(c).Where("user_id = ?", userId)
+	if len(ids) > 0 {
+		db = db.Where("id not in ?", ids)
+	}
+	return db.Delete(&model.PasswordHistory{}).Error
+}
+
+func (r passwordHistoryRepository) DeleteByUserId(c context.Context, userId string) error {
+	return r.GetDB(c).Where("user_id = ?", userId).Delete(&model.PasswordHistory{}).Error
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"next-terminal/server/model"
+)
+
+type recoveryCodeRepository struct {
+	baseRepository
+}
+
+func (r recoveryCodeRepository) CountByUserId(c context.Context, userId string) (total int64, err error) {
+	err = r.GetDB(c).Model(&model.RecoveryCode{}).Where("user_id = ?", userId).Count(&total).Error
+	return
+}
+
+func (r recoveryCodeRepository) Create(c context.Context, o *model.RecoveryCode) error {
+	return r.GetDB(c).Create(o).Error
+}
+
+// DeleteByUserIdAndCode 返回值表示恢复码是否存在，删除成功即视为已使用
+func (r recoveryCodeRepository) DeleteByUserIdAndCode(c context.Context, userId, code string) (bool, error) {
+	tx := r.GetDB(c).Where("user_id = ? and code = ?", userId, code).Delete(&model.RecoveryCode{})
+	return tx.RowsAffected > 0, tx.Error
+}
+
+func (r recoveryCodeRepository) DeleteByUserId(c context.Context, userId string) error {
+	return r.GetDB(c).Where("user_id = ?", userId).Delete(&model.RecoveryCode{}).Error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"next-terminal/server/model"
 	"next-terminal/server/utils"
@@ -10,6 +11,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// validSharerCondition 授权处于有效期内，两个参数均为当前时间
+const validSharerCondition = "(resource_sharers.valid_from is null or resource_sharers.valid_from <= ?) and (resource_sharers.valid_until is null or resource_sharers.valid_until > ?)"
+
 type resourceSharerRepository struct {
 	baseRepository
 }
@@ -119,9 +123,12 @@ func (r *resourceSharerRepository) FindAssetIdsByUserId(c context.Context, userI
 		return nil, err
 	}
 
-	db := r.GetDB(c).Table("resource_sharers").Select("resource_id").Where("user_id = ?", userId)
+	now := time.Now()
+	db := r.GetDB(c).Table("resource_sharers").Select("resource_id").Where(validSharerCondition, now, now)
 	if len(groupIds) > 0 {
-		db = db.Or("user_group_id in ?", groupIds)
+		db = db.Where("user_id = ? or user_group_id in ?", userId, groupIds)
+	} else {
+		db = db.Where("user_id = ?", userId)
 	}
 	err = db.Find(&sharerAssetIds).Error
 	if err != nil {
@@ -148,9 +155,11 @@ func (r *resourceSharerRepository) FindByResourceIdAndUserId(c context.Context,
 	if err != nil {
 		return
 	}
-	db := r.GetDB(c).Where("( resource_id = ? and user_id = ? )", assetId, userId)
+	db := r.GetDB(c).Where("resource_id = ?", assetId)
 	if len(groupIds) > 0 {
-		db = db.Or("user_group_id in ?", groupIds)
+		db = db.Where("user_id = ? or user_group_id in ?", userId, groupIds)
+	} else {
+		db = db.Where("user_id = ?", userId)
 	}
 	err = db.Find(&resourceSharers).Error
 	return
@@ -159,16 +168,16 @@ func (r *resourceSharerRepository) FindByResourceIdAndUserId(c context.Context,
 func (r *resourceSharerRepository) Find(c context.Context, resourceId, resourceType, userId, userGroupId string) (resourceSharers []model.ResourceSharer, err error) {
 	db := r.GetDB(c)
 	if resourceId != "" {
-		db = db.Where("resource_id = ?")
+		db = db.Where("resource_id = ?", resourceId)
 	}
 	if resourceType != "" {
-		db = db.Where("resource_type = ?")
+		db = db.Where("resource_type = ?", resourceType)
 	}
 	if userId != "" {
-		db = db.Where("user_id = ?")
+		db = db.Where("user_id = ?", userId)
 	}
 	if userGroupId != "" {
-		db = db.Where("user_group_id = ?")
+		db = db.Where("user_group_id = ?", userGroupId)
 	}
 	err = db.Find(&resourceSharers).Error
 	return
@@ -182,3 +191,19 @@ func (r *resourceSharerRepository) FindAll(c context.Context) (o []model.Resourc
 func (r *resourceSharerRepository) DeleteById(ctx context.Context, id string) error {
 	return r.GetDB(ctx).Where("id = ?", id).Delete(&model.ResourceSharer{}).Error
 }
+
+// FindExpired 查询已经过期的授权
+func (r *resourceSharerRepository) FindExpired(ctx context.Context, t time.Time) (o []model.ResourceSharer, err error) {
+	err = r.GetDB(ctx).Where("valid_until is not null and valid_until <= ?", t).Find(&o).Error
+	return
+}
+
+// FindExpiringNotNotified 查询将在指定时间之前过期且尚未发送提醒的授权
+func (r *resourceSharerRepository) FindExpiringNotNotified(ctx context.Context, t time.Time) (o []model.ResourceSharer, err error) {
+	err = r.GetDB(ctx).Where("valid_until is not null and valid_until <= ? and notified = ?", t, false).Find(&o).Error
+	return
+}
+
+func (r *resourceSharerRepository) UpdateNotifiedById(ctx context.Context, id string) error {
+	return r.GetDB(ctx).Model(&model.ResourceSharer{}).Where("id = ?", id).Update("notified", true).Error
+}
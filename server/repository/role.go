@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+
+	"next-terminal/server/model"
+)
+
+type roleRepository struct {
+	baseRepository
+}
+
+func (r roleRepository) FindAll(c context.Context) (o []model.Role, err error) {
+	err = r.GetDB(c).Order("name asc").Find(&o).Error
+	return
+}
+
+func (r roleRepository) Find(c context.Context, pageIndex, pageSize int, name, order, field string) (o []model.Role, total int64, err error) {
+	m := model.Role{}
+	db := r.GetDB(c).Table(m.TableName())
+	dbCounter := r.GetDB(c).Table(m.TableName())
+
+	if len(name) > 0 {
+		db = db.Where("name like ?", "%"+name+"%")
+		dbCounter = dbCounter.Where("name like ?", "%"+name+"%")
+	}
+
+	err = dbCounter.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if order == "ascend" {
+		order = "asc"
+	} else {
+		order = "desc"
+	}
+
+	if field == "name" {
+		field = "name"
+	} else {
+		field = "created"
+	}
+
+	err = db.Order(field + " " + order).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&o).Error
+	if o == nil {
+		o = make([]model.Role, 0)
+	}
+	return
+}
+
+func (r roleRepository) FindById(c context.Context, id string) (o model.Role, err error) {
+	err = r.GetDB(c).Where("id = ?", id).First(&o).Error
+	return
+}
+
+// FindByUserId 查询直接分配给用户以及用户所在用户组的角色
+func (r roleRepository) FindByUserId(c context.Context, userId string) (o []model.Role, err error) {
+	userGroupIds := r.GetDB(c).Table("user_group_members").Select("user_group_id").Where("user_id = ?", userId)
+	roleIds := r.GetDB(c).Table("role_members").Select("role_id").Where("user_id = ? or user_group_id in (?)", userId, userGroupIds)
+	err = r.GetDB(c).Where("id in (?)", roleIds).Find(&o).Error
+	return
+}
+
+// FindByUserGroupId 查询分配给用户组的角色
+func (r roleRepository) FindByUserGroupId(c context.Context, userGroupId string) (o []model.Role, err error) {
+	roleIds := r.GetDB(c).Table("role_members").Select("role_id").Where("user_group_id = ?", userGroupId)
+	err = r.GetDB(c).Where("id in (?)", roleIds).Find(&o).Error
+	return
+}
+
+func (r roleRepository) ExistByName(c context.Context, name string) (exist bool, err error) {
+	var count int64
+	err = r.GetDB(c).Model(&model.Role{}).Where("name = ?", name).Count(&count).Error
+	return count > 0, err
+}
+
+func (r roleRepository) Create(c context.Context, o *model.Role) error {
+	return r.GetDB(c).Create(o).Error
+}
+
+func (r roleRepository) UpdateById(c context.Context, o *model.Role, id string) error {
+	o.ID = id
+	return r.GetDB(c).Updates(o).Error
+}
+
+func (r roleRepository) DeleteById(c context.Context, id string) error {
+	return r.GetDB(c).Where("id = ?", id).Delete(&model.Role{}).Error
+}
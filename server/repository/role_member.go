@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"next-terminal/server/model"
+)
+
+type roleMemberRepository struct {
+	baseRepository
+}
+
+func (r roleMemberRepository) FindUserIdsByRoleId(c context.Context, roleId string) (o []string, err error) {
+	err = r.GetDB(c).Table("role_members").Select("user_id").Where("role_id = ? and user_id <> ''", roleId).Find(&o).Error
+	return
+}
+
+func (r roleMemberRepository) FindUserGroupIdsByRoleId(c context.Context, roleId string) (o []string, err error) {
+	err = r.GetDB(c).Table("role_members").Select("user_group_id").Where("role_id = ? and user_group_id <> ''", roleId).Find(&o).Error
+	return
+}
+
+func (r roleMemberRepository) Create(c context.Context, o *model.RoleMember) error {
+	return r.GetDB(c).Create(o).Error
+}
+
+func (r roleMemberRepository) DeleteByRoleId(c context.Context, roleId string) error {
+	return r.GetDB(c).Where("role_id = ?", roleId).Delete(&model.RoleMember{}).Error
+}
+
+func (r roleMemberRepository) DeleteByUserId(c context.Context, userId string) error {
+	return r.GetDB(c).Where("user_id = ?", userId).Delete(&model.RoleMember{}).Error
+}
+
+func (r roleMemberRepository) DeleteByUserGroupId(c context.Context, userGroupId string) error {
+	return r.GetDB(c).Where("user_group_id = ?", userGroupId).Delete(&model.RoleMember{}).Error
+}
@@ -113,6 +113,10 @@ func (r sessionRepository) UpdateWindowSizeById(c context.Context, width, height
 	return r.UpdateById(c, &session, id)
 }
 
+func (r sessionRepository) UpdateSupervisorsById(c context.Context, supervisors, id string) error {
+	return r.GetDB(c).Model(&model.Session{}).Where("id = ?", id).Update("supervisors", supervisors).Error
+}
+
 func (r sessionRepository) DeleteById(c context.Context, id string) error {
 	return r.GetDB(c).Where("id = ?", id).Delete(&model.Session{}).Error
 }
@@ -126,6 +130,9 @@ func (r sessionRepository) DeleteByIds(c context.Context, sessionIds []string) e
 		if err := r.DeleteById(c, sessionIds[i]); err != nil {
 			return err
 		}
+		if err := SessionCommandRepository.DeleteBySessionId(c, sessionIds[i]); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -144,6 +151,18 @@ func (r sessionRepository) EmptyPassword(c context.Context) error {
 	return r.GetDB(c).Exec(sql).Error
 }
 
+// CountByCreatorAndStatusIn 统计用户指定状态的会话，excludeId 不为空时不统计该会话
+func (r sessionRepository) CountByCreatorAndStatusIn(c context.Context, creator string, statuses []string, excludeId string) (total int64, err error) {
+	err = r.GetDB(c).Model(&model.Session{}).Where("creator = ? and status in ? and id <> ?", creator, statuses, excludeId).Count(&total).Error
+	return
+}
+
+// CountByAssetIdAndStatusIn 统计资产指定状态的会话，excludeId 不为空时不统计该会话
+func (r sessionRepository) CountByAssetIdAndStatusIn(c context.Context, assetId string, statuses []string, excludeId string) (total int64, err error) {
+	err = r.GetDB(c).Model(&model.Session{}).Where("asset_id = ? and status in ? and id <> ?", assetId, statuses, excludeId).Count(&total).Error
+	return
+}
+
 func (r sessionRepository) CountByStatus(c context.Context, status string) (total int64, err error) {
 	err = r.GetDB(c).Find(&model.Session{}).Where("status = ?", status).Count(&total).Error
 	return
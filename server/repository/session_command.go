@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"next-terminal/server/model"
+)
+
+type sessionCommandRepository struct {
+	baseRepository
+}
+
+func (r sessionCommandRepository) Find(c context.Context, pageIndex, pageSize int, sessionId, username, assetName, command, cwd string, start, end time.Time) (o []model.SessionCommand, total int64, err error) {
+	m := model.SessionCommand{}
+	db := r.GetDB(c).Table(m.TableName())
+	dbCounter := r.GetDB(c).Table(m.TableName())
+
+	if len(sessionId) > 0 {
+		db = db.Where("session_id = ?", sessionId)
+		dbCounter = dbCounter.Where("session_id = ?", sessionId)
+	}
+
+	if len(username) > 0 {
+		db = db.Where("username like ?", "%"+username+"%")
+		dbCounter = dbCounter.Where("username like ?", "%"+username+"%")
+	}
+
+	if len(assetName) > 0 {
+		db = db.Where("asset_name like ?", "%"+assetName+"%")
+		dbCounter = dbCounter.Where("asset_name like ?", "%"+assetName+"%")
+	}
+
+	if len(command) > 0 {
+		db = db.Where("command like ?", "%"+command+"%")
+		dbCounter = dbCounter.Where("command like ?", "%"+command+"%")
+	}
+
+	if len(cwd) > 0 {
+		db = db.Where("cwd like ?", "%"+cwd+"%")
+		dbCounter = dbCounter.Where("cwd like ?", "%"+cwd+"%")
+	}
+
+	if !start.IsZero() {
+		db = db.Where("created >= ?", start)
+		dbCounter = dbCounter.Where("created >= ?", start)
+	}
+
+	if !end.IsZero() {
+		db = db.Where("created <= ?", end)
+		dbCounter = dbCounter.Where("created <= ?", end)
+	}
+
+	err = dbCounter.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 同一会话中的命令按执行顺序展示
+	order := "created desc"
+	if len(sessionId) > 0 {
+		order = "created asc"
+	}
+	err = db.Order(order).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&o).Error
+	if o == nil {
+		o = make([]model.SessionCommand, 0)
+	}
+	return
+}
+
+func (r sessionCommandRepository) Create(c context.Context, o *model.SessionCommand) error {
+	return r.GetDB(c).Create(o).Error
+}
+
+func (r sessionCommandRepository) UpdateExitCodeById(c context.Context, exitCode int, id string) error {
+	return r.GetDB(c).Model(&model.SessionCommand{}).Where("id = ?", id).Update("exit_code", exitCode).Error
+}
+
+func (r sessionCommandRepository) DeleteBySessionId(c context.Context, sessionId string) error {
+	return r.GetDB(c).Where("session_id = ?", sessionId).Delete(&model.SessionCommand{}).Error
+}
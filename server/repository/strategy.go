@@ -59,7 +59,8 @@ func (r strategyRepository) Create(c context.Context, m *model.Strategy) error {
 
 func (r strategyRepository) UpdateById(c context.Context, o *model.Strategy, id string) error {
 	o.ID = id
-	return r.GetDB(c).Updates(o).Error
+	// 会话时长等限制允许修改为不限制，因此需要更新零值
+	return r.GetDB(c).Model(o).Select("*").Omit("id", "created").Updates(o).Error
 }
 
 func (r strategyRepository) FindById(c context.Context, id string) (m model.Strategy, err error) {
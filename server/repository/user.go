@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 
+	"next-terminal/server/constant"
 	"next-terminal/server/model"
+	"next-terminal/server/utils"
 )
 
 type userRepository struct {
@@ -16,7 +18,7 @@ func (r userRepository) FindAll(c context.Context) (o []model.User, err error) {
 }
 
 func (r userRepository) Find(c context.Context, pageIndex, pageSize int, username, nickname, mail, order, field string) (o []model.UserForPage, total int64, err error) {
-	db := r.GetDB(c).Table("users").Select("users.id,users.username,users.nickname,users.mail,users.online,users.created,users.type,users.status,users.source, count(resource_sharers.user_id) as sharer_asset_count, users.totp_secret").Joins("left join resource_sharers on users.id = resource_sharers.user_id and resource_sharers.resource_type = 'asset'").Group("users.id")
+	db := r.GetDB(c).Table("users").Select("users.id,users.username,users.nickname,users.mail,users.online,users.created,users.type,users.status,users.source,users.login_failed_count,users.locked_at, count(resource_sharers.user_id) as sharer_asset_count, users.totp_secret").Joins("left join resource_sharers on users.id = resource_sharers.user_id and resource_sharers.resource_type = 'asset'").Group("users.id")
 	dbCounter := r.GetDB(c).Table("users")
 
 	if len(username) > 0 {
@@ -78,6 +80,16 @@ func (r userRepository) FindByUsername(c context.Context, username string) (o mo
 	return
 }
 
+// FindBySourceAndExternalId 按外部身份源中用户的标识查询
+func (r userRepository) FindBySourceAndExternalId(c context.Context, source, externalId string) (o model.User, err error) {
+	err = r.GetDB(c).Where("source = ? and external_id = ?", source, externalId).First(&o).Error
+	return
+}
+
+func (r userRepository) UpdateExternalId(c context.Context, id, externalId string) error {
+	return r.GetDB(c).Model(&model.User{}).Where("id = ?", id).Update("external_id", externalId).Error
+}
+
 func (r userRepository) ExistByUsername(c context.Context, username string) (exist bool, err error) {
 	user := model.User{}
 	var count uint64
@@ -104,6 +116,26 @@ func (r userRepository) Update(c context.Context, o *model.User) error {
 	return r.GetDB(c).Updates(o).Error
 }
 
+// UpdateStatusById 修改用户状态，同时清除同步停用的标记，管理员手动停用的用户不会被同步重新启用
+func (r userRepository) UpdateStatusById(c context.Context, id, status string) error {
+	return r.GetDB(c).Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":           status,
+		"disabled_by_sync": false,
+	}).Error
+}
+
+// UpdateDisabledBySyncById 同步时停用目录中已不存在的用户，或重新启用被同步停用的用户
+func (r userRepository) UpdateDisabledBySyncById(c context.Context, id string, disabled bool) error {
+	status := constant.StatusEnabled
+	if disabled {
+		status = constant.StatusDisabled
+	}
+	return r.GetDB(c).Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":           status,
+		"disabled_by_sync": disabled,
+	}).Error
+}
+
 func (r userRepository) UpdateOnlineByUsername(c context.Context, username string, online bool) error {
 	sql := "update users set online = ? where username = ?"
 	return r.GetDB(c).Exec(sql, online, username).Error
@@ -126,3 +158,37 @@ func (r userRepository) Count(c context.Context) (total int64, err error) {
 	err = r.GetDB(c).Find(&model.User{}).Count(&total).Error
 	return
 }
+
+func (r userRepository) FindBySource(c context.Context, source string) (o []model.User, err error) {
+	err = r.GetDB(c).Where("source = ?", source).Find(&o).Error
+	return
+}
+
+// UpdateLoginFailed 修改连续登录失败次数及锁定时间，lockedAt 为空时解除锁定
+func (r userRepository) UpdateLoginFailed(c context.Context, id string, count int, lockedAt utils.JsonTime) error {
+	return r.GetDB(c).Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"login_failed_count": count,
+		"locked_at":          lockedAt,
+	}).Error
+}
+
+// UpdateProfile 修改昵称及邮箱，邮箱为空时同样会被更新
+func (r userRepository) UpdateProfile(c context.Context, id, nickname, mail string) error {
+	return r.GetDB(c).Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"nickname": nickname,
+		"mail":     mail,
+	}).Error
+}
+
+func (r userRepository) UpdateEmailOtp(c context.Context, id string, enabled bool) error {
+	return r.GetDB(c).Model(&model.User{}).Where("id = ?", id).Update("email_otp", enabled).Error
+}
+
+// UpdatePassword 修改密码，forceChange 为 true 时用户下次登录需要修改密码
+func (r userRepository) UpdatePassword(c context.Context, id, password string, forceChange bool) error {
+	return r.GetDB(c).Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password":              password,
+		"password_updated":      utils.NowJsonTime(),
+		"force_change_password": forceChange,
+	}).Error
+}
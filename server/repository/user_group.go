@@ -16,7 +16,7 @@ func (r userGroupRepository) FindAll(c context.Context) (o []model.UserGroup, er
 }
 
 func (r userGroupRepository) Find(c context.Context, pageIndex, pageSize int, name, order, field string) (o []model.UserGroupForPage, total int64, err error) {
-	db := r.GetDB(c).Table("user_groups").Select("user_groups.id, user_groups.name, user_groups.created, count(resource_sharers.user_group_id) as asset_count").Joins("left join resource_sharers on user_groups.id = resource_sharers.user_group_id and resource_sharers.resource_type = 'asset'").Group("user_groups.id")
+	db := r.GetDB(c).Table("user_groups").Select("user_groups.id, user_groups.name, user_groups.created, user_groups.mfa_method, count(resource_sharers.user_group_id) as asset_count").Joins("left join resource_sharers on user_groups.id = resource_sharers.user_group_id and resource_sharers.resource_type = 'asset'").Group("user_groups.id")
 	dbCounter := r.GetDB(c).Table("user_groups")
 	if len(name) > 0 {
 		db = db.Where("user_groups.name like ?", "%"+name+"%")
@@ -78,6 +78,19 @@ func (r userGroupRepository) Update(c context.Context, o *model.UserGroup) error
 	return r.GetDB(c).Updates(o).Error
 }
 
+func (r userGroupRepository) UpdateMfaMethodById(c context.Context, mfaMethod, id string) error {
+	return r.GetDB(c).Model(&model.UserGroup{}).Where("id = ?", id).Update("mfa_method", mfaMethod).Error
+}
+
+// FindMfaMethodsByUserId 查询用户所在用户组要求的双因素认证方式
+func (r userGroupRepository) FindMfaMethodsByUserId(c context.Context, userId string) (o []string, err error) {
+	err = r.GetDB(c).Table("user_groups").Distinct("user_groups.mfa_method").
+		Joins("inner join user_group_members on user_groups.id = user_group_members.user_group_id").
+		Where("user_group_members.user_id = ? and user_groups.mfa_method <> ''", userId).
+		Pluck("user_groups.mfa_method", &o).Error
+	return
+}
+
 func (r userGroupRepository) DeleteById(c context.Context, id string) (err error) {
 	return r.GetDB(c).Where("id = ?", id).Delete(&model.UserGroup{}).Error
 }
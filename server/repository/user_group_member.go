@@ -32,3 +32,10 @@ func (r userGroupMemberRepository) DeleteByUserId(c context.Context, userId stri
 func (r userGroupMemberRepository) DeleteByUserGroupId(c context.Context, userGroupId string) error {
 	return r.GetDB(c).Where("user_group_id = ?", userGroupId).Delete(&model.UserGroupMember{}).Error
 }
+
+// DeleteByUserIdAndSource 删除用户与指定来源用户组的关系
+func (r userGroupMemberRepository) DeleteByUserIdAndSource(c context.Context, userId, source string) error {
+	return r.GetDB(c).Where("user_id = ? and user_group_id in (?)", userId,
+		r.GetDB(c).Table("user_groups").Select("id").Where("source = ?", source)).
+		Delete(&model.UserGroupMember{}).Error
+}
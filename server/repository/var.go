@@ -1,21 +1,37 @@
 package repository
 
 var (
-	PropertyRepository        = new(propertyRepository)
-	UserRepository            = new(userRepository)
-	UserGroupRepository       = new(userGroupRepository)
-	UserGroupMemberRepository = new(userGroupMemberRepository)
-	ResourceSharerRepository  = new(resourceSharerRepository)
-	AssetRepository           = new(assetRepository)
-	CredentialRepository      = new(credentialRepository)
-	CommandRepository         = new(commandRepository)
-	SessionRepository         = new(sessionRepository)
-	SecurityRepository        = new(securityRepository)
-	GatewayRepository         = new(gatewayRepository)
-	JobRepository             = new(jobRepository)
-	JobLogRepository          = new(jobLogRepository)
-	LoginLogRepository        = new(loginLogRepository)
-	StorageRepository         = new(storageRepository)
-	StrategyRepository        = new(strategyRepository)
-	AccessTokenRepository     = new(accessTokenRepository)
+	PropertyRepository              = new(propertyRepository)
+	UserRepository                  = new(userRepository)
+	UserGroupRepository             = new(userGroupRepository)
+	UserGroupMemberRepository       = new(userGroupMemberRepository)
+	ResourceSharerRepository        = new(resourceSharerRepository)
+	AssetRepository                 = new(assetRepository)
+	CredentialRepository            = new(credentialRepository)
+	CommandRepository               = new(commandRepository)
+	SessionRepository               = new(sessionRepository)
+	SecurityRepository              = new(securityRepository)
+	GatewayRepository               = new(gatewayRepository)
+	JobRepository                   = new(jobRepository)
+	JobLogRepository                = new(jobLogRepository)
+	LoginLogRepository              = new(loginLogRepository)
+	StorageRepository               = new(storageRepository)
+	StrategyRepository              = new(strategyRepository)
+	AccessTokenRepository           = new(accessTokenRepository)
+	AuthorizedKeyRepository         = new(authorizedKeyRepository)
+	WebAuthnCredentialRepository    = new(webAuthnCredentialRepository)
+	PasswordHistoryRepository       = new(passwordHistoryRepository)
+	RoleRepository                  = new(roleRepository)
+	RoleMemberRepository            = new(roleMemberRepository)
+	RecoveryCodeRepository          = new(recoveryCodeRepository)
+	LoginPolicyRepository           = new(loginPolicyRepository)
+	LoginPolicyMemberRepository     = new(loginPolicyMemberRepository)
+	AccessRequestRepository         = new(accessRequestRepository)
+	AccessRequestLogRepository      = new(accessRequestLogRepository)
+	CommandRuleRepository           = new(commandRuleRepository)
+	CommandRuleRecordRepository     = new(commandRuleRecordRepository)
+	SessionCommandRepository        = new(sessionCommandRepository)
+	AssetAccountRepository          = new(assetAccountRepository)
+	CredentialCheckoutRepository    = new(credentialCheckoutRepository)
+	CredentialCheckoutLogRepository = new(credentialCheckoutLogRepository)
 )
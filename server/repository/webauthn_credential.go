@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"next-terminal/server/model"
+)
+
+type webAuthnCredentialRepository struct {
+	baseRepository
+}
+
+func (r webAuthnCredentialRepository) FindByUserId(c context.Context, userId string) (o []model.WebAuthnCredential, err error) {
+	err = r.GetDB(c).Where("user_id = ?", userId).Order("created desc").Find(&o).Error
+	if o == nil {
+		o = make([]model.WebAuthnCredential, 0)
+	}
+	return
+}
+
+func (r webAuthnCredentialRepository) CountByUserId(c context.Context, userId string) (total int64, err error) {
+	err = r.GetDB(c).Model(&model.WebAuthnCredential{}).Where("user_id = ?", userId).Count(&total).Error
+	return
+}
+
+func (r webAuthnCredentialRepository) Create(c context.Context, o *model.WebAuthnCredential) error {
+	return r.GetDB(c).Create(o).Error
+}
+
+func (r webAuthnCredentialRepository) UpdateById(c context.Context, o *model.WebAuthnCredential, id string) error {
+	o.ID = id
+	return r.GetDB(c).Updates(o).Error
+}
+
+func (r webAuthnCredentialRepository) DeleteByIdAndUserId(c context.Context, id, userId string) error {
+	return r.GetDB(c).Where("id = ? and user_id = ?", id, userId).Delete(&model.WebAuthnCredential{}).Error
+}
+
+func (r webAuthnCredentialRepository) DeleteByUserId(c context.Context, userId string) error {
+	return r.GetDB(c).Where("user_id = ?", userId).Delete(&model.WebAuthnCredential{}).Error
+}
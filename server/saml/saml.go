@@ -0,0 +1,384 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	saml_t "github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+type Config struct {
+	EntityId          string
+	RootUrl           string // 对外访问的地址，例如 https://next-terminal.example.com
+	IdpMetadata       string // 认证服务器元数据的地址或XML内容
+	Key               string // PEM格式的私钥
+	Certificate       string // PEM格式的证书
+	UsernameAttribute string // 为空时使用 NameID 作为登录账号
+	NicknameAttribute string
+	MailAttribute     string
+	GroupsAttribute   string
+}
+
+// Identity 从已校验签名的断言中解析出的用户信息
+type Identity struct {
+	NameId       string
+	SessionIndex string
+	Username     string
+	Nickname     string
+	Mail         string
+	Groups       []string
+}
+
+type ServiceProvider struct {
+	config Config
+	sp     saml_t.ServiceProvider
+}
+
+func NewServiceProvider(ctx context.Context, config Config) (*ServiceProvider, error) {
+	rootUrl, err := url.Parse(strings.TrimSuffix(config.RootUrl, "/"))
+	if err != nil {
+		return nil, err
+	}
+	keyPair, err := parseKeyPair(config.Key, config.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	idpMetadata, err := loadIdpMetadata(ctx, config.IdpMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataUrl := *rootUrl.ResolveReference(&url.URL{Path: rootUrl.Path + "/saml/metadata"})
+	acsUrl := *rootUrl.ResolveReference(&url.URL{Path: rootUrl.Path + "/saml/acs"})
+	sloUrl := *rootUrl.ResolveReference(&url.URL{Path: rootUrl.Path + "/saml/slo"})
+
+	return &ServiceProvider{
+		config: config,
+		sp: saml_t.ServiceProvider{
+			EntityID:    config.EntityId,
+			Key:         keyPair.PrivateKey,
+			Certificate: keyPair.Certificate,
+			MetadataURL: metadataUrl,
+			AcsURL:      acsUrl,
+			SloURL:      sloUrl,
+			IDPMetadata: idpMetadata,
+		},
+	}, nil
+}
+
+func loadIdpMetadata(ctx context.Context, metadata string) (*saml_t.EntityDescriptor, error) {
+	metadata = strings.TrimSpace(metadata)
+	if metadata == "" {
+		return nil, errors.New("saml: idp metadata is empty")
+	}
+	if strings.HasPrefix(metadata, "<") {
+		return samlsp.ParseMetadata([]byte(metadata))
+	}
+	metadataUrl, err := url.Parse(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return samlsp.FetchMetadata(ctx, http.DefaultClient, *metadataUrl)
+}
+
+// Metadata 本服务的元数据，需要导入到认证服务器中
+func (s *ServiceProvider) Metadata() ([]byte, error) {
+	return xml.MarshalIndent(s.sp.Metadata(), "", "  ")
+}
+
+// AuthnRequestURL 生成跳转到认证服务器的地址，返回的请求ID需要保存下来用于校验断言
+func (s *ServiceProvider) AuthnRequestURL(relayState string) (redirectUrl, requestId string, err error) {
+	request, err := s.sp.MakeAuthenticationRequest(s.sp.GetSSOBindingLocation(saml_t.HTTPRedirectBinding), saml_t.HTTPRedirectBinding, saml_t.HTTPPostBinding)
+	if err != nil {
+		return "", "", err
+	}
+	u, err := request.Redirect(relayState, &s.sp)
+	if err != nil {
+		return "", "", err
+	}
+	return u.String(), request.ID, nil
+}
+
+// ParseResponse 校验断言的签名、受众、有效期及对应的请求ID，并按配置的属性映射用户信息
+func (s *ServiceProvider) ParseResponse(r *http.Request, requestIds []string) (*Identity, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	assertion, err := s.sp.ParseResponse(r, requestIds)
+	if err != nil {
+		var invalidResponseError *saml_t.InvalidResponseError
+		if errors.As(err, &invalidResponseError) {
+			return nil, fmt.Errorf("saml: %v", invalidResponseError.PrivateErr)
+		}
+		return nil, err
+	}
+
+	identity := &Identity{}
+	if assertion.Subject != nil && assertion.Subject.NameID != nil {
+		identity.NameId = assertion.Subject.NameID.Value
+	}
+	for _, statement := range assertion.AuthnStatements {
+		if statement.SessionIndex != "" {
+			identity.SessionIndex = statement.SessionIndex
+		}
+	}
+
+	attributes := map[string][]string{}
+	for _, statement := range assertion.AttributeStatements {
+		for _, attribute := range statement.Attributes {
+			for _, value := range attribute.Values {
+				attributes[attribute.Name] = append(attributes[attribute.Name], value.Value)
+				if attribute.FriendlyName != "" {
+					attributes[attribute.FriendlyName] = append(attributes[attribute.FriendlyName], value.Value)
+				}
+			}
+		}
+	}
+	first := func(name string) string {
+		if values := attributes[name]; name != "" && len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	identity.Username = identity.NameId
+	if s.config.UsernameAttribute != "" {
+		identity.Username = first(s.config.UsernameAttribute)
+	}
+	identity.Nickname = first(s.config.NicknameAttribute)
+	identity.Mail = first(s.config.MailAttribute)
+	if s.config.GroupsAttribute != "" {
+		identity.Groups = attributes[s.config.GroupsAttribute]
+	}
+	if identity.Username == "" {
+		return nil, errors.New("saml: username is empty")
+	}
+	return identity, nil
+}
+
+// ParseLogoutRequest 解析并校验认证服务器发起的单点登出请求，支持 HTTP-Redirect 与 HTTP-POST 绑定
+func (s *ServiceProvider) ParseLogoutRequest(r *http.Request) (*saml_t.LogoutRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	certs, err := s.idpSigningCerts()
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	if r.Method == http.MethodPost {
+		data, err = base64.StdEncoding.DecodeString(r.PostForm.Get("SAMLRequest"))
+		if err != nil {
+			return nil, err
+		}
+		doc := etree.NewDocument()
+		if err := doc.ReadFromBytes(data); err != nil {
+			return nil, err
+		}
+		validationContext := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: certs})
+		signed, err := validationContext.Validate(doc.Root())
+		if err != nil {
+			return nil, fmt.Errorf("saml: %v", err)
+		}
+		// 只解析签名覆盖的元素，防止签名包装攻击
+		signedDoc := etree.NewDocument()
+		signedDoc.SetRoot(signed)
+		if data, err = signedDoc.WriteToBytes(); err != nil {
+			return nil, err
+		}
+	} else {
+		compressed, err := base64.StdEncoding.DecodeString(r.URL.Query().Get("SAMLRequest"))
+		if err != nil {
+			return nil, err
+		}
+		data, err = ioutil.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyRedirectSignature(r.URL.RawQuery, "SAMLRequest", certs); err != nil {
+			return nil, err
+		}
+	}
+
+	var request saml_t.LogoutRequest
+	if err := xml.Unmarshal(data, &request); err != nil {
+		return nil, err
+	}
+	if request.Issuer == nil || request.Issuer.Value != s.sp.IDPMetadata.EntityID {
+		return nil, errors.New("saml: logout request issuer does not match")
+	}
+	if request.NotOnOrAfter != nil && saml_t.TimeNow().After(*request.NotOnOrAfter) {
+		return nil, errors.New("saml: logout request expired")
+	}
+	if request.NameID == nil {
+		return nil, errors.New("saml: logout request has no NameID")
+	}
+	return &request, nil
+}
+
+// LogoutResponseURL 处理完登出请求后回复认证服务器的地址
+func (s *ServiceProvider) LogoutResponseURL(requestId, relayState string) (string, error) {
+	u, err := s.sp.MakeRedirectLogoutResponse(requestId, relayState)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// ValidateLogoutResponse 校验本服务发起登出后认证服务器的回复
+func (s *ServiceProvider) ValidateLogoutResponse(r *http.Request) error {
+	return s.sp.ValidateLogoutResponseRequest(r)
+}
+
+func (s *ServiceProvider) idpSigningCerts() ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, descriptor := range s.sp.IDPMetadata.IDPSSODescriptors {
+		for _, keyDescriptor := range descriptor.KeyDescriptors {
+			if keyDescriptor.Use != "" && keyDescriptor.Use != "signing" {
+				continue
+			}
+			for _, data := range keyDescriptor.KeyInfo.X509Data.X509Certificates {
+				certData, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(data.Data), ""))
+				if err != nil {
+					return nil, err
+				}
+				cert, err := x509.ParseCertificate(certData)
+				if err != nil {
+					return nil, err
+				}
+				certs = append(certs, cert)
+			}
+		}
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("saml: no idp signing certificate in metadata")
+	}
+	return certs, nil
+}
+
+// verifyRedirectSignature 校验 HTTP-Redirect 绑定的签名，签名内容为原始查询参数按固定顺序拼接
+func verifyRedirectSignature(rawQuery, messageKey string, certs []*x509.Certificate) error {
+	values := map[string]string{}
+	for _, part := range strings.Split(rawQuery, "&") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) == 2 {
+			values[kv[0]] = kv[1]
+		}
+	}
+	if values["Signature"] == "" || values["SigAlg"] == "" {
+		return errors.New("saml: logout request is not signed")
+	}
+
+	signed := messageKey + "=" + values[messageKey]
+	if relayState, ok := values["RelayState"]; ok {
+		signed += "&RelayState=" + relayState
+	}
+	signed += "&SigAlg=" + values["SigAlg"]
+
+	sigAlg, err := url.QueryUnescape(values["SigAlg"])
+	if err != nil {
+		return err
+	}
+	rawSignature, err := url.QueryUnescape(values["Signature"])
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(rawSignature)
+	if err != nil {
+		return err
+	}
+
+	var hash crypto.Hash
+	var digest []byte
+	switch sigAlg {
+	case dsig.RSASHA256SignatureMethod:
+		sum := sha256.Sum256([]byte(signed))
+		hash, digest = crypto.SHA256, sum[:]
+	case dsig.RSASHA1SignatureMethod:
+		sum := sha1.Sum([]byte(signed))
+		hash, digest = crypto.SHA1, sum[:]
+	default:
+		return fmt.Errorf("saml: unsupported signature algorithm %s", sigAlg)
+	}
+
+	for _, cert := range certs {
+		publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			continue
+		}
+		if rsa.VerifyPKCS1v15(publicKey, hash, digest, signature) == nil {
+			return nil
+		}
+	}
+	return errors.New("saml: invalid logout request signature")
+}
+
+type keyPair struct {
+	PrivateKey  *rsa.PrivateKey
+	Certificate *x509.Certificate
+}
+
+func parseKeyPair(keyPEM, certPEM string) (*keyPair, error) {
+	keyBlock, _ := pem.Decode([]byte(keyPEM))
+	if keyBlock == nil {
+		return nil, errors.New("saml: invalid private key")
+	}
+	privateKey, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	certBlock, _ := pem.Decode([]byte(certPEM))
+	if certBlock == nil {
+		return nil, errors.New("saml: invalid certificate")
+	}
+	certificate, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &keyPair{PrivateKey: privateKey, Certificate: certificate}, nil
+}
+
+// GenerateKeyPair 生成用于签名请求的自签名证书，有效期十年
+func GenerateKeyPair(commonName string) (keyPEM, certPEM string, err error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		return "", "", err
+	}
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}))
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	return keyPEM, certPEM, nil
+}
@@ -0,0 +1,189 @@
+package saml_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"next-terminal/server/saml"
+
+	"github.com/beevik/etree"
+	saml_t "github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+)
+
+const idpEntityId = "https://idp.example.com/metadata"
+
+type testIdp struct {
+	keyPair tls.Certificate
+}
+
+func newTestIdp(t *testing.T) *testIdp {
+	keyPEM, certPEM, err := saml.GenerateKeyPair("idp")
+	assert.NoError(t, err)
+	keyPair, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	assert.NoError(t, err)
+	return &testIdp{keyPair: keyPair}
+}
+
+func (idp *testIdp) metadata(t *testing.T) string {
+	descriptor := saml_t.EntityDescriptor{
+		EntityID: idpEntityId,
+		IDPSSODescriptors: []saml_t.IDPSSODescriptor{{
+			SSODescriptor: saml_t.SSODescriptor{
+				RoleDescriptor: saml_t.RoleDescriptor{
+					ProtocolSupportEnumeration: "urn:oasis:names:tc:SAML:2.0:protocol",
+					KeyDescriptors: []saml_t.KeyDescriptor{{
+						Use: "signing",
+						KeyInfo: saml_t.KeyInfo{X509Data: saml_t.X509Data{X509Certificates: []saml_t.X509Certificate{{
+							Data: base64.StdEncoding.EncodeToString(idp.keyPair.Certificate[0]),
+						}}}},
+					}},
+				},
+				SingleLogoutServices: []saml_t.Endpoint{{Binding: saml_t.HTTPRedirectBinding, Location: "https://idp.example.com/slo"}},
+			},
+			SingleSignOnServices: []saml_t.Endpoint{{Binding: saml_t.HTTPRedirectBinding, Location: "https://idp.example.com/sso"}},
+		}},
+	}
+	data, err := xml.Marshal(descriptor)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func newServiceProvider(t *testing.T, idp *testIdp) *saml.ServiceProvider {
+	keyPEM, certPEM, err := saml.GenerateKeyPair("sp")
+	assert.NoError(t, err)
+	sp, err := saml.NewServiceProvider(context.TODO(), saml.Config{
+		EntityId:    "https://next-terminal.example.com/saml/metadata",
+		RootUrl:     "https://next-terminal.example.com",
+		IdpMetadata: idp.metadata(t),
+		Key:         keyPEM,
+		Certificate: certPEM,
+	})
+	assert.NoError(t, err)
+	return sp
+}
+
+func logoutRequest(id, nameId string) *etree.Element {
+	notOnOrAfter := time.Now().Add(time.Minute).UTC()
+	request := saml_t.LogoutRequest{
+		ID:           id,
+		Version:      "2.0",
+		IssueInstant: time.Now().UTC(),
+		NotOnOrAfter: &notOnOrAfter,
+		Destination:  "https://next-terminal.example.com/saml/slo",
+		Issuer:       &saml_t.Issuer{Value: idpEntityId},
+		NameID:       &saml_t.NameID{Value: nameId},
+	}
+	return request.Element()
+}
+
+func (idp *testIdp) sign(t *testing.T, el *etree.Element) *etree.Element {
+	signingContext := dsig.NewDefaultSigningContext(dsig.TLSCertKeyStore(idp.keyPair))
+	signed, err := signingContext.SignEnveloped(el)
+	assert.NoError(t, err)
+	return signed
+}
+
+func postRequest(t *testing.T, el *etree.Element) *http.Request {
+	doc := etree.NewDocument()
+	doc.SetRoot(el)
+	data, err := doc.WriteToBytes()
+	assert.NoError(t, err)
+	form := url.Values{"SAMLRequest": {base64.StdEncoding.EncodeToString(data)}}
+	r := httptest.NewRequest(http.MethodPost, "/saml/slo", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func (idp *testIdp) redirectRequest(t *testing.T, el *etree.Element, signed bool) *http.Request {
+	doc := etree.NewDocument()
+	doc.SetRoot(el)
+	data, err := doc.WriteToBytes()
+	assert.NoError(t, err)
+	var compressed bytes.Buffer
+	writer, _ := flate.NewWriter(&compressed, flate.BestCompression)
+	_, _ = writer.Write(data)
+	_ = writer.Close()
+
+	query := "SAMLRequest=" + url.QueryEscape(base64.StdEncoding.EncodeToString(compressed.Bytes())) +
+		"&SigAlg=" + url.QueryEscape(dsig.RSASHA256SignatureMethod)
+	if signed {
+		digest := sha256.Sum256([]byte(query))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, idp.keyPair.PrivateKey.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+		query += "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+	}
+	return httptest.NewRequest(http.MethodGet, "/saml/slo?"+query, nil)
+}
+
+func TestParseSignedLogoutRequest(t *testing.T) {
+	idp := newTestIdp(t)
+	sp := newServiceProvider(t, idp)
+
+	request, err := sp.ParseLogoutRequest(postRequest(t, idp.sign(t, logoutRequest("id-post", "alice"))))
+	assert.NoError(t, err)
+	assert.Equal(t, "id-post", request.ID)
+	assert.Equal(t, "alice", request.NameID.Value)
+
+	request, err = sp.ParseLogoutRequest(idp.redirectRequest(t, logoutRequest("id-redirect", "alice"), true))
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", request.NameID.Value)
+}
+
+func TestParseUnsignedLogoutRequest(t *testing.T) {
+	idp := newTestIdp(t)
+	sp := newServiceProvider(t, idp)
+
+	_, err := sp.ParseLogoutRequest(postRequest(t, logoutRequest("id-post", "alice")))
+	assert.Error(t, err)
+
+	_, err = sp.ParseLogoutRequest(idp.redirectRequest(t, logoutRequest("id-redirect", "alice"), false))
+	assert.Error(t, err)
+
+	// 其他认证服务器签名的请求
+	other := newTestIdp(t)
+	_, err = sp.ParseLogoutRequest(postRequest(t, other.sign(t, logoutRequest("id-post", "alice"))))
+	assert.Error(t, err)
+}
+
+func TestParseWrappedLogoutRequest(t *testing.T) {
+	idp := newTestIdp(t)
+	sp := newServiceProvider(t, idp)
+	signed := idp.sign(t, logoutRequest("id-signed", "alice"))
+
+	// 将合法签名的请求包装在伪造的请求中
+	wrapper := logoutRequest("id-signed", "admin")
+	extensions := wrapper.CreateElement("samlp:Extensions")
+	extensions.AddChild(signed)
+	_, err := sp.ParseLogoutRequest(postRequest(t, wrapper))
+	assert.Error(t, err)
+
+	// 在签名的请求中追加伪造的 NameID
+	tampered := signed.Copy()
+	tampered.AddChild((&saml_t.NameID{Value: "admin"}).Element())
+	_, err = sp.ParseLogoutRequest(postRequest(t, tampered))
+	assert.Error(t, err)
+
+	// 修改签名的请求中的 NameID
+	modified := signed.Copy()
+	nameId := modified.FindElement("./NameID")
+	assert.NotNil(t, nameId)
+	nameId.SetText("admin")
+	_, err = sp.ParseLogoutRequest(postRequest(t, modified))
+	assert.Error(t, err)
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/dto"
+	"next-terminal/server/env"
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+
+	"gorm.io/gorm"
+)
+
+type accessRequestService struct {
+	baseService
+}
+
+// Create 提交访问申请并通知审批人
+func (service accessRequestService) Create(user *model.User, item dto.AccessRequest, clientIp string) (*model.AccessRequest, error) {
+	reason := strings.TrimSpace(item.Reason)
+	if reason == "" {
+		return nil, errors.New("请填写申请理由")
+	}
+	propertiesMap := repository.PropertyRepository.FindAllMap(context.TODO())
+	maxDuration, _ := strconv.Atoi(propertiesMap[constant.AccessRequestMaxDuration])
+	if item.Duration <= 0 {
+		return nil, errors.New("访问时长必须大于0分钟")
+	}
+	if maxDuration > 0 && item.Duration > maxDuration {
+		return nil, fmt.Errorf("访问时长必须在1到%d分钟之间", maxDuration)
+	}
+
+	asset, err := repository.AssetRepository.FindById(context.TODO(), item.AssetId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("资产不存在")
+		}
+		return nil, err
+	}
+	if constant.TypeUser != user.Type || asset.Owner == user.ID {
+		return nil, errors.New("您已经拥有此资产的访问权限")
+	}
+	resourceSharers, err := repository.ResourceSharerRepository.FindByResourceIdAndUserId(context.TODO(), asset.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range resourceSharers {
+		if resourceSharers[i].Valid(time.Now()) {
+			return nil, errors.New("您已经拥有此资产的访问权限")
+		}
+	}
+	exist, err := repository.AccessRequestRepository.ExistPendingByUserIdAndAssetId(context.TODO(), user.ID, asset.ID)
+	if err != nil {
+		return nil, err
+	}
+	if exist {
+		return nil, errors.New("您已经提交过此资产的访问申请，请等待审批")
+	}
+
+	request := &model.AccessRequest{
+		ID:       utils.UUID(),
+		UserId:   user.ID,
+		AssetId:  asset.ID,
+		Reason:   reason,
+		Duration: item.Duration,
+		Status:   constant.AccessRequestPending,
+		Created:  utils.NowJsonTime(),
+	}
+	err = env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		if err := repository.AccessRequestRepository.Create(c, request); err != nil {
+			return err
+		}
+		return service.audit(c, request.ID, "create", user.ID, clientIp, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	approvers, err := service.findApprovers(asset.Owner)
+	if err != nil {
+		log.Errorf("查询访问申请「%v」的审批人失败: %v", request.ID, err.Error())
+		return request, nil
+	}
+	for _, approver := range approvers {
+		if approver.ID == user.ID || approver.Mail == "" {
+			continue
+		}
+		subject := fmt.Sprintf("访问申请待审批：%s", asset.Name)
+		text := fmt.Sprintf("用户「%s」申请访问资产「%s」%d分钟，理由：%s\n请登录系统进行审批。", user.Username, asset.Name, request.Duration, reason)
+		go MailService.SendMail(approver.Mail, subject, text)
+	}
+	return request, nil
+}
+
+// findApprovers 资源所有者及审批用户组中的成员
+func (service accessRequestService) findApprovers(ownerId string) ([]model.User, error) {
+	userIds := []string{ownerId}
+	groupId := repository.PropertyRepository.FindAllMap(context.TODO())[constant.AccessRequestApproverGroup]
+	if groupId != "" {
+		memberIds, err := repository.UserGroupMemberRepository.FindUserIdsByUserGroupId(context.TODO(), groupId)
+		if err != nil {
+			return nil, err
+		}
+		userIds = append(userIds, memberIds...)
+	}
+
+	var approvers []model.User
+	exists := make(map[string]bool)
+	for _, userId := range userIds {
+		if userId == "" || exists[userId] {
+			continue
+		}
+		exists[userId] = true
+		approver, err := repository.UserRepository.FindById(context.TODO(), userId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		approvers = append(approvers, approver)
+	}
+	return approvers, nil
+}
+
+// CanApprove 管理员、资产所有者及审批用户组的成员可以审批，但不能审批自己的申请
+func (service accessRequestService) CanApprove(account *model.User, request model.AccessRequest) (bool, error) {
+	if account.ID == request.UserId {
+		return false, nil
+	}
+	if constant.TypeAdmin == account.Type {
+		return true, nil
+	}
+	asset, err := repository.AssetRepository.FindById(context.TODO(), request.AssetId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	if asset.Owner == account.ID {
+		return true, nil
+	}
+	return service.IsApproverGroupMember(account.ID)
+}
+
+func (service accessRequestService) IsApproverGroupMember(userId string) (bool, error) {
+	groupId := repository.PropertyRepository.FindAllMap(context.TODO())[constant.AccessRequestApproverGroup]
+	if groupId == "" {
+		return false, nil
+	}
+	groupIds, err := repository.UserGroupMemberRepository.FindUserGroupIdsByUserId(context.TODO(), userId)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range groupIds {
+		if id == groupId {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Approve 批准访问申请，为申请人创建一条到期自动收回的资产授权
+func (service accessRequestService) Approve(account *model.User, id string, item dto.AccessRequestReview, clientIp string) error {
+	request, err := service.findForApprover(account, id)
+	if err != nil {
+		return err
+	}
+	if request.Status != constant.AccessRequestPending {
+		return constant.ErrAccessRequestNotPending
+	}
+	if _, err := repository.AssetRepository.FindById(context.TODO(), request.AssetId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("资产不存在")
+		}
+		return err
+	}
+	if item.StrategyId != "" {
+		if _, err := repository.StrategyRepository.FindById(context.TODO(), item.StrategyId); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("授权策略不存在")
+			}
+			return err
+		}
+	}
+
+	expiresAt := time.Now().Add(time.Duration(request.Duration) * time.Minute)
+	err = env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		sharer := &model.ResourceSharer{
+			ID:           utils.UUID(),
+			ResourceId:   request.AssetId,
+			ResourceType: "asset",
+			StrategyId:   item.StrategyId,
+			UserId:       request.UserId,
+			ValidFrom:    utils.NowJsonTime(),
+			ValidUntil:   utils.NewJsonTime(expiresAt),
+		}
+		if err := repository.ResourceSharerRepository.AddSharerResource(c, sharer); err != nil {
+			return err
+		}
+		ok, err := repository.AccessRequestRepository.UpdateStatusById(c, &model.AccessRequest{
+			Status:           constant.AccessRequestApproved,
+			ApproverId:       account.ID,
+			Comment:          item.Comment,
+			ResourceSharerId: sharer.ID,
+			ExpiresAt:        utils.NewJsonTime(expiresAt),
+		}, id, constant.AccessRequestPending)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return constant.ErrAccessRequestNotPending
+		}
+		return service.audit(c, id, "approve", account.ID, clientIp, item.Comment)
+	})
+	if err != nil {
+		return err
+	}
+	service.notifyRequester(request, "已批准", fmt.Sprintf("授权有效期至 %s。", expiresAt.Format("2006-01-02 15:04:05")))
+	return nil
+}
+
+// Deny 拒绝访问申请
+func (service accessRequestService) Deny(account *model.User, id string, item dto.AccessRequestReview, clientIp string) error {
+	request, err := service.findForApprover(account, id)
+	if err != nil {
+		return err
+	}
+	if request.Status != constant.AccessRequestPending {
+		return constant.ErrAccessRequestNotPending
+	}
+	err = env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		ok, err := repository.AccessRequestRepository.UpdateStatusById(c, &model.AccessRequest{
+			Status:     constant.AccessRequestDenied,
+			ApproverId: account.ID,
+			Comment:    item.Comment,
+		}, id, constant.AccessRequestPending)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return constant.ErrAccessRequestNotPending
+		}
+		return service.audit(c, id, "deny", account.ID, clientIp, item.Comment)
+	})
+	if err != nil {
+		return err
+	}
+	service.notifyRequester(request, "已拒绝", "审批意见："+item.Comment)
+	return nil
+}
+
+// Cancel 申请人撤回尚未审批的申请
+func (service accessRequestService) Cancel(account *model.User, id, clientIp string) error {
+	request, err := repository.AccessRequestRepository.FindById(context.TODO(), id)
+	if err != nil {
+		return err
+	}
+	if request.UserId != account.ID {
+		return constant.ErrAccessRequestForbidden
+	}
+	if request.Status != constant.AccessRequestPending {
+		return constant.ErrAccessRequestNotPending
+	}
+	return env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		ok, err := repository.AccessRequestRepository.UpdateStatusById(c, &model.AccessRequest{
+			Status: constant.AccessRequestCancelled,
+		}, id, constant.AccessRequestPending)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return constant.ErrAccessRequestNotPending
+		}
+		return service.audit(c, id, "cancel", account.ID, clientIp, "")
+	})
+}
+
+// Revoke 审批人提前收回已批准的授权
+func (service accessRequestService) Revoke(account *model.User, id string, item dto.AccessRequestReview, clientIp string) error {
+	request, err := service.findForApprover(account, id)
+	if err != nil {
+		return err
+	}
+	if request.Status != constant.AccessRequestApproved {
+		return constant.ErrAccessRequestNotApproved
+	}
+	if err := service.withdraw(request, constant.AccessRequestRevoked, "revoke", account.ID, clientIp, item.Comment); err != nil {
+		return err
+	}
+	service.notifyRequester(request, "授权已被收回", "说明："+item.Comment)
+	return nil
+}
+
+// ExpireGrants 收回已经到期的授权，由定时任务调用
+func (service accessRequestService) ExpireGrants() {
+	requests, err := repository.AccessRequestRepository.FindApprovedExpiredBefore(context.TODO(), time.Now())
+	if err != nil {
+		log.Errorf("查询到期的访问申请失败: %v", err.Error())
+		return
+	}
+	for _, request := range requests {
+		if err := service.withdraw(request, constant.AccessRequestExpired, "expire", "", "", ""); err != nil {
+			log.Errorf("收回访问申请「%v」的授权失败: %v", request.ID, err.Error())
+			continue
+		}
+		log.Debugf("访问申请「%v」的授权已到期，收回授权", request.ID)
+		service.notifyRequester(request, "授权已到期", "如需继续访问请重新提交申请。")
+	}
+}
+
+func (service accessRequestService) withdraw(request model.AccessRequest, status, action, operatorId, clientIp, comment string) error {
+	return env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		if request.ResourceSharerId != "" {
+			if err := repository.ResourceSharerRepository.DeleteById(c, request.ResourceSharerId); err != nil {
+				return err
+			}
+		}
+		request.Status = status
+		ok, err := repository.AccessRequestRepository.UpdateStatusById(c, &request, request.ID, constant.AccessRequestApproved)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return constant.ErrAccessRequestNotApproved
+		}
+		return service.audit(c, request.ID, action, operatorId, clientIp, comment)
+	})
+}
+
+func (service accessRequestService) findForApprover(account *model.User, id string) (model.AccessRequest, error) {
+	request, err := repository.AccessRequestRepository.FindById(context.TODO(), id)
+	if err != nil {
+		return request, err
+	}
+	ok, err := service.CanApprove(account, request)
+	if err != nil {
+		return request, err
+	}
+	if !ok {
+		return request, constant.ErrAccessRequestForbidden
+	}
+	return request, nil
+}
+
+func (service accessRequestService) notifyRequester(request model.AccessRequest, result, detail string) {
+	user, err := repository.UserRepository.FindById(context.TODO(), request.UserId)
+	if err != nil || user.Mail == "" {
+		return
+	}
+	asset, err := repository.AssetRepository.FindById(context.TODO(), request.AssetId)
+	if err != nil {
+		return
+	}
+	subject := fmt.Sprintf("访问申请%s：%s", result, asset.Name)
+	text := fmt.Sprintf("您访问资产「%s」的申请%s。%s", asset.Name, result, detail)
+	go MailService.SendMail(user.Mail, subject, text)
+}
+
+func (service accessRequestService) audit(c context.Context, requestId, action, operatorId, clientIp, comment string) error {
+	return repository.AccessRequestLogRepository.Create(c, &model.AccessRequestLog{
+		ID:         utils.UUID(),
+		RequestId:  requestId,
+		Action:     action,
+		OperatorId: operatorId,
+		ClientIP:   clientIp,
+		Comment:    comment,
+		Created:    utils.NowJsonTime(),
+	})
+}
@@ -2,80 +2,153 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"strings"
+	"time"
 
 	"next-terminal/server/constant"
 	"next-terminal/server/dto"
-	"next-terminal/server/env"
 	"next-terminal/server/global/cache"
+	"next-terminal/server/log"
 	"next-terminal/server/model"
 	"next-terminal/server/repository"
 	"next-terminal/server/utils"
-
-	"gorm.io/gorm"
 )
 
 type accessTokenService struct {
 	baseService
 }
 
-func (service accessTokenService) GenAccessToken(userId string) error {
-	return env.GetDB().Transaction(func(tx *gorm.DB) error {
-		ctx := service.Context(tx)
-		user, err := repository.UserRepository.FindById(ctx, userId)
-		if err != nil {
-			return err
-		}
-		oldAccessToken, err := repository.AccessTokenRepository.FindByUserId(ctx, userId)
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-			return err
-		}
-		if oldAccessToken.Token != "" {
-			cache.TokenManager.Delete(oldAccessToken.Token)
+// hashToken 数据库中只保存令牌的摘要，令牌本身只在创建时返回一次
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenAccessToken 创建授权令牌，返回的明文令牌无法再次查看
+func (service accessTokenService) GenAccessToken(userId string, item dto.AccessToken) (string, error) {
+	if strings.TrimSpace(item.Name) == "" {
+		return "", errors.New("请输入令牌名称")
+	}
+	for _, scope := range item.Scopes {
+		if !utils.Contains(constant.AccessTokenScopes, scope) {
+			return "", errors.New("未知的权限范围: " + scope)
 		}
-		if err := repository.AccessTokenRepository.DeleteByUserId(ctx, userId); err != nil {
-			return err
+	}
+	var expired utils.JsonTime
+	if item.Expired != nil && !item.Expired.IsZero() {
+		if item.Expired.Before(time.Now()) {
+			return "", errors.New("过期时间不能早于当前时间")
 		}
+		expired = *item.Expired
+	}
 
-		token := "forever-" + utils.UUID()
-		accessToken := &model.AccessToken{
-			ID:      utils.UUID(),
-			UserId:  userId,
-			Token:   token,
-			Created: utils.NowJsonTime(),
-		}
+	token := "token-" + utils.UUID()
+	accessToken := &model.AccessToken{
+		ID:      utils.UUID(),
+		UserId:  userId,
+		Name:    item.Name,
+		Token:   hashToken(token),
+		Scopes:  strings.Join(utils.Distinct(item.Scopes), ","),
+		Expired: expired,
+		Created: utils.NowJsonTime(),
+	}
+	if err := repository.AccessTokenRepository.Create(context.TODO(), accessToken); err != nil {
+		return "", err
+	}
+	return token, nil
+}
 
-		authorization := dto.Authorization{
-			Token:    token,
-			Remember: false,
-			Type:     constant.AccessToken,
-			User:     &user,
+// Authenticate 校验授权令牌并放入缓存，缓存有效期不超过令牌的过期时间
+func (service accessTokenService) Authenticate(token string) (*dto.Authorization, error) {
+	accessToken, err := repository.AccessTokenRepository.FindByToken(context.TODO(), hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	expiration := time.Duration(cache.NoExpiration)
+	if !accessToken.Expired.IsZero() {
+		expiration = time.Until(accessToken.Expired.Time)
+		if expiration <= 0 {
+			return nil, errors.New("授权令牌已过期")
 		}
+	}
+	user, err := repository.UserRepository.FindById(context.TODO(), accessToken.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == constant.StatusDisabled {
+		return nil, errors.New("用户已被禁用")
+	}
+
+	authorization := dto.Authorization{
+		Token:    token,
+		Remember: false,
+		Type:     constant.AccessToken,
+		User:     &user,
+	}
+	if accessToken.Scopes != "" {
+		authorization.Scopes = strings.Split(accessToken.Scopes, ",")
+	}
+	cache.TokenManager.Set(token, authorization, expiration)
+	return &authorization, nil
+}
+
+// Used 记录令牌最后一次使用的时间和来源IP
+func (service accessTokenService) Used(token, ip string) {
+	if err := repository.AccessTokenRepository.UpdateLastUsedByToken(context.TODO(), ip, hashToken(token)); err != nil {
+		log.Warnf("更新授权令牌使用记录失败: %v", err)
+	}
+}
 
-		cache.TokenManager.Set(token, authorization, cache.NoExpiration)
+func (service accessTokenService) DeleteById(userId, id string) error {
+	accessToken, err := repository.AccessTokenRepository.FindByIdAndUserId(context.TODO(), id, userId)
+	if err != nil {
+		return err
+	}
+	if err := repository.AccessTokenRepository.DeleteByIdAndUserId(context.TODO(), id, userId); err != nil {
+		return err
+	}
+	service.evict(func(token string, authorization dto.Authorization) bool {
+		return hashToken(token) == accessToken.Token
+	})
+	return nil
+}
 
-		return repository.AccessTokenRepository.Create(ctx, accessToken)
+// EvictByUserId 将用户的授权令牌从缓存中移除，下次使用时重新校验
+func (service accessTokenService) EvictByUserId(userId string) {
+	service.evict(func(token string, authorization dto.Authorization) bool {
+		return authorization.User.ID == userId
 	})
 }
 
+func (service accessTokenService) evict(match func(token string, authorization dto.Authorization) bool) {
+	for token, item := range cache.TokenManager.Items() {
+		authorization, ok := item.Object.(dto.Authorization)
+		if !ok || authorization.Type != constant.AccessToken {
+			continue
+		}
+		if match(token, authorization) {
+			cache.TokenManager.Delete(token)
+		}
+	}
+}
+
+// Reload 旧版本使用明文保存令牌，启动时转换为摘要，令牌在首次使用时加载到缓存
 func (service accessTokenService) Reload() error {
 	accessTokens, err := repository.AccessTokenRepository.FindAll(context.TODO())
 	if err != nil {
 		return err
 	}
 	for _, accessToken := range accessTokens {
-		user, err := repository.UserRepository.FindById(context.TODO(), accessToken.UserId)
-		if err != nil {
-			return err
+		if !strings.HasPrefix(accessToken.Token, "forever-") {
+			continue
 		}
-		authorization := dto.Authorization{
-			Token:    accessToken.Token,
-			Remember: false,
-			Type:     constant.AccessToken,
-			User:     &user,
+		if err := repository.AccessTokenRepository.UpdateTokenById(context.TODO(), hashToken(accessToken.Token), accessToken.ID); err != nil {
+			return err
 		}
-
-		cache.TokenManager.Set(accessToken.Token, authorization, cache.NoExpiration)
+		log.Debugf("授权令牌「%v」已转换为摘要保存", accessToken.ID)
 	}
 	return nil
 }
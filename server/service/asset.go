@@ -10,9 +10,11 @@ import (
 	"next-terminal/server/env"
 	"next-terminal/server/model"
 	"next-terminal/server/repository"
+	"next-terminal/server/term"
 	"next-terminal/server/utils"
 
 	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/ssh"
 	"gorm.io/gorm"
 )
 
@@ -203,6 +205,10 @@ func (s assetService) DeleteById(id string) error {
 		if err := repository.ResourceSharerRepository.DeleteByResourceId(c, id); err != nil {
 			return err
 		}
+		// 删除资产账号
+		if err := repository.AssetAccountRepository.DeleteByAssetId(c, id); err != nil {
+			return err
+		}
 		return nil
 	})
 }
@@ -242,6 +248,14 @@ func (s assetService) UpdateById(id string, m echo.Map) error {
 		if len(item.Password) == 0 {
 			item.Password = "-"
 		}
+	case "certificate":
+		item.Password = "-"
+		item.PrivateKey = "-"
+		item.Passphrase = "-"
+		item.CredentialId = "-"
+		if len(item.Username) == 0 {
+			item.Username = "-"
+		}
 	}
 
 	if len(item.Tags) == 0 {
@@ -272,3 +286,34 @@ func (s assetService) UpdateById(id string, m echo.Map) error {
 func (s assetService) FixSshMode() error {
 	return repository.AssetRepository.UpdateAttrs(context.TODO(), "ssh-mode", "naive", constant.Native)
 }
+
+// newAssetSshClient 连接资产，资产配置了接入网关时通过网关建立隧道，返回的函数用于关闭连接及隧道
+func newAssetSshClient(asset model.Asset, username, password, privateKey, passphrase string) (*ssh.Client, func(), error) {
+	if asset.AccessGatewayId != "" && asset.AccessGatewayId != "-" {
+		g, err := GatewayService.GetGatewayAndReconnectById(asset.AccessGatewayId)
+		if err != nil {
+			return nil, nil, err
+		}
+		uuid := utils.UUID()
+		exposedIP, exposedPort, err := g.OpenSshTunnel(uuid, asset.IP, asset.Port)
+		if err != nil {
+			return nil, nil, err
+		}
+		client, err := term.NewSshClient(exposedIP, exposedPort, username, password, privateKey, passphrase)
+		if err != nil {
+			g.CloseSshTunnel(uuid)
+			return nil, nil, err
+		}
+		return client, func() {
+			_ = client.Close()
+			g.CloseSshTunnel(uuid)
+		}, nil
+	}
+	client, err := term.NewSshClient(asset.IP, asset.Port, username, password, privateKey, passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, func() {
+		_ = client.Close()
+	}, nil
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"next-terminal/server/config"
+	"next-terminal/server/constant"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+)
+
+type assetAccountService struct {
+	baseService
+}
+
+func (s assetAccountService) Encrypt(item *model.AssetAccount, password []byte) error {
+	if item.Password != "" && item.Password != "-" {
+		encryptedCBC, err := utils.AesEncryptCBC([]byte(item.Password), password)
+		if err != nil {
+			return err
+		}
+		item.Password = base64.StdEncoding.EncodeToString(encryptedCBC)
+	}
+	if item.PrivateKey != "" && item.PrivateKey != "-" {
+		encryptedCBC, err := utils.AesEncryptCBC([]byte(item.PrivateKey), password)
+		if err != nil {
+			return err
+		}
+		item.PrivateKey = base64.StdEncoding.EncodeToString(encryptedCBC)
+	}
+	if item.Passphrase != "" && item.Passphrase != "-" {
+		encryptedCBC, err := utils.AesEncryptCBC([]byte(item.Passphrase), password)
+		if err != nil {
+			return err
+		}
+		item.Passphrase = base64.StdEncoding.EncodeToString(encryptedCBC)
+	}
+	item.Encrypted = true
+	return nil
+}
+
+func (s assetAccountService) Decrypt(item *model.AssetAccount, password []byte) error {
+	if item.Encrypted {
+		if item.Password != "" && item.Password != "-" {
+			origData, err := base64.StdEncoding.DecodeString(item.Password)
+			if err != nil {
+				return err
+			}
+			decryptedCBC, err := utils.AesDecryptCBC(origData, password)
+			if err != nil {
+				return err
+			}
+			item.Password = string(decryptedCBC)
+		}
+		if item.PrivateKey != "" && item.PrivateKey != "-" {
+			origData, err := base64.StdEncoding.DecodeString(item.PrivateKey)
+			if err != nil {
+				return err
+			}
+			decryptedCBC, err := utils.AesDecryptCBC(origData, password)
+			if err != nil {
+				return err
+			}
+			item.PrivateKey = string(decryptedCBC)
+		}
+		if item.Passphrase != "" && item.Passphrase != "-" {
+			origData, err := base64.StdEncoding.DecodeString(item.Passphrase)
+			if err != nil {
+				return err
+			}
+			decryptedCBC, err := utils.AesDecryptCBC(origData, password)
+			if err != nil {
+				return err
+			}
+			item.Passphrase = string(decryptedCBC)
+		}
+		item.Encrypted = false
+	}
+	return nil
+}
+
+// defaultAccount 资产自身配置的账号
+func (s assetAccountService) defaultAccount(asset model.Asset) model.AssetAccount {
+	return model.AssetAccount{
+		ID:           constant.DefaultAccount,
+		AssetId:      asset.ID,
+		Name:         "默认账号",
+		AccountType:  asset.AccountType,
+		Username:     asset.Username,
+		Password:     asset.Password,
+		CredentialId: asset.CredentialId,
+		PrivateKey:   asset.PrivateKey,
+		Passphrase:   asset.Passphrase,
+		Encrypted:    asset.Encrypted,
+		Created:      asset.Created,
+	}
+}
+
+// FindAccount 查询资产的账号，accountId 为空时返回资产自身配置的账号，返回的账号信息未解密
+func (s assetAccountService) FindAccount(c context.Context, asset model.Asset, accountId string) (model.AssetAccount, error) {
+	if accountId == "" || accountId == constant.DefaultAccount {
+		return s.defaultAccount(asset), nil
+	}
+	item, err := repository.AssetAccountRepository.FindById(c, accountId)
+	if err != nil {
+		return model.AssetAccount{}, err
+	}
+	if item.AssetId != asset.ID {
+		return model.AssetAccount{}, errors.New("资产账号不存在")
+	}
+	return item, nil
+}
+
+// FindAccountAndDecrypt 查询资产的账号并解密，账号使用授权凭证时填充凭证的用户名、密码及密钥，使用证书时签发短期证书
+func (s assetAccountService) FindAccountAndDecrypt(c context.Context, asset model.Asset, accountId string) (model.AssetAccount, error) {
+	item, err := s.FindAccount(c, asset, accountId)
+	if err != nil {
+		return item, err
+	}
+	if err := s.Decrypt(&item, config.GlobalCfg.EncryptionPassword); err != nil {
+		return item, err
+	}
+	if item.AccountType == constant.AccountCredential {
+		credential, err := CredentialService.FindByIdAndDecrypt(c, item.CredentialId)
+		if err != nil {
+			return item, err
+		}
+		item.Username = credential.Username
+		if credential.Type == constant.Custom {
+			item.Password = credential.Password
+		} else {
+			item.PrivateKey = credential.PrivateKey
+			item.Passphrase = credential.Passphrase
+		}
+	}
+	if item.AccountType == constant.AccountCertificate {
+		// 系统操作使用短期证书
+		privateKey, err := SshCAService.IssueCertificate(c, item.Username, certificateKeyId("system", asset.ID), 5)
+		if err != nil {
+			return item, err
+		}
+		item.PrivateKey = privateKey
+		item.Passphrase = "-"
+	}
+	return item, nil
+}
+
+// FindAccountByName 按名称查询资产的账号，name 为空时返回资产自身配置的账号，返回的账号信息未解密
+func (s assetAccountService) FindAccountByName(c context.Context, asset model.Asset, name string) (model.AssetAccount, error) {
+	if name == "" {
+		return s.defaultAccount(asset), nil
+	}
+	return repository.AssetAccountRepository.FindByAssetIdAndName(c, asset.ID, name)
+}
+
+// FindAllowed 查询用户可以使用的资产账号，第一个为资产自身配置的账号，不包含密码及密钥
+func (s assetAccountService) FindAllowed(c context.Context, asset model.Asset, user *model.User) ([]model.AssetAccount, error) {
+	accounts, err := repository.AssetAccountRepository.FindByAssetId(c, asset.ID)
+	if err != nil {
+		return nil, err
+	}
+	accounts = append([]model.AssetAccount{s.defaultAccount(asset)}, accounts...)
+
+	if asset.Owner != user.ID && constant.TypeUser == user.Type {
+		resourceSharers, err := repository.ResourceSharerRepository.FindByResourceIdAndUserId(c, asset.ID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		var allowed []model.AssetAccount
+		for _, account := range accounts {
+			for i := range resourceSharers {
+				if resourceSharers[i].Valid(time.Now()) && resourceSharers[i].AllowAccount(account.ID) {
+					allowed = append(allowed, account)
+					break
+				}
+			}
+		}
+		accounts = allowed
+	}
+
+	for i := range accounts {
+		accounts[i].Password = ""
+		accounts[i].PrivateKey = ""
+		accounts[i].Passphrase = ""
+	}
+	return accounts, nil
+}
+
+func (s assetAccountService) Create(c context.Context, item *model.AssetAccount) error {
+	if err := s.validate(c, item, ""); err != nil {
+		return err
+	}
+	item.ID = utils.UUID()
+	item.Created = utils.NowJsonTime()
+	if err := s.Encrypt(item, config.GlobalCfg.EncryptionPassword); err != nil {
+		return err
+	}
+	return repository.AssetAccountRepository.Create(c, item)
+}
+
+func (s assetAccountService) UpdateById(c context.Context, item *model.AssetAccount, id string) error {
+	if err := s.validate(c, item, id); err != nil {
+		return err
+	}
+	if err := s.Encrypt(item, config.GlobalCfg.EncryptionPassword); err != nil {
+		return err
+	}
+	return repository.AssetAccountRepository.UpdateById(c, item, id)
+}
+
+// validate 校验账号并使用 - 占位清空不使用的字段
+func (s assetAccountService) validate(c context.Context, item *model.AssetAccount, id string) error {
+	item.Name = strings.TrimSpace(item.Name)
+	if item.Name == "" {
+		return errors.New("账号名称不能为空")
+	}
+	exist, err := repository.AssetAccountRepository.ExistByAssetIdAndName(c, item.AssetId, item.Name, id)
+	if err != nil {
+		return err
+	}
+	if exist {
+		return errors.New("账号名称已存在")
+	}
+
+	switch item.AccountType {
+	case constant.AccountCredential:
+		if item.CredentialId == "" {
+			return errors.New("请选择授权凭证")
+		}
+		item.Username = "-"
+		item.Password = "-"
+		item.PrivateKey = "-"
+		item.Passphrase = "-"
+	case constant.PrivateKey:
+		item.Password = "-"
+		item.CredentialId = "-"
+		if len(item.Username) == 0 {
+			item.Username = "-"
+		}
+		if len(item.Passphrase) == 0 {
+			item.Passphrase = "-"
+		}
+	case constant.Custom:
+		item.PrivateKey = "-"
+		item.Passphrase = "-"
+		item.CredentialId = "-"
+		if len(item.Username) == 0 {
+			item.Username = "-"
+		}
+		if len(item.Password) == 0 {
+			item.Password = "-"
+		}
+	case constant.AccountCertificate:
+		item.Password = "-"
+		item.PrivateKey = "-"
+		item.Passphrase = "-"
+		item.CredentialId = "-"
+		if len(item.Username) == 0 {
+			item.Username = "-"
+		}
+	default:
+		return errors.New("不支持的账号类型：" + item.AccountType)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"next-terminal/server/dto"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+
+	"golang.org/x/crypto/ssh"
+)
+
+type authorizedKeyService struct {
+}
+
+// Create 添加公钥，如果提供了指纹则必须与公钥计算出的指纹一致，防止粘贴错误的公钥
+func (service authorizedKeyService) Create(ctx context.Context, userId string, item dto.AuthorizedKey) (*model.AuthorizedKey, error) {
+	publicKey, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(item.PublicKey))
+	if err != nil {
+		return nil, errors.New("公钥格式不正确")
+	}
+	fingerprint := ssh.FingerprintSHA256(publicKey)
+	if item.Fingerprint != "" {
+		pinned := strings.TrimSpace(item.Fingerprint)
+		if pinned != fingerprint && pinned != ssh.FingerprintLegacyMD5(publicKey) {
+			return nil, errors.New("公钥指纹不匹配")
+		}
+	}
+
+	exist, err := repository.AuthorizedKeyRepository.ExistByUserIdAndFingerprint(ctx, userId, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if exist {
+		return nil, errors.New("该公钥已存在")
+	}
+
+	name := item.Name
+	if name == "" {
+		name = comment
+	}
+	authorizedKey := &model.AuthorizedKey{
+		ID:          utils.UUID(),
+		UserId:      userId,
+		Name:        name,
+		PublicKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))),
+		Fingerprint: fingerprint,
+		Created:     utils.NowJsonTime(),
+	}
+	if item.Expired != nil {
+		authorizedKey.Expired = *item.Expired
+	}
+	if err := repository.AuthorizedKeyRepository.Create(ctx, authorizedKey); err != nil {
+		return nil, err
+	}
+	return authorizedKey, nil
+}
+
+// Match 查找用户与公钥匹配且未过期的记录
+func (service authorizedKeyService) Match(ctx context.Context, userId string, publicKey ssh.PublicKey) (model.AuthorizedKey, error) {
+	authorizedKey, err := repository.AuthorizedKeyRepository.FindByUserIdAndFingerprint(ctx, userId, ssh.FingerprintSHA256(publicKey))
+	if err != nil {
+		return authorizedKey, err
+	}
+	if !authorizedKey.Expired.IsZero() && authorizedKey.Expired.Before(time.Now()) {
+		return authorizedKey, errors.New("公钥已过期")
+	}
+	return authorizedKey, nil
+}
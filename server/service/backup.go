@@ -105,6 +105,16 @@ func (service backupService) Export() (error, *dto.Backup) {
 		}
 	}
 
+	assetAccounts, err := repository.AssetAccountRepository.FindAll(ctx)
+	if err != nil {
+		return err, nil
+	}
+	for i := range assetAccounts {
+		if err := AssetAccountService.Decrypt(&assetAccounts[i], config.GlobalCfg.EncryptionPassword); err != nil {
+			return err, nil
+		}
+	}
+
 	resourceSharers, err := repository.ResourceSharerRepository.FindAll(ctx)
 	if err != nil {
 		return err, nil
@@ -121,6 +131,7 @@ func (service backupService) Export() (error, *dto.Backup) {
 		Commands:         commands,
 		Credentials:      credentials,
 		Assets:           assetMaps,
+		AssetAccounts:    assetAccounts,
 		ResourceSharers:  resourceSharers,
 	}
 	return nil, &backup
@@ -143,7 +154,11 @@ func (service backupService) Import(backup *dto.Backup) error {
 				}
 				newId := utils.UUID()
 				item.ID = newId
-				item.Password = utils.GenPassword()
+				password, err := utils.GenPassword()
+				if err != nil {
+					return err
+				}
+				item.Password = password
 				if err := repository.UserRepository.Create(ctx, &item); err != nil {
 					return err
 				}
@@ -163,7 +178,7 @@ func (service backupService) Import(backup *dto.Backup) error {
 					}
 				}
 
-				userGroup, err := UserGroupService.Create(ctx, item.Name, members)
+				userGroup, err := UserGroupService.Create(ctx, item.Name, item.MfaMethod, members)
 				if err != nil {
 					if errors.Is(constant.ErrNameAlreadyUsed, err) {
 						// 删除名称重复的用户组
@@ -291,6 +306,21 @@ func (service backupService) Import(backup *dto.Backup) error {
 			}
 		}
 
+		var accountIdMapping = map[string]string{constant.DefaultAccount: constant.DefaultAccount}
+		if len(backup.AssetAccounts) > 0 {
+			for _, item := range backup.AssetAccounts {
+				oldId := item.ID
+				item.AssetId = assetIdMapping[item.AssetId]
+				if item.CredentialId != "" && item.CredentialId != "-" {
+					item.CredentialId = credentialIdMapping[item.CredentialId]
+				}
+				if err := AssetAccountService.Create(ctx, &item); err != nil {
+					return err
+				}
+				accountIdMapping[oldId] = item.ID
+			}
+		}
+
 		if len(backup.ResourceSharers) > 0 {
 			for _, item := range backup.ResourceSharers {
 
@@ -298,8 +328,14 @@ func (service backupService) Import(backup *dto.Backup) error {
 				userId := userIdMapping[item.UserId]
 				strategyId := strategyIdMapping[item.StrategyId]
 				resourceId := assetIdMapping[item.ResourceId]
+				var accountIds []string
+				if item.AccountIds != "" {
+					for _, accountId := range strings.Split(item.AccountIds, ",") {
+						accountIds = append(accountIds, accountIdMapping[accountId])
+					}
+				}
 
-				if err := UserService.AddSharerResources(ctx, userGroupId, userId, strategyId, item.ResourceType, []string{resourceId}); err != nil {
+				if err := UserService.AddSharerResources(ctx, userGroupId, userId, strategyId, item.ResourceType, []string{resourceId}, accountIds, item.ValidFrom, item.ValidUntil); err != nil {
 					return err
 				}
 			}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/term"
+	"next-terminal/server/utils"
+)
+
+const (
+	// secretMask 在密码输入提示后输入的内容只记录掩码
+	secretMask       = "******"
+	ruleLookupFailed = "查询命令拦截规则失败，命令已被禁止执行，请稍后重试"
+)
+
+// CommandFilter 还原会话中输入的命令行并按拦截规则处理，被拒绝的命令不会发送到目标资产，放行的命令会记录到审计日志
+type CommandFilter struct {
+	sessionId string
+	userId    string
+	username  string
+	assetId   string
+	assetName string
+	clientIp  string
+	recorder  *term.Recorder
+
+	line    utils.CommandLine
+	pending []byte // 不完整的 UTF-8 字符，等待下一次输入
+
+	// 终端输出与用户输入在不同的协程中处理
+	mutex         sync.Mutex
+	output        utils.ShellOutput
+	lastCommandId string // 等待退出码的命令
+}
+
+// Output 处理终端的输出，用于解析当前目录及命令的退出码
+func (f *CommandFilter) Output(s string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	exitCodes := f.output.Write(s)
+	if len(exitCodes) == 0 || f.lastCommandId == "" {
+		return
+	}
+	if err := repository.SessionCommandRepository.UpdateExitCodeById(context.TODO(), exitCodes[0], f.lastCommandId); err != nil {
+		log.Errorf("更新命令退出码失败: %v", err.Error())
+	}
+	f.lastCommandId = ""
+}
+
+// record 记录放行的命令
+func (f *CommandFilter) record(command string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	offset := float64(-1)
+	if f.recorder != nil {
+		offset = f.recorder.Offset()
+	}
+	item := model.SessionCommand{
+		ID:              utils.UUID(),
+		SessionId:       f.sessionId,
+		UserId:          f.userId,
+		Username:        f.username,
+		AssetId:         f.assetId,
+		AssetName:       f.assetName,
+		ClientIP:        f.clientIp,
+		Command:         command,
+		Cwd:             f.output.Cwd(),
+		RecordingOffset: offset,
+		Created:         utils.NowJsonTime(),
+	}
+	if err := repository.SessionCommandRepository.Create(context.TODO(), &item); err != nil {
+		log.Errorf("保存会话命令失败: %v", err.Error())
+		return
+	}
+	f.lastCommandId = item.ID
+}
+
+// Filter 处理用户的输入，返回需要转发到目标资产的数据以及需要提示给用户的消息
+func (f *CommandFilter) Filter(p []byte) (forward []byte, message string) {
+	data := append(f.pending, p...)
+	f.pending = nil
+	if f.alternateScreen() {
+		// vim、less、top 等全屏程序中的回车不是执行命令，直接转发
+		f.line.Reset()
+		return data, ""
+	}
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && !utf8.FullRune(data) {
+			f.pending = append([]byte{}, data...)
+			break
+		}
+		raw := data[:size]
+		data = data[size:]
+
+		line, enter, exact := f.line.Input(r)
+		if !enter {
+			forward = append(forward, raw...)
+			continue
+		}
+		reason := f.check(line, exact, f.passwordPrompt())
+		if reason == "" {
+			forward = append(forward, raw...)
+			continue
+		}
+		// 发送 Ctrl+C 取消远程 shell 中已经输入的内容
+		forward = append(forward, 0x03)
+		message += "\r\n" + reason + "\r\n"
+	}
+	return
+}
+
+// alternateScreen 远程终端是否正在运行全屏程序
+func (f *CommandFilter) alternateScreen() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.output.AlternateScreen()
+}
+
+// passwordPrompt 远程终端是否正在等待输入密码
+func (f *CommandFilter) passwordPrompt() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.output.PasswordPrompt()
+}
+
+// check 判断命令是否放行，拒绝时返回提示给用户的原因。
+// exact 为 false 时命令行与远程 shell 实际执行的命令可能不一致，存在拒绝规则时一律拒绝；查询规则失败时同样拒绝，避免拦截失效。
+// secret 为 true 时输入的是密码，仍然按规则拦截，但审计记录、日志及提示中只保存掩码
+func (f *CommandFilter) check(line string, exact, secret bool) (reason string) {
+	command := strings.TrimSpace(line)
+	audited := command
+	if secret && command != "" {
+		audited = secretMask
+	}
+	if !exact {
+		deny, err := CommandRuleService.HasDenyRule(f.userId, f.assetId)
+		if err != nil {
+			log.Errorf("查询命令拦截规则失败: %v", err.Error())
+			return ruleLookupFailed
+		}
+		if deny {
+			log.Warnf("用户「%v」在资产「%v」上执行的命令无法还原，已被拒绝，还原结果「%v」", f.username, f.assetName, audited)
+			f.saveRecord(nil, audited)
+			return "使用历史命令、Tab补全等输入的命令无法校验，已被禁止执行，请直接输入完整的命令"
+		}
+		if command != "" {
+			log.Warnf("用户「%v」在资产「%v」上执行的命令无法准确还原，还原结果「%v」", f.username, f.assetName, audited)
+		}
+	}
+	if command == "" {
+		return ""
+	}
+	rule, err := CommandRuleService.Match(f.userId, f.assetId, command)
+	if err != nil {
+		log.Errorf("匹配命令拦截规则失败: %v", err.Error())
+		return ruleLookupFailed
+	}
+	if rule == nil {
+		f.record(audited)
+		return ""
+	}
+	f.saveRecord(rule, audited)
+
+	switch rule.Action {
+	case constant.CommandDeny:
+		log.Infof("用户「%v」在资产「%v」上执行的命令「%v」被规则「%v」拒绝", f.username, f.assetName, audited, rule.Name)
+		return fmt.Sprintf("命令「%s」已被禁止执行", audited)
+	case constant.CommandAlert:
+		log.Warnf("用户「%v」在资产「%v」上执行了告警命令「%v」，匹配规则「%v」", f.username, f.assetName, audited, rule.Name)
+	}
+	f.record(audited)
+	return ""
+}
+
+// saveRecord 保存命令拦截记录，rule 为 nil 时表示命令行无法还原而被拒绝
+func (f *CommandFilter) saveRecord(rule *model.CommandRule, command string) {
+	record := model.CommandRuleRecord{
+		ID:        utils.UUID(),
+		RuleName:  "无法还原的命令行",
+		Action:    constant.CommandDeny,
+		SessionId: f.sessionId,
+		UserId:    f.userId,
+		Username:  f.username,
+		AssetId:   f.assetId,
+		AssetName: f.assetName,
+		ClientIP:  f.clientIp,
+		Command:   command,
+		Created:   utils.NowJsonTime(),
+	}
+	if rule != nil {
+		record.RuleId = rule.ID
+		record.RuleName = rule.Name
+		record.Action = rule.Action
+	}
+	if err := repository.CommandRuleRecordRepository.Create(context.TODO(), &record); err != nil {
+		log.Errorf("保存命令拦截记录失败: %v", err.Error())
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/term"
+	"next-terminal/server/utils"
+)
+
+type commandRuleService struct {
+	baseService
+}
+
+// 编译后的正则表达式，避免每次匹配命令时重复编译
+var commandPatterns sync.Map
+
+func (service commandRuleService) compile(pattern string) (*regexp.Regexp, error) {
+	if v, ok := commandPatterns.Load(pattern); ok {
+		return v.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	commandPatterns.Store(pattern, re)
+	return re, nil
+}
+
+func (service commandRuleService) validate(item *model.CommandRule) error {
+	if strings.TrimSpace(item.Name) == "" {
+		return errors.New("请输入规则名称")
+	}
+	if item.Pattern == "" {
+		return errors.New("请输入命令匹配规则")
+	}
+	if _, err := service.compile(item.Pattern); err != nil {
+		return fmt.Errorf("正则表达式格式不正确: %v", err.Error())
+	}
+	switch item.Action {
+	case constant.CommandAllow, constant.CommandDeny, constant.CommandAlert:
+	default:
+		return fmt.Errorf("不支持的规则动作: %s", item.Action)
+	}
+	return nil
+}
+
+func (service commandRuleService) Create(item *model.CommandRule) error {
+	if err := service.validate(item); err != nil {
+		return err
+	}
+	item.ID = utils.UUID()
+	item.Created = utils.NowJsonTime()
+	return repository.CommandRuleRepository.Create(context.TODO(), item)
+}
+
+func (service commandRuleService) UpdateById(id string, item *model.CommandRule) error {
+	if err := service.validate(item); err != nil {
+		return err
+	}
+	return repository.CommandRuleRepository.UpdateById(context.TODO(), item, id)
+}
+
+// findInScope 按优先级返回对用户及资产生效的规则
+func (service commandRuleService) findInScope(userId, assetId string) ([]model.CommandRule, error) {
+	rules, err := repository.CommandRuleRepository.FindEnabled(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	userGroupIds, err := repository.UserGroupMemberRepository.FindUserGroupIdsByUserId(context.TODO(), userId)
+	if err != nil {
+		return nil, err
+	}
+	var items []model.CommandRule
+	for _, rule := range rules {
+		if service.inScope(rule, userId, userGroupIds, assetId) {
+			items = append(items, rule)
+		}
+	}
+	return items, nil
+}
+
+// HasDenyRule 是否存在对用户及资产生效的拒绝规则
+func (service commandRuleService) HasDenyRule(userId, assetId string) (bool, error) {
+	rules, err := service.findInScope(userId, assetId)
+	if err != nil {
+		return false, err
+	}
+	for _, rule := range rules {
+		if rule.Action == constant.CommandDeny {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Match 查找与命令匹配的第一条规则，没有匹配的规则时返回 nil。
+// 规则没有默认动作，未匹配任何规则的命令都会放行，allow 规则只用于豁免优先级更低的拒绝规则
+func (service commandRuleService) Match(userId, assetId, command string) (*model.CommandRule, error) {
+	rules, err := service.findInScope(userId, assetId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		rule := rules[i]
+		re, err := service.compile(rule.Pattern)
+		if err != nil {
+			log.Warnf("命令拦截规则「%v」正则表达式格式不正确: %v", rule.Name, err.Error())
+			continue
+		}
+		if re.MatchString(command) {
+			return &rule, nil
+		}
+	}
+	return nil, nil
+}
+
+func (service commandRuleService) inScope(rule model.CommandRule, userId string, userGroupIds []string, assetId string) bool {
+	if rule.AssetIds != "" && !utils.Contains(strings.Split(rule.AssetIds, ","), assetId) {
+		return false
+	}
+	if rule.UserIds == "" && rule.UserGroupIds == "" {
+		return true
+	}
+	if rule.UserIds != "" && utils.Contains(strings.Split(rule.UserIds, ","), userId) {
+		return true
+	}
+	if rule.UserGroupIds != "" {
+		for _, userGroupId := range strings.Split(rule.UserGroupIds, ",") {
+			if utils.Contains(userGroupIds, userGroupId) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewCommandFilter 为会话创建命令过滤器，会话的用户及资产名称只查询一次，未录屏时 recorder 为 nil
+func (service commandRuleService) NewCommandFilter(s model.Session, recorder *term.Recorder) *CommandFilter {
+	filter := &CommandFilter{
+		sessionId: s.ID,
+		userId:    s.Creator,
+		assetId:   s.AssetId,
+		clientIp:  s.ClientIP,
+		recorder:  recorder,
+	}
+	if user, err := repository.UserRepository.FindById(context.TODO(), s.Creator); err == nil {
+		filter.username = user.Username
+	}
+	if asset, err := repository.AssetRepository.FindById(context.TODO(), s.AssetId); err == nil {
+		filter.assetName = asset.Name
+	}
+	return filter
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+)
+
+// concurrentLimitService 限制用户同时在线的登录数以及用户、资产同时连接的会话数
+type concurrentLimitService struct {
+}
+
+// CheckLogin 在签发登录令牌之前调用，超出限制时根据配置拒绝本次登录或下线最早的登录
+func (service concurrentLimitService) CheckLogin(username string) error {
+	propertiesMap := repository.PropertyRepository.FindAllMap(context.TODO())
+	maxCount, _ := strconv.Atoi(propertiesMap[constant.LoginMaxConcurrent])
+	if maxCount <= 0 {
+		return nil
+	}
+	aliveLogs, err := repository.LoginLogRepository.FindAliveLoginLogsByUsername(context.TODO(), username)
+	if err != nil {
+		return err
+	}
+	// 只统计 Web 登录，SSH 登录由会话数限制
+	var loginLogs []model.LoginLog
+	for _, loginLog := range aliveLogs {
+		if loginLog.ClientUserAgent != "terminal" {
+			loginLogs = append(loginLogs, loginLog)
+		}
+	}
+	if len(loginLogs) < maxCount {
+		return nil
+	}
+
+	if propertiesMap[constant.LoginConcurrentPolicy] != constant.LoginConcurrentEvict {
+		return fmt.Errorf("同时在线的登录数已达到上限%d个，请先退出其他登录", maxCount)
+	}
+
+	sort.Slice(loginLogs, func(i, j int) bool {
+		return loginLogs[i].LoginTime.Before(loginLogs[j].LoginTime.Time)
+	})
+	for _, loginLog := range loginLogs[:len(loginLogs)-maxCount+1] {
+		token := loginLog.ID
+		if err := UserService.LogoutByToken(token); err != nil {
+			return err
+		}
+		UserService.Logout(token)
+		log.Debugf("用户「%v」同时在线的登录数超出限制，下线最早的登录「%v」", username, token)
+	}
+	return nil
+}
+
+// CheckSession 在创建会话之前调用，统计连接中及已连接的会话
+func (service concurrentLimitService) CheckSession(userId, assetId string) error {
+	return service.checkSession(userId, assetId, "")
+}
+
+// CheckConnect 在会话状态修改为连接中或已连接之前再次检查，防止先创建多个未连接的会话再同时连接，统计时不包括会话自身
+func (service concurrentLimitService) CheckConnect(s model.Session) error {
+	return service.checkSession(s.Creator, s.AssetId, s.ID)
+}
+
+func (service concurrentLimitService) checkSession(userId, assetId, excludeId string) error {
+	propertiesMap := repository.PropertyRepository.FindAllMap(context.TODO())
+	maxPerUser, _ := strconv.Atoi(propertiesMap[constant.SessionMaxPerUser])
+	maxPerAsset, _ := strconv.Atoi(propertiesMap[constant.SessionMaxPerAsset])
+
+	statuses := []string{constant.Connecting, constant.Connected}
+	if maxPerUser > 0 && userId != "" {
+		total, err := repository.SessionRepository.CountByCreatorAndStatusIn(context.TODO(), userId, statuses, excludeId)
+		if err != nil {
+			return err
+		}
+		if total >= int64(maxPerUser) {
+			return fmt.Errorf("您同时连接的会话数已达到上限%d个", maxPerUser)
+		}
+	}
+	if maxPerAsset > 0 {
+		total, err := repository.SessionRepository.CountByAssetIdAndStatusIn(context.TODO(), assetId, statuses, excludeId)
+		if err != nil {
+			return err
+		}
+		if total >= int64(maxPerAsset) {
+			return errors.New("该资产同时连接的会话数已达到上限，请稍后再试")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/dto"
+	"next-terminal/server/env"
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+
+	"gorm.io/gorm"
+)
+
+type credentialCheckoutService struct {
+	baseService
+}
+
+// Create 借出授权凭证，开启审批时凭证所有者及管理员以外的用户需要等待审批
+func (service credentialCheckoutService) Create(user *model.User, item dto.CredentialCheckout, clientIp string) (*model.CredentialCheckout, error) {
+	reason := strings.TrimSpace(item.Reason)
+	if reason == "" {
+		return nil, errors.New("请填写借出理由")
+	}
+	propertiesMap := repository.PropertyRepository.FindAllMap(context.TODO())
+	maxDuration, _ := strconv.Atoi(propertiesMap[constant.CredentialCheckoutMaxDuration])
+	if item.Duration <= 0 {
+		return nil, errors.New("借出时长必须大于0分钟")
+	}
+	if maxDuration > 0 && item.Duration > maxDuration {
+		return nil, fmt.Errorf("借出时长必须在1到%d分钟之间", maxDuration)
+	}
+
+	credential, err := repository.CredentialRepository.FindById(context.TODO(), item.CredentialId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("授权凭证不存在")
+		}
+		return nil, err
+	}
+	allowed, err := service.canCheckout(user, credential)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.New("您没有权限借出此授权凭证")
+	}
+	exist, err := repository.CredentialCheckoutRepository.ExistOpenByUserIdAndCredentialId(context.TODO(), user.ID, credential.ID)
+	if err != nil {
+		return nil, err
+	}
+	if exist {
+		return nil, errors.New("您已经借出或申请借出此授权凭证")
+	}
+
+	checkout := &model.CredentialCheckout{
+		ID:           utils.UUID(),
+		CredentialId: credential.ID,
+		UserId:       user.ID,
+		Reason:       reason,
+		Duration:     item.Duration,
+		Status:       constant.CheckoutPending,
+		Created:      utils.NowJsonTime(),
+	}
+	needApproval := propertiesMap[constant.CredentialCheckoutApproval] == "true" &&
+		constant.TypeAdmin != user.Type && credential.Owner != user.ID
+	if !needApproval {
+		checkout.Status = constant.CheckoutActive
+		checkout.ExpiresAt = utils.NewJsonTime(time.Now().Add(time.Duration(item.Duration) * time.Minute))
+	}
+
+	err = env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		if checkout.Status == constant.CheckoutActive {
+			if err := service.checkExclusive(c, credential.ID); err != nil {
+				return err
+			}
+		}
+		if err := repository.CredentialCheckoutRepository.Create(c, checkout); err != nil {
+			return err
+		}
+		return service.audit(c, checkout.ID, "create", user.ID, clientIp, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if needApproval {
+		approvers, err := AccessRequestService.findApprovers(credential.Owner)
+		if err != nil {
+			log.Errorf("查询凭证借出「%v」的审批人失败: %v", checkout.ID, err.Error())
+			return checkout, nil
+		}
+		for _, approver := range approvers {
+			if approver.ID == user.ID || approver.Mail == "" {
+				continue
+			}
+			subject := fmt.Sprintf("凭证借出待审批：%s", credential.Name)
+			text := fmt.Sprintf("用户「%s」申请借出授权凭证「%s」%d分钟，理由：%s\n请登录系统进行审批。", user.Username, credential.Name, checkout.Duration, reason)
+			go MailService.SendMail(approver.Mail, subject, text)
+		}
+	}
+	return checkout, nil
+}
+
+// canCheckout 凭证所有者、管理员及被授权使用凭证的用户可以借出
+func (service credentialCheckoutService) canCheckout(user *model.User, credential model.Credential) (bool, error) {
+	if constant.TypeAdmin == user.Type || credential.Owner == user.ID {
+		return true, nil
+	}
+	resourceSharers, err := repository.ResourceSharerRepository.FindByResourceIdAndUserId(context.TODO(), credential.ID, user.ID)
+	if err != nil {
+		return false, err
+	}
+	for i := range resourceSharers {
+		if resourceSharers[i].Valid(time.Now()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkExclusive 同一个凭证同一时间只能借出给一个用户，需要在借出生效的事务中调用，锁定凭证避免并发借出
+func (service credentialCheckoutService) checkExclusive(c context.Context, credentialId string) error {
+	if _, err := repository.CredentialRepository.FindByIdForUpdate(c, credentialId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("授权凭证不存在")
+		}
+		return err
+	}
+	checkouts, err := repository.CredentialCheckoutRepository.FindActiveByCredentialId(c, credentialId)
+	if err != nil {
+		return err
+	}
+	if len(checkouts) > 0 {
+		return fmt.Errorf("授权凭证已被借出，将于 %s 到期", checkouts[0].ExpiresAt.Format("2006-01-02 15:04:05"))
+	}
+	return nil
+}
+
+// CanApprove 管理员、凭证所有者及审批用户组的成员可以审批，但不能审批自己的申请
+func (service credentialCheckoutService) CanApprove(account *model.User, checkout model.CredentialCheckout) (bool, error) {
+	if account.ID == checkout.UserId {
+		return false, nil
+	}
+	if constant.TypeAdmin == account.Type {
+		return true, nil
+	}
+	credential, err := repository.CredentialRepository.FindById(context.TODO(), checkout.CredentialId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	if credential.Owner == account.ID {
+		return true, nil
+	}
+	return AccessRequestService.IsApproverGroupMember(account.ID)
+}
+
+// Approve 批准借出，有效期从批准时开始计算
+func (service credentialCheckoutService) Approve(account *model.User, id string, item dto.CredentialCheckoutReview, clientIp string) error {
+	checkout, err := service.findForApprover(account, id)
+	if err != nil {
+		return err
+	}
+	if checkout.Status != constant.CheckoutPending {
+		return constant.ErrCheckoutNotPending
+	}
+
+	expiresAt := time.Now().Add(time.Duration(checkout.Duration) * time.Minute)
+	err = env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		if err := service.checkExclusive(c, checkout.CredentialId); err != nil {
+			return err
+		}
+		ok, err := repository.CredentialCheckoutRepository.UpdateStatusById(c, &model.CredentialCheckout{
+			Status:     constant.CheckoutActive,
+			ApproverId: account.ID,
+			Comment:    item.Comment,
+			ExpiresAt:  utils.NewJsonTime(expiresAt),
+		}, id, constant.CheckoutPending)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return constant.ErrCheckoutNotPending
+		}
+		return service.audit(c, id, "approve", account.ID, clientIp, item.Comment)
+	})
+	if err != nil {
+		return err
+	}
+	service.notifyRequester(checkout, "已批准", fmt.Sprintf("借出有效期至 %s。", expiresAt.Format("2006-01-02 15:04:05")))
+	return nil
+}
+
+// Deny 拒绝借出
+func (service credentialCheckoutService) Deny(account *model.User, id string, item dto.CredentialCheckoutReview, clientIp string) error {
+	checkout, err := service.findForApprover(account, id)
+	if err != nil {
+		return err
+	}
+	if checkout.Status != constant.CheckoutPending {
+		return constant.ErrCheckoutNotPending
+	}
+	err = env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		ok, err := repository.CredentialCheckoutRepository.UpdateStatusById(c, &model.CredentialCheckout{
+			Status:     constant.CheckoutDenied,
+			ApproverId: account.ID,
+			Comment:    item.Comment,
+		}, id, constant.CheckoutPending)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return constant.ErrCheckoutNotPending
+		}
+		return service.audit(c, id, "deny", account.ID, clientIp, item.Comment)
+	})
+	if err != nil {
+		return err
+	}
+	service.notifyRequester(checkout, "已拒绝", "审批意见："+item.Comment)
+	return nil
+}
+
+// Cancel 申请人撤回尚未审批的借出申请
+func (service credentialCheckoutService) Cancel(account *model.User, id, clientIp string) error {
+	checkout, err := service.findForRequester(account, id)
+	if err != nil {
+		return err
+	}
+	if checkout.Status != constant.CheckoutPending {
+		return constant.ErrCheckoutNotPending
+	}
+	return env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		ok, err := repository.CredentialCheckoutRepository.UpdateStatusById(c, &model.CredentialCheckout{
+			Status: constant.CheckoutCancelled,
+		}, id, constant.CheckoutPending)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return constant.ErrCheckoutNotPending
+		}
+		return service.audit(c, id, "cancel", account.ID, clientIp, "")
+	})
+}
+
+// Reveal 借出期间查看凭证的密码或密钥，每次查看都会记录审计日志
+func (service credentialCheckoutService) Reveal(account *model.User, id, clientIp string) (*dto.CredentialSecret, error) {
+	checkout, err := service.findForRequester(account, id)
+	if err != nil {
+		return nil, err
+	}
+	if checkout.Status != constant.CheckoutActive || !time.Now().Before(checkout.ExpiresAt.Time) {
+		return nil, constant.ErrCheckoutNotActive
+	}
+	credential, err := CredentialService.FindByIdAndDecrypt(context.TODO(), checkout.CredentialId)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &dto.CredentialSecret{Username: credential.Username}
+	var revealed string
+	switch credential.Type {
+	case constant.PrivateKey:
+		secret.PrivateKey = credential.PrivateKey
+		secret.Passphrase = credential.Passphrase
+		revealed = "查看密钥"
+	default:
+		secret.Password = credential.Password
+		revealed = "查看密码"
+	}
+	if err := service.audit(context.TODO(), id, "reveal", account.ID, clientIp, revealed); err != nil {
+		return nil, err
+	}
+	log.Infof("用户「%v」查看了借出的授权凭证「%v」", account.Username, credential.Name)
+	return secret, nil
+}
+
+// CheckIn 归还凭证，凭证被标记为需要轮换
+func (service credentialCheckoutService) CheckIn(account *model.User, id, clientIp string) error {
+	checkout, err := service.findForRequester(account, id)
+	if err != nil {
+		return err
+	}
+	if checkout.Status != constant.CheckoutActive {
+		return constant.ErrCheckoutNotActive
+	}
+	return service.checkIn(checkout, constant.CheckoutReturned, "checkin", account.ID, clientIp)
+}
+
+// ExpireCheckouts 收回已经到期的借出，由定时任务调用
+func (service credentialCheckoutService) ExpireCheckouts() {
+	checkouts, err := repository.CredentialCheckoutRepository.FindActiveExpiredBefore(context.TODO(), time.Now())
+	if err != nil {
+		log.Errorf("查询到期的凭证借出失败: %v", err.Error())
+		return
+	}
+	for _, checkout := range checkouts {
+		if err := service.checkIn(checkout, constant.CheckoutExpired, "expire", "", ""); err != nil {
+			log.Errorf("收回凭证借出「%v」失败: %v", checkout.ID, err.Error())
+			continue
+		}
+		log.Debugf("凭证借出「%v」已到期", checkout.ID)
+		service.notifyRequester(checkout, "已到期", "如需继续使用请重新借出。")
+	}
+}
+
+func (service credentialCheckoutService) checkIn(checkout model.CredentialCheckout, status, action, operatorId, clientIp string) error {
+	return env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		checkout.Status = status
+		checkout.CheckedIn = utils.NowJsonTime()
+		ok, err := repository.CredentialCheckoutRepository.UpdateStatusById(c, &checkout, checkout.ID, constant.CheckoutActive)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return constant.ErrCheckoutNotActive
+		}
+		if err := repository.CredentialRepository.UpdateNeedsRotationById(c, true, checkout.CredentialId); err != nil {
+			return err
+		}
+		return service.audit(c, checkout.ID, action, operatorId, clientIp, "")
+	})
+}
+
+func (service credentialCheckoutService) findForApprover(account *model.User, id string) (model.CredentialCheckout, error) {
+	checkout, err := repository.CredentialCheckoutRepository.FindById(context.TODO(), id)
+	if err != nil {
+		return checkout, err
+	}
+	ok, err := service.CanApprove(account, checkout)
+	if err != nil {
+		return checkout, err
+	}
+	if !ok {
+		return checkout, constant.ErrCheckoutForbidden
+	}
+	return checkout, nil
+}
+
+func (service credentialCheckoutService) findForRequester(account *model.User, id string) (model.CredentialCheckout, error) {
+	checkout, err := repository.CredentialCheckoutRepository.FindById(context.TODO(), id)
+	if err != nil {
+		return checkout, err
+	}
+	if checkout.UserId != account.ID {
+		return checkout, constant.ErrCheckoutForbidden
+	}
+	return checkout, nil
+}
+
+func (service credentialCheckoutService) notifyRequester(checkout model.CredentialCheckout, result, detail string) {
+	user, err := repository.UserRepository.FindById(context.TODO(), checkout.UserId)
+	if err != nil || user.Mail == "" {
+		return
+	}
+	credential, err := repository.CredentialRepository.FindById(context.TODO(), checkout.CredentialId)
+	if err != nil {
+		return
+	}
+	subject := fmt.Sprintf("凭证借出%s：%s", result, credential.Name)
+	text := fmt.Sprintf("您借出授权凭证「%s」的申请%s。%s", credential.Name, result, detail)
+	go MailService.SendMail(user.Mail, subject, text)
+}
+
+func (service credentialCheckoutService) audit(c context.Context, checkoutId, action, operatorId, clientIp, comment string) error {
+	return repository.CredentialCheckoutLogRepository.Create(c, &model.CredentialCheckoutLog{
+		ID:         utils.UUID(),
+		CheckoutId: checkoutId,
+		Action:     action,
+		OperatorId: operatorId,
+		ClientIP:   clientIp,
+		Comment:    comment,
+		Created:    utils.NowJsonTime(),
+	})
+}
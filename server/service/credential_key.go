@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/dto"
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// keyComment 生成及部署的公钥使用的注释，便于在 authorized_keys 中识别
+const keyComment = "next-terminal"
+
+// GenerateKeyPair 在服务端生成密钥对并保存为密钥类型的授权凭证，私钥加密存储，返回公钥
+func (s credentialService) GenerateKeyPair(c context.Context, item dto.CredentialGenerate, owner string) (*model.Credential, string, error) {
+	name := strings.TrimSpace(item.Name)
+	if name == "" {
+		return nil, "", errors.New("凭证名称不能为空")
+	}
+	privateKey, publicKey, err := utils.GenerateSshKey(item.KeyType, item.Bits, item.Passphrase, keyComment)
+	if err != nil {
+		return nil, "", err
+	}
+
+	credential := &model.Credential{
+		ID:         utils.UUID(),
+		Name:       name,
+		Type:       constant.PrivateKey,
+		Username:   item.Username,
+		Password:   "-",
+		PrivateKey: privateKey,
+		Passphrase: item.Passphrase,
+		Owner:      owner,
+		Created:    utils.NowJsonTime(),
+	}
+	if credential.Username == "" {
+		credential.Username = "-"
+	}
+	if credential.Passphrase == "" {
+		credential.Passphrase = "-"
+	}
+	if err := s.Create(c, credential); err != nil {
+		return nil, "", err
+	}
+	return credential, publicKey, nil
+}
+
+// PublicKey 从密钥类型的授权凭证中解析出公钥
+func (s credentialService) PublicKey(c context.Context, id string) (string, error) {
+	credential, err := s.FindByIdAndDecrypt(c, id)
+	if err != nil {
+		return "", err
+	}
+	signer, err := s.parseSigner(credential)
+	if err != nil {
+		return "", err
+	}
+	return utils.AuthorizedKeyLine(signer.PublicKey(), keyComment), nil
+}
+
+func (s credentialService) parseSigner(credential model.Credential) (ssh.Signer, error) {
+	if credential.Type != constant.PrivateKey {
+		return nil, errors.New("只有密钥类型的授权凭证可以部署公钥")
+	}
+	if credential.Passphrase != "" && credential.Passphrase != "-" {
+		return ssh.ParsePrivateKeyWithPassphrase([]byte(credential.PrivateKey), []byte(credential.Passphrase))
+	}
+	return ssh.ParsePrivateKey([]byte(credential.PrivateKey))
+}
+
+// DeployPublicKey 使用资产当前的登录账号将公钥追加到 ~/.ssh/authorized_keys，并使用密钥重新登录验证
+func (s credentialService) DeployPublicKey(c context.Context, id string, item dto.CredentialDeploy) ([]dto.CredentialDeployResult, error) {
+	credential, err := s.FindByIdAndDecrypt(c, id)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := s.parseSigner(credential)
+	if err != nil {
+		return nil, err
+	}
+	publicKey := signer.PublicKey()
+	keyLine := utils.AuthorizedKeyLine(publicKey, keyComment)
+	keyData := utils.AuthorizedKeyLine(publicKey, "")
+	command := fmt.Sprintf("mkdir -p ~/.ssh && chmod 700 ~/.ssh && touch ~/.ssh/authorized_keys && chmod 600 ~/.ssh/authorized_keys && "+
+		"(grep -qF '%s' ~/.ssh/authorized_keys || echo '%s' >> ~/.ssh/authorized_keys)", keyData, keyLine)
+
+	assets, err := repository.AssetRepository.FindByProtocolAndIds(c, "ssh", item.AssetIds)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]dto.CredentialDeployResult, 0)
+	for _, asset := range assets {
+		result := dto.CredentialDeployResult{AssetId: asset.ID, AssetName: asset.Name}
+		username, err := s.deployPublicKey(c, &credential, asset, command, item.DisablePassword)
+		if err != nil {
+			result.Message = err.Error()
+			log.Warnf("部署授权凭证「%v」的公钥到资产「%v」失败: %v", credential.Name, asset.Name, err.Error())
+		} else {
+			result.Success = true
+			result.Message = fmt.Sprintf("公钥已部署到账号「%v」", username)
+			log.Infof("部署授权凭证「%v」的公钥到资产「%v」账号「%v」", credential.Name, asset.Name, username)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s credentialService) deployPublicKey(c context.Context, credential *model.Credential, asset model.Asset, command string, disablePassword bool) (string, error) {
+	account, err := AssetAccountService.FindAccountAndDecrypt(c, asset, "")
+	if err != nil {
+		return "", err
+	}
+	username := account.Username
+	if username == "" || username == "-" {
+		username = "root"
+	}
+	if credential.Username != "" && credential.Username != "-" && credential.Username != username {
+		return "", fmt.Errorf("凭证的用户名「%v」与资产的登录账号「%v」不一致", credential.Username, username)
+	}
+
+	client, closeClient, err := newAssetSshClient(asset, username, account.Password, account.PrivateKey, account.Passphrase)
+	if err != nil {
+		return "", fmt.Errorf("登录失败「%v」", err.Error())
+	}
+	defer closeClient()
+	if _, err := utils.RunCommand(client, command); err != nil {
+		return "", fmt.Errorf("写入 authorized_keys 失败「%v」", err.Error())
+	}
+
+	_, closeVerify, err := newAssetSshClient(asset, username, "", credential.PrivateKey, credential.Passphrase)
+	if err != nil {
+		return "", fmt.Errorf("公钥已写入，但使用密钥登录验证失败「%v」", err.Error())
+	}
+	closeVerify()
+
+	if !disablePassword || (account.AccountType == constant.AccountCredential && account.CredentialId == credential.ID) {
+		return username, nil
+	}
+	// 凭证未设置用户名时使用部署的账号，资产改为使用凭证登录后才能保持同一个账号
+	if credential.Username == "" || credential.Username == "-" {
+		if err := repository.CredentialRepository.UpdateById(c, &model.Credential{Username: username}, credential.ID); err != nil {
+			return "", err
+		}
+		credential.Username = username
+	}
+	update := model.Asset{
+		AccountType:  constant.AccountCredential,
+		CredentialId: credential.ID,
+		Username:     "-",
+		Password:     "-",
+		PrivateKey:   "-",
+		Passphrase:   "-",
+	}
+	if err := repository.AssetRepository.UpdateById(c, &update, asset.ID); err != nil {
+		return "", fmt.Errorf("公钥已部署，但修改资产的登录方式失败「%v」", err.Error())
+	}
+	return username, nil
+}
+
+// RevokePublicKey 从资产的 ~/.ssh/authorized_keys 中删除公钥，资产正在使用该凭证登录时不允许撤销
+func (s credentialService) RevokePublicKey(c context.Context, id string, item dto.CredentialDeploy) ([]dto.CredentialDeployResult, error) {
+	credential, err := s.FindByIdAndDecrypt(c, id)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := s.parseSigner(credential)
+	if err != nil {
+		return nil, err
+	}
+	keyData := utils.AuthorizedKeyLine(signer.PublicKey(), "")
+	command := fmt.Sprintf("f=~/.ssh/authorized_keys; if [ -f \"$f\" ]; then "+
+		"grep -vF '%s' \"$f\" > \"$f.tmp\"; cat \"$f.tmp\" > \"$f\"; rm -f \"$f.tmp\"; fi", keyData)
+
+	assets, err := repository.AssetRepository.FindByProtocolAndIds(c, "ssh", item.AssetIds)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]dto.CredentialDeployResult, 0)
+	for _, asset := range assets {
+		result := dto.CredentialDeployResult{AssetId: asset.ID, AssetName: asset.Name}
+		username, err := s.revokePublicKey(c, credential, asset, command)
+		if err != nil {
+			result.Message = err.Error()
+			log.Warnf("从资产「%v」撤销授权凭证「%v」的公钥失败: %v", asset.Name, credential.Name, err.Error())
+		} else {
+			result.Success = true
+			result.Message = fmt.Sprintf("已从账号「%v」撤销公钥", username)
+			log.Infof("从资产「%v」账号「%v」撤销授权凭证「%v」的公钥", asset.Name, username, credential.Name)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s credentialService) revokePublicKey(c context.Context, credential model.Credential, asset model.Asset, command string) (string, error) {
+	account, err := AssetAccountService.FindAccountAndDecrypt(c, asset, "")
+	if err != nil {
+		return "", err
+	}
+	if account.AccountType == constant.AccountCredential && account.CredentialId == credential.ID {
+		return "", errors.New("资产正在使用该凭证登录，请先修改资产的登录方式")
+	}
+	username := account.Username
+	if username == "" || username == "-" {
+		username = "root"
+	}
+
+	client, closeClient, err := newAssetSshClient(asset, username, account.Password, account.PrivateKey, account.Passphrase)
+	if err != nil {
+		return "", fmt.Errorf("登录失败「%v」", err.Error())
+	}
+	defer closeClient()
+	if _, err := utils.RunCommand(client, command); err != nil {
+		return "", fmt.Errorf("修改 authorized_keys 失败「%v」", err.Error())
+	}
+	return username, nil
+}
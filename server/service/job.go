@@ -53,6 +53,10 @@ func getJob(j *model.Job) (job cron.Job, err error) {
 		}
 	case constant.FuncShellJob:
 		job = ShellJob{ID: j.ID, Mode: j.Mode, ResourceIds: j.ResourceIds, Metadata: j.Metadata}
+	case constant.FuncLdapUserSyncJob:
+		job = LdapUserSyncJob{ID: j.ID}
+	case constant.FuncRotatePasswordJob:
+		job = RotatePasswordJob{ID: j.ID, Mode: j.Mode, ResourceIds: j.ResourceIds, Metadata: j.Metadata}
 	default:
 		return nil, errors.New("未识别的任务")
 	}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"next-terminal/server/config"
 	"next-terminal/server/constant"
 	"next-terminal/server/log"
 	"next-terminal/server/model"
@@ -26,7 +27,8 @@ type ShellJob struct {
 }
 
 type MetadataShell struct {
-	Shell string
+	Shell   string
+	Account string // 执行脚本使用的资产账号名称，为空时使用资产自身配置的账号
 }
 
 func (r ShellJob) Run() {
@@ -52,28 +54,34 @@ func (r ShellJob) Run() {
 		return
 	}
 
-	msgChan := make(chan string)
+	// 查询资产账号失败时直接写入结果，需要足够的缓冲区
+	msgChan := make(chan string, len(assets))
 	for i := range assets {
-		asset, err := AssetService.FindByIdAndDecrypt(context.TODO(), assets[i].ID)
+		asset := assets[i]
+		account, err := AssetAccountService.FindAccountByName(context.TODO(), asset, metadataShell.Account)
 		if err != nil {
+			msgChan <- fmt.Sprintf("资产「%v」Shell执行失败，查询资产账号「%v」异常「%v」", assets[i].Name, metadataShell.Account, err.Error())
+			continue
+		}
+		if err := AssetAccountService.Decrypt(&account, config.GlobalCfg.EncryptionPassword); err != nil {
 			msgChan <- fmt.Sprintf("资产「%v」Shell执行失败，查询数据异常「%v」", assets[i].Name, err.Error())
-			return
+			continue
 		}
 
 		var (
-			username   = asset.Username
-			password   = asset.Password
-			privateKey = asset.PrivateKey
-			passphrase = asset.Passphrase
+			username   = account.Username
+			password   = account.Password
+			privateKey = account.PrivateKey
+			passphrase = account.Passphrase
 			ip         = asset.IP
 			port       = asset.Port
 		)
 
-		if asset.AccountType == "credential" {
-			credential, err := CredentialService.FindByIdAndDecrypt(context.TODO(), asset.CredentialId)
+		if account.AccountType == constant.AccountCredential {
+			credential, err := CredentialService.FindByIdAndDecrypt(context.TODO(), account.CredentialId)
 			if err != nil {
 				msgChan <- fmt.Sprintf("资产「%v」Shell执行失败，查询授权凭证数据异常「%v」", assets[i].Name, err.Error())
-				return
+				continue
 			}
 
 			if credential.Type == constant.Custom {
@@ -85,6 +93,14 @@ func (r ShellJob) Run() {
 				passphrase = credential.Passphrase
 			}
 		}
+		if account.AccountType == constant.AccountCertificate {
+			privateKey, err = SshCAService.IssueCertificate(context.TODO(), username, certificateKeyId("job", r.ID), 5)
+			if err != nil {
+				msgChan <- fmt.Sprintf("资产「%v」Shell执行失败，签发SSH证书异常「%v」", assets[i].Name, err.Error())
+				continue
+			}
+			passphrase = "-"
+		}
 
 		go func() {
 			t1 := time.Now()
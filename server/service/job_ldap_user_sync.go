@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+)
+
+type LdapUserSyncJob struct {
+	ID string
+}
+
+func (r LdapUserSyncJob) Run() {
+	if r.ID == "" {
+		return
+	}
+
+	var msg string
+	if !LdapService.Enabled() {
+		msg = "未开启LDAP认证，跳过同步"
+	} else {
+		t1 := time.Now()
+		created, updated, disabled, err := LdapService.Sync()
+		elapsed := time.Since(t1)
+		if err != nil {
+			msg = fmt.Sprintf("LDAP用户同步失败，错误内容为：「%v」，耗时「%v」", err.Error(), elapsed)
+		} else {
+			msg = fmt.Sprintf("LDAP用户同步完成，新增「%v」，更新「%v」，停用「%v」，耗时「%v」", created, updated, disabled, elapsed)
+		}
+	}
+	log.Infof(msg)
+
+	_ = repository.JobRepository.UpdateLastUpdatedById(context.TODO(), r.ID)
+	jobLog := model.JobLog{
+		ID:        utils.UUID(),
+		JobId:     r.ID,
+		Timestamp: utils.NowJsonTime(),
+		Message:   msg,
+	}
+
+	_ = repository.JobLogRepository.Create(context.TODO(), &jobLog)
+}
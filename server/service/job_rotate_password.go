@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"next-terminal/server/config"
+	"next-terminal/server/constant"
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+
+	"golang.org/x/crypto/ssh"
+)
+
+type RotatePasswordJob struct {
+	ID          string
+	Mode        string
+	ResourceIds string // 轮换资产密码时为资产ID，轮换授权凭证密码时为凭证ID
+	Metadata    string
+}
+
+type MetadataRotatePassword struct {
+	Target  string               // asset: 轮换资产账号的密码，credential: 轮换授权凭证的密码，为空时轮换资产账号的密码
+	Account string               // 轮换的资产账号名称，为空时使用资产自身配置的账号
+	Policy  utils.PasswordPolicy // 新密码的生成策略
+}
+
+func (r RotatePasswordJob) Run() {
+	if r.ID == "" {
+		return
+	}
+
+	var metadata MetadataRotatePassword
+	if r.Metadata != "" {
+		if err := json.Unmarshal([]byte(r.Metadata), &metadata); err != nil {
+			log.Errorf("JSON数据解析失败 %v", err)
+			return
+		}
+	}
+
+	var message string
+	if err := metadata.Policy.ValidateSpecials(); err != nil {
+		message = fmt.Sprintf("密码轮换失败，密码生成策略无效「%v」", err.Error())
+	} else if metadata.Target == constant.RotateTargetCredential {
+		message = r.rotateCredentials(metadata)
+	} else {
+		message = r.rotateAssets(metadata)
+	}
+	if message == "" {
+		return
+	}
+
+	_ = repository.JobRepository.UpdateLastUpdatedById(context.TODO(), r.ID)
+	jobLog := model.JobLog{
+		ID:        utils.UUID(),
+		JobId:     r.ID,
+		Timestamp: utils.NowJsonTime(),
+		Message:   message,
+	}
+
+	_ = repository.JobLogRepository.Create(context.TODO(), &jobLog)
+}
+
+func (r RotatePasswordJob) rotateAssets(metadata MetadataRotatePassword) string {
+	var assets []model.Asset
+	if r.Mode == constant.JobModeAll {
+		assets, _ = repository.AssetRepository.FindByProtocol(context.TODO(), "ssh")
+	} else {
+		assets, _ = repository.AssetRepository.FindByProtocolAndIds(context.TODO(), "ssh", strings.Split(r.ResourceIds, ","))
+	}
+
+	msgChan := make(chan string, len(assets))
+	for i := range assets {
+		asset := assets[i]
+		go func() {
+			msg := r.rotateAsset(asset, metadata)
+			log.Infof(msg)
+			msgChan <- msg
+		}()
+	}
+
+	var message = ""
+	for i := 0; i < len(assets); i++ {
+		message += <-msgChan + "\n"
+	}
+	return message
+}
+
+func (r RotatePasswordJob) rotateAsset(asset model.Asset, metadata MetadataRotatePassword) string {
+	t1 := time.Now()
+	account, err := AssetAccountService.FindAccountByName(context.TODO(), asset, metadata.Account)
+	if err != nil {
+		return fmt.Sprintf("资产「%v」密码轮换失败，查询资产账号「%v」异常「%v」", asset.Name, metadata.Account, err.Error())
+	}
+	if err := AssetAccountService.Decrypt(&account, config.GlobalCfg.EncryptionPassword); err != nil {
+		return fmt.Sprintf("资产「%v」密码轮换失败，查询数据异常「%v」", asset.Name, err.Error())
+	}
+	switch account.AccountType {
+	case constant.Custom:
+	case constant.AccountCredential:
+		return fmt.Sprintf("资产「%v」密码轮换失败，资产账号「%v」使用授权凭证登录，请轮换授权凭证的密码", asset.Name, account.Name)
+	case constant.AccountCertificate:
+		return fmt.Sprintf("资产「%v」密码轮换失败，资产账号「%v」使用SSH证书登录，无需轮换密码", asset.Name, account.Name)
+	default:
+		return fmt.Sprintf("资产「%v」密码轮换失败，资产账号「%v」使用密钥登录", asset.Name, account.Name)
+	}
+
+	newPassword, err := utils.GenPasswordWithPolicy(metadata.Policy)
+	if err != nil {
+		return fmt.Sprintf("资产「%v」密码轮换失败，生成新密码异常「%v」", asset.Name, err.Error())
+	}
+	change, err := rotatePasswordBySSH(asset, account.Username, account.Password, newPassword)
+	if err != nil {
+		return fmt.Sprintf("资产「%v」密码轮换失败，%v，耗时「%v」", asset.Name, err.Error(), time.Since(t1))
+	}
+	defer change.close()
+
+	if err := r.saveAssetPassword(asset, account, newPassword); err != nil {
+		if e := change.Rollback(); e != nil {
+			return fmt.Sprintf("资产「%v」密码轮换失败，保存新密码异常「%v」，回滚失败「%v」，请尽快手动处理", asset.Name, err.Error(), e.Error())
+		}
+		return fmt.Sprintf("资产「%v」密码轮换失败，保存新密码异常「%v」，已回滚", asset.Name, err.Error())
+	}
+	return fmt.Sprintf("资产「%v」账号「%v」密码轮换成功，耗时「%v」", asset.Name, account.Name, time.Since(t1))
+}
+
+// saveAssetPassword 加密保存新密码，默认账号保存在资产上，其他账号保存在资产账号上
+func (r RotatePasswordJob) saveAssetPassword(asset model.Asset, account model.AssetAccount, newPassword string) error {
+	if account.ID == constant.DefaultAccount {
+		item := model.Asset{Password: newPassword}
+		if err := AssetService.Encrypt(&item, config.GlobalCfg.EncryptionPassword); err != nil {
+			return err
+		}
+		return repository.AssetRepository.UpdateById(context.TODO(), &item, asset.ID)
+	}
+	item := model.AssetAccount{Password: newPassword}
+	if err := AssetAccountService.Encrypt(&item, config.GlobalCfg.EncryptionPassword); err != nil {
+		return err
+	}
+	return repository.AssetAccountRepository.UpdateById(context.TODO(), &item, account.ID)
+}
+
+func (r RotatePasswordJob) rotateCredentials(metadata MetadataRotatePassword) string {
+	var credentials []model.Credential
+	if r.Mode == constant.JobModeAll {
+		credentials, _ = repository.CredentialRepository.FindAll(context.TODO())
+	} else {
+		credentials, _ = repository.CredentialRepository.FindByIds(context.TODO(), strings.Split(r.ResourceIds, ","))
+	}
+
+	// 不同的凭证可能用于同一个资产，依次轮换
+	var message = ""
+	for _, credential := range credentials {
+		if credential.Type != constant.Custom {
+			if r.Mode != constant.JobModeAll {
+				message += fmt.Sprintf("授权凭证「%v」密码轮换失败，密钥类型的凭证无法轮换密码\n", credential.Name)
+			}
+			continue
+		}
+		msg := r.rotateCredential(credential, metadata)
+		log.Infof(msg)
+		message += msg + "\n"
+	}
+	return message
+}
+
+// rotateCredential 在所有使用凭证的SSH资产上修改密码，任意一个资产失败时回滚已经修改的资产
+func (r RotatePasswordJob) rotateCredential(credential model.Credential, metadata MetadataRotatePassword) string {
+	t1 := time.Now()
+	if err := CredentialService.Decrypt(&credential, config.GlobalCfg.EncryptionPassword); err != nil {
+		return fmt.Sprintf("授权凭证「%v」密码轮换失败，查询数据异常「%v」", credential.Name, err.Error())
+	}
+	assets, err := r.findCredentialAssets(credential.ID)
+	if err != nil {
+		return fmt.Sprintf("授权凭证「%v」密码轮换失败，查询资产异常「%v」", credential.Name, err.Error())
+	}
+	if len(assets) == 0 {
+		return fmt.Sprintf("授权凭证「%v」密码轮换失败，没有使用该凭证的SSH资产", credential.Name)
+	}
+
+	newPassword, err := utils.GenPasswordWithPolicy(metadata.Policy)
+	if err != nil {
+		return fmt.Sprintf("授权凭证「%v」密码轮换失败，生成新密码异常「%v」", credential.Name, err.Error())
+	}
+	var (
+		lines   []string
+		changes []*passwordChange
+		failed  bool
+	)
+	defer func() {
+		for _, change := range changes {
+			change.close()
+		}
+	}()
+
+	for _, asset := range assets {
+		change, err := rotatePasswordBySSH(asset, credential.Username, credential.Password, newPassword)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("授权凭证「%v」在资产「%v」上修改密码失败，%v", credential.Name, asset.Name, err.Error()))
+			failed = true
+			break
+		}
+		changes = append(changes, change)
+		lines = append(lines, fmt.Sprintf("授权凭证「%v」在资产「%v」上修改密码成功", credential.Name, asset.Name))
+	}
+	if !failed {
+		if err := r.saveCredentialPassword(credential.ID, newPassword); err != nil {
+			lines = append(lines, fmt.Sprintf("授权凭证「%v」保存新密码异常「%v」", credential.Name, err.Error()))
+			failed = true
+		}
+	}
+
+	if failed {
+		for _, change := range changes {
+			if err := change.Rollback(); err != nil {
+				lines = append(lines, fmt.Sprintf("授权凭证「%v」在资产「%v」上回滚失败「%v」，请尽快手动处理", credential.Name, change.asset.Name, err.Error()))
+			} else {
+				lines = append(lines, fmt.Sprintf("授权凭证「%v」在资产「%v」上已回滚", credential.Name, change.asset.Name))
+			}
+		}
+		lines = append(lines, fmt.Sprintf("授权凭证「%v」密码轮换失败，耗时「%v」", credential.Name, time.Since(t1)))
+	} else {
+		lines = append(lines, fmt.Sprintf("授权凭证「%v」密码轮换成功，共修改「%v」个资产，耗时「%v」", credential.Name, len(changes), time.Since(t1)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// findCredentialAssets 查询使用凭证登录的SSH资产，包括使用凭证的资产账号所属的资产
+func (r RotatePasswordJob) findCredentialAssets(credentialId string) ([]model.Asset, error) {
+	assets, err := repository.AssetRepository.FindByProtocolAndCredentialId(context.TODO(), "ssh", credentialId)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := repository.AssetAccountRepository.FindByCredentialId(context.TODO(), credentialId)
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) > 0 {
+		var assetIds []string
+		for _, account := range accounts {
+			assetIds = append(assetIds, account.AssetId)
+		}
+		items, err := repository.AssetRepository.FindByProtocolAndIds(context.TODO(), "ssh", assetIds)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, items...)
+	}
+
+	var result []model.Asset
+	exist := make(map[string]bool)
+	for _, asset := range assets {
+		if exist[asset.ID] {
+			continue
+		}
+		exist[asset.ID] = true
+		result = append(result, asset)
+	}
+	return result, nil
+}
+
+// saveCredentialPassword 加密保存新密码，并清除凭证需要轮换的标记
+func (r RotatePasswordJob) saveCredentialPassword(id, newPassword string) error {
+	item := model.Credential{Password: newPassword, PrivateKey: "-", Passphrase: "-"}
+	if err := CredentialService.Encrypt(&item, config.GlobalCfg.EncryptionPassword); err != nil {
+		return err
+	}
+	if err := repository.CredentialRepository.UpdateById(context.TODO(), &item, id); err != nil {
+		return err
+	}
+	if err := repository.CredentialRepository.UpdateNeedsRotationById(context.TODO(), false, id); err != nil {
+		log.Warnf("清除授权凭证「%v」的轮换标记失败: %v", id, err.Error())
+	}
+	return nil
+}
+
+// passwordChange 已经在资产上完成的密码修改，保持原有连接用于回滚
+type passwordChange struct {
+	asset       model.Asset
+	username    string
+	oldPassword string
+	newPassword string
+	client      *ssh.Client
+	close       func()
+}
+
+// Rollback 将密码恢复为修改前的密码
+func (p *passwordChange) Rollback() error {
+	return changePasswordBySSH(p.client, p.username, p.newPassword, p.oldPassword)
+}
+
+// rotatePasswordBySSH 登录资产修改密码，并使用新密码重新登录验证，验证失败时回滚。调用方需要关闭返回的连接
+func rotatePasswordBySSH(asset model.Asset, username, oldPassword, newPassword string) (*passwordChange, error) {
+	if username == "" || username == "-" {
+		username = "root"
+	}
+	client, closeClient, err := newAssetSshClient(asset, username, oldPassword, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("登录失败「%v」", err.Error())
+	}
+	change := &passwordChange{
+		asset:       asset,
+		username:    username,
+		oldPassword: oldPassword,
+		newPassword: newPassword,
+		client:      client,
+		close:       closeClient,
+	}
+	if err := changePasswordBySSH(client, username, oldPassword, newPassword); err != nil {
+		closeClient()
+		return nil, fmt.Errorf("修改密码失败「%v」", err.Error())
+	}
+
+	_, closeVerify, err := newAssetSshClient(asset, username, newPassword, "", "")
+	if err != nil {
+		defer closeClient()
+		if e := change.Rollback(); e != nil {
+			return nil, fmt.Errorf("新密码验证失败「%v」，回滚失败「%v」，请尽快手动处理", err.Error(), e.Error())
+		}
+		return nil, fmt.Errorf("新密码验证失败「%v」，已回滚", err.Error())
+	}
+	closeVerify()
+	return change, nil
+}
+
+// changePasswordBySSH 修改登录账号的密码，root 使用 chpasswd 直接修改，其他账号通过 passwd 交互修改
+func changePasswordBySSH(client *ssh.Client, username, oldPassword, newPassword string) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = session.Close()
+	}()
+
+	if username == "root" {
+		session.Stdin = strings.NewReader(username + ":" + newPassword + "\n")
+		output, err := session.CombinedOutput("chpasswd")
+		if err != nil {
+			return fmt.Errorf("%v %v", err.Error(), strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+
+	if err := session.RequestPty("xterm", 40, 80, ssh.TerminalModes{ssh.ECHO: 0}); err != nil {
+		return err
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := session.Start("LANG=C passwd"); err != nil {
+		return err
+	}
+
+	var output strings.Builder
+	done := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1024)
+		var pending string
+		for {
+			n, err := stdout.Read(buf)
+			if n > 0 {
+				output.Write(buf[:n])
+				pending += string(buf[:n])
+				// 根据提示输入当前密码或新密码
+				prompt := strings.ToLower(strings.TrimSpace(pending))
+				if strings.HasSuffix(prompt, ":") {
+					switch {
+					case strings.Contains(prompt, "current"):
+						_, _ = stdin.Write([]byte(oldPassword + "\n"))
+					case strings.Contains(prompt, "password"):
+						_, _ = stdin.Write([]byte(newPassword + "\n"))
+					}
+					pending = ""
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+		done <- session.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("%v %v", err.Error(), strings.Join(strings.Fields(output.String()), " "))
+		}
+		return nil
+	case <-time.After(30 * time.Second):
+		return errors.New("执行 passwd 超时")
+	}
+}
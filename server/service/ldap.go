@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/env"
+	"next-terminal/server/ldap"
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+
+	"gorm.io/gorm"
+)
+
+type ldapService struct {
+	baseService
+}
+
+func (service ldapService) Enabled() bool {
+	property, err := repository.PropertyRepository.FindByName(context.TODO(), constant.EnableLdap)
+	if err != nil {
+		return false
+	}
+	return property.Value == "true"
+}
+
+func (service ldapService) client() *ldap.Client {
+	propertiesMap := repository.PropertyRepository.FindAllMap(context.TODO())
+	value := func(name string) string {
+		// 属性值为空时会被保存为 -
+		if propertiesMap[name] == "-" {
+			return ""
+		}
+		return propertiesMap[name]
+	}
+	return ldap.NewClient(ldap.Config{
+		Url:                value(constant.LdapUrl),
+		BindDN:             value(constant.LdapBindDN),
+		BindPassword:       value(constant.LdapBindPassword),
+		BaseDN:             value(constant.LdapBaseDN),
+		UserFilter:         value(constant.LdapUserFilter),
+		UsernameAttribute:  value(constant.LdapUserAttribute),
+		NicknameAttribute:  value(constant.LdapNicknameAttribute),
+		MailAttribute:      value(constant.LdapMailAttribute),
+		GroupAttribute:     value(constant.LdapGroupAttribute),
+		InsecureSkipVerify: value(constant.LdapSkipVerify) == "true",
+	})
+}
+
+// Authenticate 到LDAP服务器校验账号密码，成功后同步该用户的信息及用户组
+func (service ldapService) Authenticate(username, password string) (user model.User, err error) {
+	entry, err := service.client().Authenticate(username, password)
+	if err != nil {
+		return user, err
+	}
+	err = env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		user, err = UserService.SaveExternalUser(c, constant.SourceLdap, entry.Username, entry.Nickname, entry.Mail)
+		if err != nil {
+			return err
+		}
+		return UserGroupService.SaveExternalMembers(c, user.ID, constant.SourceLdap, entry.Groups)
+	})
+	return user, err
+}
+
+// Sync 同步目录中的全部用户：新增的用户自动创建，已存在的用户更新信息，目录中已不存在的用户将被停用，
+// 被同步停用的用户重新出现在目录中时将被重新启用，管理员手动停用的用户保持停用
+func (service ldapService) Sync() (created, updated, disabled int, err error) {
+	entries, err := service.client().Users()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	err = env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		users, err := repository.UserRepository.FindBySource(c, constant.SourceLdap)
+		if err != nil {
+			return err
+		}
+		existUsers := make(map[string]model.User, len(users))
+		localUsers := make([]ldap.LocalUser, 0, len(users))
+		for _, user := range users {
+			existUsers[user.Username] = user
+			localUsers = append(localUsers, ldap.LocalUser{
+				Username:       user.Username,
+				Disabled:       user.Status == constant.StatusDisabled,
+				DisabledBySync: user.DisabledBySync,
+			})
+		}
+		enable, disable := ldap.SyncActions(entries, localUsers)
+
+		for _, entry := range entries {
+			_, exist := existUsers[entry.Username]
+			user, err := UserService.SaveExternalUser(c, constant.SourceLdap, entry.Username, entry.Nickname, entry.Mail)
+			if err != nil {
+				if errors.Is(err, constant.ErrUsernameAlreadyUsed) {
+					log.Warnf("同步LDAP用户「%v」失败: %v", entry.Username, err)
+					continue
+				}
+				return err
+			}
+			if exist {
+				updated++
+			} else {
+				created++
+			}
+			if utils.Contains(enable, user.Username) {
+				if err := repository.UserRepository.UpdateDisabledBySyncById(c, user.ID, false); err != nil {
+					return err
+				}
+				log.Infof("LDAP用户「%v」重新出现在目录中，已重新启用", user.Username)
+			}
+			if err := UserGroupService.SaveExternalMembers(c, user.ID, constant.SourceLdap, entry.Groups); err != nil {
+				return err
+			}
+		}
+
+		for _, username := range disable {
+			user := existUsers[username]
+			if err := UserService.LogoutById(c, user.ID); err != nil {
+				return err
+			}
+			if err := repository.UserRepository.UpdateDisabledBySyncById(c, user.ID, true); err != nil {
+				return err
+			}
+			if err := repository.UserGroupMemberRepository.DeleteByUserIdAndSource(c, user.ID, constant.SourceLdap); err != nil {
+				return err
+			}
+			disabled++
+		}
+		return nil
+	})
+	return created, updated, disabled, err
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/global/cache"
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+)
+
+// loginLockService 按账号记录登录失败次数，失败次数达到阈值后锁定账号，对所有来源IP都生效
+type loginLockService struct {
+}
+
+func (service loginLockService) config() (maxCount int, duration time.Duration) {
+	propertiesMap := repository.PropertyRepository.FindAllMap(context.TODO())
+	maxCount, _ = strconv.Atoi(propertiesMap[constant.LoginLockFailedCount])
+	minutes, _ := strconv.Atoi(propertiesMap[constant.LoginLockDuration])
+	return maxCount, time.Duration(minutes) * time.Minute
+}
+
+// locked 锁定时长为0时需要管理员手动解锁
+func (service loginLockService) locked(user model.User, duration time.Duration) bool {
+	if user.LockedAt.IsZero() {
+		return false
+	}
+	return duration == 0 || time.Since(user.LockedAt.Time) < duration
+}
+
+func (service loginLockService) lockedMessage(duration time.Duration) error {
+	if duration == 0 {
+		return errors.New("登录失败次数过多，账户已被锁定，请联系管理员解锁")
+	}
+	return fmt.Errorf("登录失败次数过多，请等待%d分钟后再试", int(duration.Minutes()))
+}
+
+// Check 账号被锁定时返回错误
+func (service loginLockService) Check(username string) error {
+	maxCount, duration := service.config()
+	if maxCount <= 0 {
+		return nil
+	}
+	user, err := repository.UserRepository.FindByUsername(context.TODO(), username)
+	if err != nil {
+		// 不存在的账号记录在缓存中，避免通过锁定行为判断账号是否存在
+		if v, ok := cache.LoginFailedKeyManager.Get(username); ok && v.(int) >= maxCount {
+			return service.lockedMessage(duration)
+		}
+		return nil
+	}
+	if service.locked(user, duration) {
+		return service.lockedMessage(duration)
+	}
+	return nil
+}
+
+// Failed 记录一次登录失败，返回连续失败的次数
+func (service loginLockService) Failed(username string) int {
+	maxCount, duration := service.config()
+	if maxCount <= 0 {
+		return 0
+	}
+	user, err := repository.UserRepository.FindByUsername(context.TODO(), username)
+	if err != nil {
+		expiration := duration
+		if expiration == 0 {
+			expiration = cache.LoginLockExpiration
+		}
+		count := 1
+		if v, ok := cache.LoginFailedKeyManager.Get(username); ok {
+			count = v.(int) + 1
+		}
+		cache.LoginFailedKeyManager.Set(username, count, expiration)
+		return count
+	}
+
+	count := user.LoginFailedCount + 1
+	// 上一次锁定已过期，重新计数
+	if !user.LockedAt.IsZero() && !service.locked(user, duration) {
+		count = 1
+	}
+	lockedAt := utils.JsonTime{}
+	if count >= maxCount {
+		lockedAt = utils.NowJsonTime()
+		log.Warnf("用户「%v」登录失败次数过多，账户已被锁定", username)
+	}
+	if err := repository.UserRepository.UpdateLoginFailed(context.TODO(), user.ID, count, lockedAt); err != nil {
+		log.Errorf("记录用户「%v」登录失败次数失败: %v", username, err)
+	}
+	return count
+}
+
+// Reset 登录成功后清空失败次数
+func (service loginLockService) Reset(username string) {
+	cache.LoginFailedKeyManager.Delete(username)
+	user, err := repository.UserRepository.FindByUsername(context.TODO(), username)
+	if err != nil || (user.LoginFailedCount == 0 && user.LockedAt.IsZero()) {
+		return
+	}
+	if err := repository.UserRepository.UpdateLoginFailed(context.TODO(), user.ID, 0, utils.JsonTime{}); err != nil {
+		log.Errorf("清空用户「%v」登录失败次数失败: %v", username, err)
+	}
+}
+
+// Unlock 管理员解锁账号
+func (service loginLockService) Unlock(c context.Context, userId string) error {
+	return repository.UserRepository.UpdateLoginFailed(c, userId, 0, utils.JsonTime{})
+}
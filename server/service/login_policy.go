@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/dto"
+	"next-terminal/server/env"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+
+	"gorm.io/gorm"
+)
+
+type loginPolicyService struct {
+	baseService
+}
+
+// Check 校验用户是否可以在当前时间从指定IP登录。
+// 用户可能同时关联了多个策略，满足其中任意一个即可登录，没有关联策略时不限制
+func (service loginPolicyService) Check(ctx context.Context, user model.User, ip string) error {
+	policies, err := repository.LoginPolicyRepository.FindEnabledByUserId(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+
+	reason := constant.ErrLoginIpNotAllowed
+	for _, policy := range policies {
+		if !service.ipAllowed(policy, ip) {
+			continue
+		}
+		if service.timeAllowed(policy, time.Now()) {
+			return nil
+		}
+		reason = constant.ErrLoginTimeNotAllowed
+	}
+	return reason
+}
+
+func (service loginPolicyService) ipAllowed(policy model.LoginPolicy, ip string) bool {
+	if policy.IPs == "" {
+		return true
+	}
+	for _, rule := range strings.Split(policy.IPs, ",") {
+		if utils.IpMatch(rule, ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (service loginPolicyService) timeAllowed(policy model.LoginPolicy, now time.Time) bool {
+	if policy.TimePeriods == "" {
+		return true
+	}
+	var periods []utils.TimePeriod
+	if err := json.Unmarshal([]byte(policy.TimePeriods), &periods); err != nil {
+		return false
+	}
+	if len(periods) == 0 {
+		return true
+	}
+	if policy.TimeZone != "" {
+		location, err := time.LoadLocation(policy.TimeZone)
+		if err != nil {
+			return false
+		}
+		now = now.In(location)
+	}
+	for _, period := range periods {
+		if period.Contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (service loginPolicyService) toModel(item dto.LoginPolicy) (model.LoginPolicy, error) {
+	if strings.TrimSpace(item.Name) == "" {
+		return model.LoginPolicy{}, errors.New("请输入策略名称")
+	}
+	var ips []string
+	for _, ip := range item.IPs {
+		ip = strings.TrimSpace(ip)
+		if ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+	if item.TimeZone != "" {
+		if _, err := time.LoadLocation(item.TimeZone); err != nil {
+			return model.LoginPolicy{}, fmt.Errorf("时区格式不正确: %s", item.TimeZone)
+		}
+	}
+	var timePeriods string
+	if len(item.TimePeriods) > 0 {
+		for _, period := range item.TimePeriods {
+			if err := period.Validate(); err != nil {
+				return model.LoginPolicy{}, err
+			}
+		}
+		data, err := json.Marshal(item.TimePeriods)
+		if err != nil {
+			return model.LoginPolicy{}, err
+		}
+		timePeriods = string(data)
+	}
+	return model.LoginPolicy{
+		Name:        item.Name,
+		IPs:         strings.Join(ips, ","),
+		TimeZone:    item.TimeZone,
+		TimePeriods: timePeriods,
+		Enabled:     item.Enabled,
+	}, nil
+}
+
+func (service loginPolicyService) FindById(ctx context.Context, id string) (*dto.LoginPolicy, error) {
+	policy, err := repository.LoginPolicyRepository.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	users, err := repository.LoginPolicyMemberRepository.FindUserIdsByLoginPolicyId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	userGroups, err := repository.LoginPolicyMemberRepository.FindUserGroupIdsByLoginPolicyId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	item := dto.LoginPolicy{
+		Id:         policy.ID,
+		Name:       policy.Name,
+		TimeZone:   policy.TimeZone,
+		Enabled:    policy.Enabled,
+		Users:      users,
+		UserGroups: userGroups,
+	}
+	if policy.IPs != "" {
+		item.IPs = strings.Split(policy.IPs, ",")
+	}
+	if policy.TimePeriods != "" {
+		if err := json.Unmarshal([]byte(policy.TimePeriods), &item.TimePeriods); err != nil {
+			return nil, err
+		}
+	}
+	return &item, nil
+}
+
+func (service loginPolicyService) Create(item dto.LoginPolicy) (model.LoginPolicy, error) {
+	policy, err := service.toModel(item)
+	if err != nil {
+		return policy, err
+	}
+	policy.ID = utils.UUID()
+	policy.Created = utils.NowJsonTime()
+	err = env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		if err := repository.LoginPolicyRepository.Create(c, &policy); err != nil {
+			return err
+		}
+		return service.saveMembers(c, policy.ID, item.Users, item.UserGroups)
+	})
+	return policy, err
+}
+
+func (service loginPolicyService) Update(id string, item dto.LoginPolicy) error {
+	policy, err := service.toModel(item)
+	if err != nil {
+		return err
+	}
+	return env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		if err := repository.LoginPolicyRepository.UpdateById(c, &policy, id); err != nil {
+			return err
+		}
+		if err := repository.LoginPolicyMemberRepository.DeleteByLoginPolicyId(c, id); err != nil {
+			return err
+		}
+		return service.saveMembers(c, id, item.Users, item.UserGroups)
+	})
+}
+
+func (service loginPolicyService) saveMembers(c context.Context, loginPolicyId string, users, userGroups []string) error {
+	for _, userId := range users {
+		member := model.LoginPolicyMember{
+			ID:            utils.Sign([]string{loginPolicyId, "user", userId}),
+			LoginPolicyId: loginPolicyId,
+			UserId:        userId,
+		}
+		if err := repository.LoginPolicyMemberRepository.Create(c, &member); err != nil {
+			return err
+		}
+	}
+	for _, userGroupId := range userGroups {
+		member := model.LoginPolicyMember{
+			ID:            utils.Sign([]string{loginPolicyId, "user-group", userGroupId}),
+			LoginPolicyId: loginPolicyId,
+			UserGroupId:   userGroupId,
+		}
+		if err := repository.LoginPolicyMemberRepository.Create(c, &member); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (service loginPolicyService) DeleteById(id string) error {
+	return env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		if err := repository.LoginPolicyRepository.DeleteById(c, id); err != nil {
+			return err
+		}
+		return repository.LoginPolicyMemberRepository.DeleteByLoginPolicyId(c, id)
+	})
+}
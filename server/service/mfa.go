@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/global/cache"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/totp"
+	"next-terminal/server/utils"
+)
+
+const (
+	emailOtpResendInterval = time.Minute // 两次发送邮件验证码的最小间隔
+	emailOtpHourlyLimit    = 5           // 每小时最多发送的邮件验证码数量
+	emailOtpMaxAttempts    = 5           // 单个邮件验证码最多尝试次数
+)
+
+var mfaMethodNames = map[string]string{
+	constant.MfaTotp:     "认证器应用",
+	constant.MfaEmail:    "邮件验证码",
+	constant.MfaWebAuthn: "WebAuthn认证器",
+}
+
+type emailOtp struct {
+	mutex    sync.Mutex
+	Code     string // 验证码的 SHA256 摘要
+	Attempts int
+	Used     bool
+}
+
+type mfaService struct {
+}
+
+func checkMfaMethod(mfaMethod string) error {
+	if mfaMethod == "" {
+		return nil
+	}
+	if _, ok := mfaMethodNames[mfaMethod]; !ok {
+		return fmt.Errorf("不支持的双因素认证方式: %s", mfaMethod)
+	}
+	return nil
+}
+
+// Methods 用户可以使用的双因素认证方式，为空时无需双因素认证。
+// 用户组要求了认证方式时只能使用要求的方式，required 为 true；邮件验证码只要用户设置了邮箱即可使用，其他方式需要用户自行开启
+func (service mfaService) Methods(ctx context.Context, user model.User) (methods []string, required bool, err error) {
+	var enabled []string
+	if user.TOTPSecret != "" && user.TOTPSecret != "-" {
+		enabled = append(enabled, constant.MfaTotp)
+	}
+	if WebAuthnService.Enabled(ctx, user.ID) {
+		enabled = append(enabled, constant.MfaWebAuthn)
+	}
+	if user.EmailOtp && user.Mail != "" {
+		enabled = append(enabled, constant.MfaEmail)
+	}
+
+	requiredMethods, err := repository.UserGroupRepository.FindMfaMethodsByUserId(ctx, user.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(requiredMethods) == 0 {
+		return enabled, false, nil
+	}
+
+	var names []string
+	for _, method := range requiredMethods {
+		if utils.Contains(enabled, method) || (method == constant.MfaEmail && user.Mail != "") {
+			methods = append(methods, method)
+		}
+		names = append(names, mfaMethodNames[method])
+	}
+	if len(methods) == 0 {
+		return nil, true, fmt.Errorf("您所在的用户组要求使用%s进行双因素认证，请联系管理员", strings.Join(names, "或"))
+	}
+	return methods, true, nil
+}
+
+// Verify 校验授权码、恢复码或邮件验证码
+func (service mfaService) Verify(user model.User, methods []string, code string) bool {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return false
+	}
+	if utils.Contains(methods, constant.MfaTotp) {
+		if totp.Validate(code, user.TOTPSecret) || RecoveryCodeService.Use(user, code) {
+			return true
+		}
+	}
+	if utils.Contains(methods, constant.MfaEmail) {
+		if service.verifyEmailCode(user, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// SendEmailCode 发送邮件验证码，限制发送频率防止滥用
+func (service mfaService) SendEmailCode(user model.User) error {
+	if user.Mail == "" {
+		return errors.New("您还没有设置邮箱")
+	}
+	if _, found := cache.EmailOtpManager.Get("resend:" + user.ID); found {
+		return errors.New("验证码发送过于频繁，请稍后再试")
+	}
+	if err := cache.EmailOtpManager.Add("hourly:"+user.ID, 1, time.Hour); err != nil {
+		count, err := cache.EmailOtpManager.IncrementInt("hourly:"+user.ID, 1)
+		if err != nil || count > emailOtpHourlyLimit {
+			return errors.New("验证码发送次数过多，请稍后再试")
+		}
+	}
+	cache.EmailOtpManager.Set("resend:"+user.ID, true, emailOtpResendInterval)
+
+	code, err := genEmailCode()
+	if err != nil {
+		return err
+	}
+	cache.EmailOtpManager.Set("code:"+user.ID, &emailOtp{Code: hashToken(code)}, cache.EmailOtpExpiration)
+
+	subject := "登录验证码"
+	text := fmt.Sprintf(`您好，%s。
+	您的登录验证码为：%s，%d 分钟内有效。
+	如果这不是您本人的操作，请立即修改密码。
+`, user.Username, code, int(cache.EmailOtpExpiration.Minutes()))
+	go MailService.SendMail(user.Mail, subject, text)
+	return nil
+}
+
+// verifyEmailCode 验证码只能使用一次，错误次数过多时失效
+func (service mfaService) verifyEmailCode(user model.User, code string) bool {
+	key := "code:" + user.ID
+	v, found := cache.EmailOtpManager.Get(key)
+	if !found {
+		return false
+	}
+	otp := v.(*emailOtp)
+	// 同一个验证码可能被并发校验
+	otp.mutex.Lock()
+	defer otp.mutex.Unlock()
+	if otp.Used || otp.Attempts >= emailOtpMaxAttempts {
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(otp.Code), []byte(hashToken(code))) == 1 {
+		otp.Used = true
+		cache.EmailOtpManager.Delete(key)
+		return true
+	}
+	otp.Attempts++
+	if otp.Attempts >= emailOtpMaxAttempts {
+		cache.EmailOtpManager.Delete(key)
+	}
+	return false
+}
+
+func genEmailCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
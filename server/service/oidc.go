@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/env"
+	"next-terminal/server/global/cache"
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/oidc"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+
+	"gorm.io/gorm"
+)
+
+type oidcService struct {
+	baseService
+}
+
+// oidcState 跳转到认证服务器前保存的状态，回调时使用
+type oidcState struct {
+	Nonce        string
+	CodeVerifier string
+	RedirectUrl  string
+}
+
+func (service oidcService) Enabled() bool {
+	property, err := repository.PropertyRepository.FindByName(context.TODO(), constant.EnableOidc)
+	if err != nil {
+		return false
+	}
+	return property.Value == "true"
+}
+
+func (service oidcService) client(ctx context.Context, redirectUrl string) (*oidc.Client, error) {
+	propertiesMap := repository.PropertyRepository.FindAllMap(context.TODO())
+	value := func(name string) string {
+		// 属性值为空时会被保存为 -
+		if propertiesMap[name] == "-" {
+			return ""
+		}
+		return propertiesMap[name]
+	}
+	if value(constant.OidcRedirectUrl) != "" {
+		redirectUrl = value(constant.OidcRedirectUrl)
+	}
+	return oidc.NewClient(ctx, oidc.Config{
+		Issuer:        value(constant.OidcIssuer),
+		ClientId:      value(constant.OidcClientId),
+		ClientSecret:  value(constant.OidcClientSecret),
+		RedirectUrl:   redirectUrl,
+		Scopes:        strings.Fields(value(constant.OidcScopes)),
+		UsernameClaim: value(constant.OidcUsernameClaim),
+		NicknameClaim: value(constant.OidcNicknameClaim),
+		MailClaim:     value(constant.OidcMailClaim),
+		GroupsClaim:   value(constant.OidcGroupsClaim),
+	})
+}
+
+// AuthCodeURL 生成跳转到认证服务器的地址，redirectUrl 为未配置回调地址时使用的默认地址。
+// 返回的 state 需要保存在浏览器的 Cookie 中，回调时校验，防止登录CSRF
+func (service oidcService) AuthCodeURL(ctx context.Context, redirectUrl string) (authCodeURL, state string, err error) {
+	if !service.Enabled() {
+		return "", "", errors.New("oidc is disabled")
+	}
+	client, err := service.client(ctx, redirectUrl)
+	if err != nil {
+		return "", "", err
+	}
+	state = utils.LongUUID()
+	s := oidcState{
+		Nonce:        utils.UUID(),
+		CodeVerifier: oidc.NewCodeVerifier(),
+		RedirectUrl:  redirectUrl,
+	}
+	cache.OidcStateManager.Set(state, s, cache.OidcStateExpiration)
+	return client.AuthCodeURL(state, s.Nonce, s.CodeVerifier), state, nil
+}
+
+// Login 校验回调参数并换取用户信息，用户不存在时自动创建，同时同步用户组。browserState 为发起登录的浏览器 Cookie 中保存的 state
+func (service oidcService) Login(ctx context.Context, state, browserState, code string) (user model.User, err error) {
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return user, errors.New("oidc: state does not match the browser")
+	}
+	v, ok := cache.OidcStateManager.Get(state)
+	if !ok {
+		return user, errors.New("oidc: invalid or expired state")
+	}
+	// state 只允许使用一次
+	cache.OidcStateManager.Delete(state)
+	s := v.(oidcState)
+
+	client, err := service.client(ctx, s.RedirectUrl)
+	if err != nil {
+		return user, err
+	}
+	identity, err := client.Exchange(ctx, code, s.Nonce, s.CodeVerifier)
+	if err != nil {
+		return user, err
+	}
+
+	err = env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		user, err = service.saveUser(c, identity)
+		if err != nil {
+			return err
+		}
+		return UserGroupService.SaveExternalMembers(c, user.ID, constant.SourceOidc, identity.Groups)
+	})
+	return user, err
+}
+
+// saveUser 使用 iss 与 sub 关联本地用户，用户名只在创建用户时使用，认证服务器中修改用户名不会关联到其他用户
+func (service oidcService) saveUser(c context.Context, identity *oidc.Identity) (model.User, error) {
+	externalId := identity.ExternalId()
+	user, err := repository.UserRepository.FindBySourceAndExternalId(c, constant.SourceOidc, externalId)
+	if err == nil {
+		// 认证服务器中删除了昵称或邮箱时同样需要更新，昵称为空时与创建用户时一样使用用户名
+		user.Nickname = identity.Nickname
+		if user.Nickname == "" {
+			user.Nickname = user.Username
+		}
+		user.Mail = identity.Mail
+		return user, repository.UserRepository.UpdateProfile(c, user.ID, user.Nickname, user.Mail)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+
+	user, err = repository.UserRepository.FindByUsername(c, identity.Username)
+	if err == nil {
+		// 记录 iss 与 sub 之前创建的用户，在首次登录时关联
+		if user.Source != constant.SourceOidc || user.ExternalId != "" {
+			return user, constant.ErrUsernameAlreadyUsed
+		}
+		log.Infof("OIDC用户「%v」关联认证服务器中的用户「%v」", user.Username, externalId)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+
+	user, err = UserService.SaveExternalUser(c, constant.SourceOidc, identity.Username, identity.Nickname, identity.Mail)
+	if err != nil {
+		return user, err
+	}
+	return user, repository.UserRepository.UpdateExternalId(c, user.ID, externalId)
+}
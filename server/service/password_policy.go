@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+)
+
+type passwordPolicyService struct {
+	baseService
+}
+
+func (service passwordPolicyService) properties() map[string]string {
+	propertiesMap := repository.PropertyRepository.FindAllMap(context.TODO())
+	for name, value := range propertiesMap {
+		// 属性值为空时会被保存为 -
+		if value == "-" {
+			propertiesMap[name] = ""
+		}
+	}
+	return propertiesMap
+}
+
+func (service passwordPolicyService) Policy() utils.PasswordPolicy {
+	propertiesMap := service.properties()
+	minLength, _ := strconv.Atoi(propertiesMap[constant.PasswordMinLength])
+	var banned []string
+	for _, item := range strings.FieldsFunc(propertiesMap[constant.PasswordBannedList], func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	}) {
+		if item = strings.TrimSpace(item); item != "" {
+			banned = append(banned, item)
+		}
+	}
+	return utils.PasswordPolicy{
+		MinLength:        minLength,
+		RequireUppercase: propertiesMap[constant.PasswordRequireUppercase] == "true",
+		RequireLowercase: propertiesMap[constant.PasswordRequireLowercase] == "true",
+		RequireDigit:     propertiesMap[constant.PasswordRequireDigit] == "true",
+		RequireSpecial:   propertiesMap[constant.PasswordRequireSpecial] == "true",
+		BannedPasswords:  banned,
+	}
+}
+
+// Validate 校验新密码是否满足密码策略，并且不能与最近使用过的密码相同
+func (service passwordPolicyService) Validate(c context.Context, user model.User, password string) error {
+	if err := service.Policy().Validate(user.Username, password); err != nil {
+		return err
+	}
+	historyCount, _ := strconv.Atoi(service.properties()[constant.PasswordHistoryCount])
+	if historyCount <= 0 {
+		return nil
+	}
+	if user.Password != "" && utils.Encoder.Match([]byte(user.Password), []byte(password)) == nil {
+		return errors.New("新密码不能与当前密码相同")
+	}
+	histories, err := repository.PasswordHistoryRepository.FindLatestByUserId(c, user.ID, historyCount)
+	if err != nil {
+		return err
+	}
+	for _, history := range histories {
+		if utils.Encoder.Match([]byte(history.Password), []byte(password)) == nil {
+			return errors.New("新密码不能与最近使用过的密码相同")
+		}
+	}
+	return nil
+}
+
+// ChangePassword 校验并修改用户密码，同时记录历史密码，forceChange 为 true 时用户下次登录需要修改密码
+func (service passwordPolicyService) ChangePassword(c context.Context, user model.User, password string, forceChange bool) error {
+	if err := service.Validate(c, user, password); err != nil {
+		return err
+	}
+	passwd, err := utils.Encoder.Encode([]byte(password))
+	if err != nil {
+		return err
+	}
+	if err := repository.UserRepository.UpdatePassword(c, user.ID, string(passwd), forceChange); err != nil {
+		return err
+	}
+	return service.SaveHistory(c, user.ID, string(passwd))
+}
+
+// SaveHistory 记录历史密码，只保留策略要求的数量
+func (service passwordPolicyService) SaveHistory(c context.Context, userId, passwd string) error {
+	historyCount, _ := strconv.Atoi(service.properties()[constant.PasswordHistoryCount])
+	if historyCount <= 0 {
+		return repository.PasswordHistoryRepository.DeleteByUserId(c, userId)
+	}
+	history := &model.PasswordHistory{
+		ID:       utils.UUID(),
+		UserId:   userId,
+		Password: passwd,
+		Created:  utils.NowJsonTime(),
+	}
+	if err := repository.PasswordHistoryRepository.Create(c, history); err != nil {
+		return err
+	}
+	histories, err := repository.PasswordHistoryRepository.FindLatestByUserId(c, userId, historyCount)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, history := range histories {
+		ids = append(ids, history.ID)
+	}
+	return repository.PasswordHistoryRepository.DeleteByUserIdExcept(c, userId, ids)
+}
+
+// Expired 密码是否已过期或被要求在下次登录时修改，外部认证的用户不受密码策略限制
+func (service passwordPolicyService) Expired(user model.User) bool {
+	if user.Source != "" {
+		return false
+	}
+	if user.ForceChangePassword {
+		return true
+	}
+	maxAge, _ := strconv.Atoi(service.properties()[constant.PasswordMaxAge])
+	if maxAge <= 0 {
+		return false
+	}
+	updated := user.PasswordUpdated
+	if updated.IsZero() {
+		updated = user.Created
+	}
+	return time.Since(updated.Time) > time.Duration(maxAge)*24*time.Hour
+}
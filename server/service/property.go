@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"next-terminal/server/constant"
 	"next-terminal/server/env"
 	"next-terminal/server/guacd"
 	"next-terminal/server/model"
@@ -26,22 +27,58 @@ var deprecatedPropertyNames = []string{
 }
 
 var defaultProperties = map[string]string{
-	guacd.EnableRecording:          "true",
-	guacd.FontName:                 "menlo",
-	guacd.FontSize:                 "12",
-	guacd.ColorScheme:              "gray-black",
-	guacd.EnableWallpaper:          "true",
-	guacd.EnableTheming:            "true",
-	guacd.EnableFontSmoothing:      "true",
-	guacd.EnableFullWindowDrag:     "true",
-	guacd.EnableDesktopComposition: "true",
-	guacd.EnableMenuAnimations:     "true",
-	guacd.DisableBitmapCaching:     "false",
-	guacd.DisableOffscreenCaching:  "false",
-	"cron-log-saved-limit":         "360",
-	"login-log-saved-limit":        "360",
-	"session-saved-limit":          "360",
-	"user-default-storage-size":    "5120",
+	guacd.EnableRecording:               "true",
+	guacd.FontName:                      "menlo",
+	guacd.FontSize:                      "12",
+	guacd.ColorScheme:                   "gray-black",
+	guacd.EnableWallpaper:               "true",
+	guacd.EnableTheming:                 "true",
+	guacd.EnableFontSmoothing:           "true",
+	guacd.EnableFullWindowDrag:          "true",
+	guacd.EnableDesktopComposition:      "true",
+	guacd.EnableMenuAnimations:          "true",
+	guacd.DisableBitmapCaching:          "false",
+	guacd.DisableOffscreenCaching:       "false",
+	"cron-log-saved-limit":              "360",
+	"login-log-saved-limit":             "360",
+	"session-saved-limit":               "360",
+	"user-default-storage-size":         "5120",
+	constant.EnableLdap:                 "false",
+	constant.LdapUserFilter:             "(objectClass=person)",
+	constant.LdapUserAttribute:          "uid",
+	constant.LdapNicknameAttribute:      "cn",
+	constant.LdapMailAttribute:          "mail",
+	constant.LdapGroupAttribute:         "memberOf",
+	constant.LdapSkipVerify:             "false",
+	constant.EnableOidc:                 "false",
+	constant.OidcScopes:                 "openid profile email",
+	constant.OidcUsernameClaim:          "preferred_username",
+	constant.OidcNicknameClaim:          "name",
+	constant.OidcMailClaim:              "email",
+	constant.OidcGroupsClaim:            "groups",
+	constant.EnableSaml:                 "false",
+	constant.SsoTrustIdpMfa:             "false",
+	constant.PasswordMinLength:          "6",
+	constant.PasswordRequireUppercase:   "false",
+	constant.PasswordRequireLowercase:   "false",
+	constant.PasswordRequireDigit:       "false",
+	constant.PasswordRequireSpecial:     "false",
+	constant.PasswordHistoryCount:       "0",
+	constant.PasswordMaxAge:             "0",
+	constant.LoginLockFailedCount:       "5",
+	constant.LoginLockDuration:          "5",
+	constant.LoginMaxConcurrent:         "0",
+	constant.LoginConcurrentPolicy:      constant.LoginConcurrentEvict,
+	constant.SessionMaxPerUser:          "0",
+	constant.SessionMaxPerAsset:         "0",
+	constant.ResourceSharerExpiryNotice: "24",
+	constant.AccessRequestApproverGroup: "",
+	constant.AccessRequestMaxDuration:   "1440",
+
+	constant.CredentialCheckoutApproval:    "false",
+	constant.CredentialCheckoutMaxDuration: "60",
+
+	constant.SshCertificateValidity: "5",
 }
 
 func (service propertyService) InitProperties() error {
@@ -84,6 +121,10 @@ func (service propertyService) Update(item map[string]interface{}) error {
 	return env.GetDB().Transaction(func(tx *gorm.DB) error {
 		c := service.Context(tx)
 		for key := range item {
+			// SSH CA 私钥由服务端生成，不允许通过系统设置修改
+			if key == constant.SshCAPrivateKey {
+				continue
+			}
 			value := fmt.Sprintf("%v", item[key])
 			if value == "" {
 				value = "-"
@@ -94,7 +135,7 @@ func (service propertyService) Update(item map[string]interface{}) error {
 				Value: value,
 			}
 
-			if key == "enable-ldap" && value == "false" {
+			if key == constant.EnableLdap && value == "false" {
 				if err := UserService.DeleteALlLdapUser(c); err != nil {
 					return err
 				}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"next-terminal/server/env"
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789" // 去掉了容易混淆的字符
+)
+
+type recoveryCodeService struct {
+	baseService
+}
+
+// Generate 重新生成恢复码，旧的恢复码全部失效，明文只在生成时返回一次
+func (service recoveryCodeService) Generate(userId string) (codes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := genRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	err = env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		if err := repository.RecoveryCodeRepository.DeleteByUserId(c, userId); err != nil {
+			return err
+		}
+		for _, code := range codes {
+			recoveryCode := model.RecoveryCode{
+				ID:      utils.UUID(),
+				UserId:  userId,
+				Code:    hashToken(code),
+				Created: utils.NowJsonTime(),
+			}
+			if err := repository.RecoveryCodeRepository.Create(c, &recoveryCode); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return codes, err
+}
+
+// Use 校验并消耗一个恢复码，使用成功后通过邮件通知用户
+func (service recoveryCodeService) Use(user model.User, code string) bool {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == "" {
+		return false
+	}
+	used, err := repository.RecoveryCodeRepository.DeleteByUserIdAndCode(context.TODO(), user.ID, hashToken(code))
+	if err != nil {
+		log.Errorf("校验恢复码失败: %v", err)
+		return false
+	}
+	if !used {
+		return false
+	}
+
+	remaining, _ := repository.RecoveryCodeRepository.CountByUserId(context.TODO(), user.ID)
+	log.Infof("用户「%v」使用了恢复码，剩余 %v 个", user.Username, remaining)
+	if user.Mail != "" {
+		subject := "恢复码使用通知"
+		text := fmt.Sprintf(`您好，%s。
+	您的账户刚刚使用恢复码通过了双因素认证，剩余可用恢复码 %d 个。
+	如果这不是您本人的操作，请立即修改密码并联系管理员。
+`, user.Username, remaining)
+		go MailService.SendMail(user.Mail, subject, text)
+	}
+	return true
+}
+
+func (service recoveryCodeService) Count(userId string) (int64, error) {
+	return repository.RecoveryCodeRepository.CountByUserId(context.TODO(), userId)
+}
+
+// genRecoveryCode 生成 xxxxx-xxxxx 格式的恢复码
+func genRecoveryCode() (string, error) {
+	var sb strings.Builder
+	size := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			sb.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/dto"
+	"next-terminal/server/env"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+
+	"gorm.io/gorm"
+)
+
+type roleService struct {
+	baseService
+}
+
+// FindPermissionsByUserId 汇总用户通过角色获得的权限
+func (service roleService) FindPermissionsByUserId(c context.Context, userId string) ([]string, error) {
+	roles, err := repository.RoleRepository.FindByUserId(c, userId)
+	if err != nil {
+		return nil, err
+	}
+	var permissions []string
+	exist := make(map[string]bool)
+	for _, role := range roles {
+		for _, permission := range strings.Split(role.Permissions, ",") {
+			if permission == "" || exist[permission] {
+				continue
+			}
+			exist[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
+
+// HasPermission 管理员拥有全部权限
+func (service roleService) HasPermission(c context.Context, account *model.User, permission string) bool {
+	if account.Type == constant.TypeAdmin {
+		return true
+	}
+	permissions, err := service.FindPermissionsByUserId(c, account.ID)
+	if err != nil {
+		return false
+	}
+	return utils.Contains(permissions, permission)
+}
+
+// checkPermissions 校验权限是否合法，非管理员只能分配自己拥有的权限，防止越权
+func (service roleService) checkPermissions(c context.Context, account *model.User, permissions []string) error {
+	for _, permission := range permissions {
+		if !utils.Contains(constant.Permissions, permission) {
+			return fmt.Errorf("未知的权限: %s", permission)
+		}
+		if !service.HasPermission(c, account, permission) {
+			return fmt.Errorf("您没有权限分配: %s", permission)
+		}
+	}
+	return nil
+}
+
+// containsPermissions 非管理员只能操作权限不超过自己的用户及用户组
+func (service roleService) containsPermissions(c context.Context, account *model.User, permissions []string) (bool, error) {
+	if account.Type == constant.TypeAdmin {
+		return true, nil
+	}
+	owned, err := service.FindPermissionsByUserId(c, account.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, permission := range permissions {
+		if !utils.Contains(owned, permission) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// CheckUser 只有管理员可以操作管理员账户，非管理员不能操作拥有自己所没有的权限的用户
+func (service roleService) CheckUser(c context.Context, account *model.User, userId string) error {
+	if account.Type == constant.TypeAdmin {
+		return nil
+	}
+	user, err := repository.UserRepository.FindById(c, userId)
+	if err != nil {
+		return err
+	}
+	if user.Type == constant.TypeAdmin {
+		return errors.New("您没有权限操作管理员账户")
+	}
+	permissions, err := service.FindPermissionsByUserId(c, userId)
+	if err != nil {
+		return err
+	}
+	ok, err := service.containsPermissions(c, account, permissions)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("您没有权限操作拥有更多权限的用户")
+	}
+	return nil
+}
+
+// CheckUserGroup 用户组的成员会获得分配给用户组的角色权限，非管理员不能修改拥有自己所没有的权限的用户组
+func (service roleService) CheckUserGroup(c context.Context, account *model.User, userGroupId string) error {
+	roles, err := repository.RoleRepository.FindByUserGroupId(c, userGroupId)
+	if err != nil {
+		return err
+	}
+	var permissions []string
+	for _, role := range roles {
+		if role.Permissions != "" {
+			permissions = append(permissions, strings.Split(role.Permissions, ",")...)
+		}
+	}
+	ok, err := service.containsPermissions(c, account, permissions)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("您没有权限修改拥有更多权限的用户组")
+	}
+	return nil
+}
+
+func (service roleService) Create(account *model.User, item dto.Role) (model.Role, error) {
+	role := model.Role{
+		ID:          utils.UUID(),
+		Name:        item.Name,
+		Permissions: strings.Join(item.Permissions, ","),
+		Created:     utils.NowJsonTime(),
+	}
+	err := env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		if err := service.checkPermissions(c, account, item.Permissions); err != nil {
+			return err
+		}
+		exist, err := repository.RoleRepository.ExistByName(c, item.Name)
+		if err != nil {
+			return err
+		}
+		if exist {
+			return constant.ErrNameAlreadyUsed
+		}
+		if err := repository.RoleRepository.Create(c, &role); err != nil {
+			return err
+		}
+		return service.saveMembers(c, role.ID, item.Users, item.UserGroups)
+	})
+	return role, err
+}
+
+func (service roleService) Update(account *model.User, id string, item dto.Role) error {
+	return env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		dbRole, err := repository.RoleRepository.FindById(c, id)
+		if err != nil {
+			return err
+		}
+		// 原有的权限同样需要校验，防止非管理员修改拥有更多权限的角色
+		if dbRole.Permissions != "" {
+			if err := service.checkPermissions(c, account, strings.Split(dbRole.Permissions, ",")); err != nil {
+				return errors.New("您没有权限修改该角色")
+			}
+		}
+		if err := service.checkPermissions(c, account, item.Permissions); err != nil {
+			return err
+		}
+		if dbRole.Name != item.Name {
+			exist, err := repository.RoleRepository.ExistByName(c, item.Name)
+			if err != nil {
+				return err
+			}
+			if exist {
+				return constant.ErrNameAlreadyUsed
+			}
+		}
+		// 使用 Select 更新，允许清空权限
+		if err := tx.Model(&model.Role{}).Where("id = ?", id).Select("name", "permissions").Updates(&model.Role{
+			Name:        item.Name,
+			Permissions: strings.Join(item.Permissions, ","),
+		}).Error; err != nil {
+			return err
+		}
+		if err := repository.RoleMemberRepository.DeleteByRoleId(c, id); err != nil {
+			return err
+		}
+		return service.saveMembers(c, id, item.Users, item.UserGroups)
+	})
+}
+
+func (service roleService) saveMembers(c context.Context, roleId string, users, userGroups []string) error {
+	for _, userId := range users {
+		member := model.RoleMember{
+			ID:     utils.Sign([]string{roleId, "user", userId}),
+			RoleId: roleId,
+			UserId: userId,
+		}
+		if err := repository.RoleMemberRepository.Create(c, &member); err != nil {
+			return err
+		}
+	}
+	for _, userGroupId := range userGroups {
+		member := model.RoleMember{
+			ID:          utils.Sign([]string{roleId, "user-group", userGroupId}),
+			RoleId:      roleId,
+			UserGroupId: userGroupId,
+		}
+		if err := repository.RoleMemberRepository.Create(c, &member); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (service roleService) DeleteById(account *model.User, id string) error {
+	return env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		role, err := repository.RoleRepository.FindById(c, id)
+		if err != nil {
+			return err
+		}
+		if role.Permissions != "" {
+			if err := service.checkPermissions(c, account, strings.Split(role.Permissions, ",")); err != nil {
+				return errors.New("您没有权限删除该角色")
+			}
+		}
+		if err := repository.RoleRepository.DeleteById(c, id); err != nil {
+			return err
+		}
+		return repository.RoleMemberRepository.DeleteByRoleId(c, id)
+	})
+}
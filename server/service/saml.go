@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/env"
+	"next-terminal/server/global/cache"
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/saml"
+	"next-terminal/server/utils"
+
+	"gorm.io/gorm"
+)
+
+type samlService struct {
+	baseService
+}
+
+func (service samlService) Enabled() bool {
+	property, err := repository.PropertyRepository.FindByName(context.TODO(), constant.EnableSaml)
+	if err != nil {
+		return false
+	}
+	return property.Value == "true"
+}
+
+// serviceProvider rootUrl 为未配置对外访问地址时使用的默认地址
+func (service samlService) serviceProvider(ctx context.Context, rootUrl string) (*saml.ServiceProvider, error) {
+	if !service.Enabled() {
+		return nil, errors.New("saml is disabled")
+	}
+	if err := service.initKeyPair(); err != nil {
+		return nil, err
+	}
+	propertiesMap := repository.PropertyRepository.FindAllMap(context.TODO())
+	value := func(name string) string {
+		// 属性值为空时会被保存为 -
+		if propertiesMap[name] == "-" {
+			return ""
+		}
+		return propertiesMap[name]
+	}
+	if value(constant.SamlRootUrl) != "" {
+		rootUrl = value(constant.SamlRootUrl)
+	}
+	return saml.NewServiceProvider(ctx, saml.Config{
+		EntityId:          value(constant.SamlEntityId),
+		RootUrl:           rootUrl,
+		IdpMetadata:       value(constant.SamlIdpMetadata),
+		Key:               value(constant.SamlSpKey),
+		Certificate:       value(constant.SamlSpCert),
+		UsernameAttribute: value(constant.SamlUsernameAttribute),
+		NicknameAttribute: value(constant.SamlNicknameAttribute),
+		MailAttribute:     value(constant.SamlMailAttribute),
+		GroupsAttribute:   value(constant.SamlGroupsAttribute),
+	})
+}
+
+// initKeyPair 未配置签名证书时自动生成并保存
+func (service samlService) initKeyPair() error {
+	propertiesMap := repository.PropertyRepository.FindAllMap(context.TODO())
+	if key := propertiesMap[constant.SamlSpKey]; key != "" && key != "-" {
+		return nil
+	}
+	keyPEM, certPEM, err := saml.GenerateKeyPair(constant.AppName)
+	if err != nil {
+		return err
+	}
+	log.Infof("自动生成SAML签名证书")
+	return PropertyService.Update(map[string]interface{}{
+		constant.SamlSpKey:  keyPEM,
+		constant.SamlSpCert: certPEM,
+	})
+}
+
+func (service samlService) Metadata(ctx context.Context, rootUrl string) ([]byte, error) {
+	sp, err := service.serviceProvider(ctx, rootUrl)
+	if err != nil {
+		return nil, err
+	}
+	return sp.Metadata()
+}
+
+// AuthnRequestURL 生成跳转到认证服务器的地址，并记录请求ID用于校验断言
+func (service samlService) AuthnRequestURL(ctx context.Context, rootUrl string) (string, error) {
+	sp, err := service.serviceProvider(ctx, rootUrl)
+	if err != nil {
+		return "", err
+	}
+	relayState := utils.LongUUID()
+	redirectUrl, requestId, err := sp.AuthnRequestURL(relayState)
+	if err != nil {
+		return "", err
+	}
+	cache.SamlRequestManager.Set(relayState, requestId, cache.SamlRequestExpiration)
+	return redirectUrl, nil
+}
+
+// Login 校验断言，用户不存在时自动创建，同时同步用户组
+func (service samlService) Login(ctx context.Context, rootUrl string, r *http.Request) (user model.User, err error) {
+	sp, err := service.serviceProvider(ctx, rootUrl)
+	if err != nil {
+		return user, err
+	}
+	relayState := r.FormValue("RelayState")
+	v, ok := cache.SamlRequestManager.Get(relayState)
+	if !ok {
+		return user, errors.New("saml: invalid or expired relay state")
+	}
+	// 请求ID只允许使用一次
+	cache.SamlRequestManager.Delete(relayState)
+
+	identity, err := sp.ParseResponse(r, []string{v.(string)})
+	if err != nil {
+		return user, err
+	}
+
+	err = env.GetDB().Transaction(func(tx *gorm.DB) error {
+		c := service.Context(tx)
+		user, err = UserService.SaveExternalUser(c, constant.SourceSaml, identity.Username, identity.Nickname, identity.Mail)
+		if err != nil {
+			return err
+		}
+		// 配置了账号属性时 NameID 与账号不同，单点登出时需要通过 NameID 找到用户
+		if err := repository.UserRepository.UpdateExternalId(c, user.ID, identity.NameId); err != nil {
+			return err
+		}
+		return UserGroupService.SaveExternalMembers(c, user.ID, constant.SourceSaml, identity.Groups)
+	})
+	return user, err
+}
+
+// Logout 处理认证服务器发起的单点登出请求，将 NameID 对应用户的全部登录令牌下线，返回回复认证服务器的地址
+func (service samlService) Logout(ctx context.Context, rootUrl string, r *http.Request) (string, error) {
+	sp, err := service.serviceProvider(ctx, rootUrl)
+	if err != nil {
+		return "", err
+	}
+	if r.FormValue("SAMLResponse") != "" {
+		// 本服务发起登出后认证服务器的回复
+		if err := sp.ValidateLogoutResponse(r); err != nil {
+			return "", err
+		}
+		return "/", nil
+	}
+
+	request, err := sp.ParseLogoutRequest(r)
+	if err != nil {
+		return "", err
+	}
+	user, err := repository.UserRepository.FindBySourceAndExternalId(ctx, constant.SourceSaml, request.NameID.Value)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 兼容记录 NameID 之前登录的用户
+		user, err = repository.UserRepository.FindByUsername(ctx, request.NameID.Value)
+	}
+	if err == nil && user.Source == constant.SourceSaml {
+		if err := UserService.LogoutById(ctx, user.ID); err != nil {
+			return "", err
+		}
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	return sp.LogoutResponseURL(request.ID, r.FormValue("RelayState"))
+}
@@ -3,9 +3,11 @@ package service
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"strconv"
 	"sync"
+	"time"
 
 	"next-terminal/server/config"
 	"next-terminal/server/constant"
@@ -170,6 +172,79 @@ func (service sessionService) DisDBSess(sessionId string, code int, reason strin
 	})
 }
 
+// MonitorAllowed 授权策略禁止监控时不允许监控会话，需要双人复核的会话除外
+func (service sessionService) MonitorAllowed(s model.Session) bool {
+	if s.Monitor != "0" {
+		return true
+	}
+	nextSession := session.GlobalSessionManager.GetById(s.ID)
+	return nextSession != nil && nextSession.Supervisor != nil
+}
+
+// SupervisorJoin 观察者加入开启了双人复核的会话时登记为监控人，只有可以审批该资产的用户才能作为监控人，会话的创建者不能监控自己的会话
+func (service sessionService) SupervisorJoin(sessionId, observerId string, user *model.User) {
+	nextSession := session.GlobalSessionManager.GetById(sessionId)
+	if nextSession == nil || nextSession.Supervisor == nil {
+		return
+	}
+	s, err := repository.SessionRepository.FindById(context.TODO(), sessionId)
+	if err != nil {
+		return
+	}
+	if ok, err := service.canSupervise(user, s); err != nil || !ok {
+		return
+	}
+	records := nextSession.Supervisor.Join(observerId, user.ID, user.Username)
+	log.Debugf("[%v] 监控人 %v 加入会话", sessionId, user.Username)
+	service.saveSupervisors(sessionId, records)
+}
+
+// canSupervise 管理员、资产所有者及审批用户组的成员可以作为监控人，与访问申请的审批人保持一致
+func (service sessionService) canSupervise(user *model.User, s model.Session) (bool, error) {
+	if s.Creator == user.ID {
+		return false, nil
+	}
+	if constant.TypeAdmin == user.Type {
+		return true, nil
+	}
+	asset, err := repository.AssetRepository.FindById(context.TODO(), s.AssetId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	if asset.Owner == user.ID {
+		return true, nil
+	}
+	return AccessRequestService.IsApproverGroupMember(user.ID)
+}
+
+// SupervisorLeave 监控人退出会话，返回是否需要断开会话
+func (service sessionService) SupervisorLeave(sessionId, observerId string) (terminate bool) {
+	nextSession := session.GlobalSessionManager.GetById(sessionId)
+	if nextSession == nil || nextSession.Supervisor == nil {
+		return false
+	}
+	records, present := nextSession.Supervisor.Leave(observerId)
+	if records == nil {
+		return false
+	}
+	service.saveSupervisors(sessionId, records)
+	if present {
+		return false
+	}
+	log.Debugf("[%v] 监控人已全部退出会话，处理方式：%v", sessionId, nextSession.Supervisor.LeaveAction)
+	return nextSession.Supervisor.LeaveAction == constant.DualControlTerminate
+}
+
+func (service sessionService) saveSupervisors(sessionId string, records []model.SessionSupervisor) {
+	supervisors, err := json.Marshal(records)
+	if err != nil {
+		return
+	}
+	if err := repository.SessionRepository.UpdateSupervisorsById(context.TODO(), string(supervisors), sessionId); err != nil {
+		log.Warnf("[%v] 保存会话监控人失败: %v", sessionId, err)
+	}
+}
+
 func (service sessionService) FindByIdAndDecrypt(c context.Context, id string) (o model.Session, err error) {
 	sess, err := repository.SessionRepository.FindById(c, id)
 	if err != nil {
@@ -218,7 +293,8 @@ func (service sessionService) Decrypt(item *model.Session) error {
 	return nil
 }
 
-func (service sessionService) Create(clientIp, assetId, mode string, user *model.User) (*model.Session, error) {
+// Create 创建会话，accountId 为空时使用资产自身配置的账号
+func (service sessionService) Create(clientIp, assetId, accountId, mode string, user *model.User) (*model.Session, error) {
 	asset, err := repository.AssetRepository.FindById(context.TODO(), assetId)
 	if err != nil {
 		return nil, err
@@ -233,6 +309,11 @@ func (service sessionService) Create(clientIp, assetId, mode string, user *model
 		fileSystem = "1"
 		_copy      = "1"
 		paste      = "1"
+
+		maxDuration    = 0
+		idleTimeout    = 0
+		forceRecording = "0"
+		monitor        = "1"
 	)
 
 	if asset.Owner != user.ID && constant.TypeUser == user.Type {
@@ -244,7 +325,28 @@ func (service sessionService) Create(clientIp, assetId, mode string, user *model
 		if len(resourceSharers) == 0 {
 			return nil, errors.New("您没有权限访问此资产")
 		}
-		strategyId := resourceSharers[0].StrategyId
+		// 只使用处于有效期内的授权
+		var validSharers []model.ResourceSharer
+		for i := range resourceSharers {
+			if resourceSharers[i].Valid(time.Now()) {
+				validSharers = append(validSharers, resourceSharers[i])
+			}
+		}
+		if len(validSharers) == 0 {
+			return nil, errors.New("您对此资产的授权尚未生效或已过期")
+		}
+		// 授权限制了可以使用的账号时，使用允许该账号的授权的策略
+		var resourceSharer *model.ResourceSharer
+		for i := range validSharers {
+			if validSharers[i].AllowAccount(accountIdOrDefault(accountId)) {
+				resourceSharer = &validSharers[i]
+				break
+			}
+		}
+		if resourceSharer == nil {
+			return nil, constant.ErrAccountNotAllowed
+		}
+		strategyId := resourceSharer.StrategyId
 		if strategyId != "" {
 			strategy, err := repository.StrategyRepository.FindById(context.TODO(), strategyId)
 			if err != nil {
@@ -259,10 +361,32 @@ func (service sessionService) Create(clientIp, assetId, mode string, user *model
 				edit = strategy.Edit
 				_copy = strategy.Copy
 				paste = strategy.Paste
+				if strategy.Clipboard != "" {
+					_copy, paste = "0", "0"
+					if strategy.Clipboard == constant.ClipboardOut || strategy.Clipboard == constant.ClipboardBoth {
+						_copy = "1"
+					}
+					if strategy.Clipboard == constant.ClipboardIn || strategy.Clipboard == constant.ClipboardBoth {
+						paste = "1"
+					}
+				}
+				maxDuration = strategy.MaxDuration
+				idleTimeout = strategy.IdleTimeout
+				forceRecording = strategy.ForceRecording
+				monitor = strategy.Monitor
 			}
 		}
 	}
 
+	// 同时连接的会话数限制，匿名访问只受资产的限制
+	creator := user.ID
+	if constant.Anonymous == user.Type {
+		creator = ""
+	}
+	if err := ConcurrentLimitService.CheckSession(creator, assetId); err != nil {
+		return nil, err
+	}
+
 	var storageId = ""
 	if constant.RDP == asset.Protocol {
 		attr, err := repository.AssetRepository.FindAssetAttrMapByAssetId(context.TODO(), assetId)
@@ -303,14 +427,26 @@ func (service sessionService) Create(clientIp, assetId, mode string, user *model
 	if paste != "1" {
 		paste = "0"
 	}
+	if forceRecording != "1" {
+		forceRecording = "0"
+	}
+	if monitor != "0" {
+		monitor = "1"
+	}
+
+	account, err := AssetAccountService.FindAccount(context.TODO(), asset, accountId)
+	if err != nil {
+		return nil, err
+	}
 
 	s := &model.Session{
 		ID:              utils.UUID(),
 		AssetId:         asset.ID,
-		Username:        asset.Username,
-		Password:        asset.Password,
-		PrivateKey:      asset.PrivateKey,
-		Passphrase:      asset.Passphrase,
+		AccountId:       account.ID,
+		Username:        account.Username,
+		Password:        account.Password,
+		PrivateKey:      account.PrivateKey,
+		Passphrase:      account.Passphrase,
 		Protocol:        asset.Protocol,
 		IP:              asset.IP,
 		Port:            asset.Port,
@@ -325,6 +461,10 @@ func (service sessionService) Create(clientIp, assetId, mode string, user *model
 		Edit:            edit,
 		Copy:            _copy,
 		Paste:           paste,
+		MaxDuration:     maxDuration,
+		IdleTimeout:     idleTimeout,
+		ForceRecording:  forceRecording,
+		Monitor:         monitor,
 		StorageId:       storageId,
 		AccessGatewayId: asset.AccessGatewayId,
 		Reviewed:        false,
@@ -333,8 +473,8 @@ func (service sessionService) Create(clientIp, assetId, mode string, user *model
 		s.Creator = user.ID
 	}
 
-	if asset.AccountType == "credential" {
-		credential, err := repository.CredentialRepository.FindById(context.TODO(), asset.CredentialId)
+	if account.AccountType == constant.AccountCredential {
+		credential, err := repository.CredentialRepository.FindById(context.TODO(), account.CredentialId)
 		if err != nil {
 			return nil, err
 		}
@@ -349,6 +489,24 @@ func (service sessionService) Create(clientIp, assetId, mode string, user *model
 		}
 	}
 
+	if account.AccountType == constant.AccountCertificate {
+		// 每个会话签发独立的证书，证书只用于建立连接，有效期较短且不超过会话的最长时长
+		if constant.SSH != asset.Protocol || constant.Guacd == mode {
+			return nil, errors.New("使用SSH证书登录的账号只支持原生SSH模式")
+		}
+		privateKey, err := SshCAService.IssueCertificate(context.TODO(), account.Username, certificateKeyId(user.Username, s.ID), maxDuration)
+		if err != nil {
+			return nil, err
+		}
+		encryptedCBC, err := utils.AesEncryptCBC([]byte(privateKey), config.GlobalCfg.EncryptionPassword)
+		if err != nil {
+			return nil, err
+		}
+		s.PrivateKey = base64.StdEncoding.EncodeToString(encryptedCBC)
+		s.Password = "-"
+		s.Passphrase = "-"
+	}
+
 	if err := repository.SessionRepository.Create(context.TODO(), s); err != nil {
 		return nil, err
 	}
@@ -358,3 +516,10 @@ func (service sessionService) Create(clientIp, assetId, mode string, user *model
 func (service sessionService) FixSshMode() error {
 	return repository.SessionRepository.UpdateMode(context.TODO())
 }
+
+func accountIdOrDefault(accountId string) string {
+	if accountId == "" {
+		return constant.DefaultAccount
+	}
+	return accountId
+}
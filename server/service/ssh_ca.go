@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"next-terminal/server/config"
+	"next-terminal/server/constant"
+	"next-terminal/server/log"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+const (
+	// caComment SSH CA 公钥使用的注释
+	caComment = "next-terminal-ca"
+	// defaultCertificateValidity 证书的默认有效期（分钟），只需覆盖建立连接的时间
+	defaultCertificateValidity = 5
+)
+
+// 防止并发生成多个 CA 私钥
+var sshCAMutex sync.Mutex
+
+type sshCAService struct {
+}
+
+// signer 读取 CA 私钥，不存在时生成 ed25519 私钥并加密保存
+func (s sshCAService) signer(c context.Context) (ssh.Signer, error) {
+	sshCAMutex.Lock()
+	defer sshCAMutex.Unlock()
+
+	property, err := repository.PropertyRepository.FindByName(c, constant.SshCAPrivateKey)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	exist := err == nil
+	if exist && property.Value != "" && property.Value != "-" {
+		origData, err := base64.StdEncoding.DecodeString(property.Value)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := utils.AesDecryptCBC(origData, config.GlobalCfg.EncryptionPassword)
+		if err != nil {
+			return nil, err
+		}
+		return ssh.ParsePrivateKey(privateKey)
+	}
+
+	privateKey, _, err := utils.GenerateSshKey(utils.SshKeyEd25519, 0, "", caComment)
+	if err != nil {
+		return nil, err
+	}
+	encryptedCBC, err := utils.AesEncryptCBC([]byte(privateKey), config.GlobalCfg.EncryptionPassword)
+	if err != nil {
+		return nil, err
+	}
+	item := model.Property{
+		Name:  constant.SshCAPrivateKey,
+		Value: base64.StdEncoding.EncodeToString(encryptedCBC),
+	}
+	if exist {
+		err = repository.PropertyRepository.UpdateByName(c, &item, item.Name)
+	} else {
+		err = repository.PropertyRepository.Create(c, &item)
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("已生成 SSH CA 私钥")
+	return ssh.ParsePrivateKey([]byte(privateKey))
+}
+
+// PublicKey 返回 CA 公钥，用于配置目标资产 sshd 的 TrustedUserCAKeys
+func (s sshCAService) PublicKey(c context.Context) (string, error) {
+	ca, err := s.signer(c)
+	if err != nil {
+		return "", err
+	}
+	return utils.AuthorizedKeyLine(ca.PublicKey(), caComment), nil
+}
+
+// IssueCertificate 生成临时私钥并签发证书，返回附带证书的私钥。
+// 证书只用于建立连接，有效期使用系统配置的较短时长，maxDuration 为会话的最长时长（分钟），不为0时作为有效期的上限
+func (s sshCAService) IssueCertificate(c context.Context, principal, keyId string, maxDuration int) (string, error) {
+	if principal == "" || principal == "-" {
+		return "", errors.New("资产账号未设置登录账号，无法签发SSH证书")
+	}
+	propertiesMap := repository.PropertyRepository.FindAllMap(c)
+	validity, _ := strconv.Atoi(propertiesMap[constant.SshCertificateValidity])
+	if validity <= 0 {
+		validity = defaultCertificateValidity
+	}
+	if maxDuration > 0 && maxDuration < validity {
+		validity = maxDuration
+	}
+
+	ca, err := s.signer(c)
+	if err != nil {
+		return "", err
+	}
+	privateKey, _, err := utils.GenerateSshKey(utils.SshKeyEd25519, 0, "", "")
+	if err != nil {
+		return "", err
+	}
+	key, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return "", err
+	}
+
+	// 允许目标资产与堡垒机之间存在少量的时间误差
+	now := time.Now()
+	cert, err := utils.SignUserCertificate(ca, key.PublicKey(), keyId, []string{principal}, now.Add(-5*time.Minute), now.Add(time.Duration(validity)*time.Minute))
+	if err != nil {
+		return "", err
+	}
+	log.Debugf("签发 SSH 证书「%v」principal「%v」有效期「%v」分钟", keyId, principal, validity)
+	return utils.BundleCertificate(privateKey, cert), nil
+}
+
+// certificateKeyId 证书的 KeyId 会记录在目标资产的认证日志中，用于追溯到堡垒机的用户及会话
+func certificateKeyId(username, sessionId string) string {
+	return fmt.Sprintf("next-terminal:%v:%v", username, sessionId)
+}
@@ -68,6 +68,29 @@ func (service userService) InitUser() (err error) {
 	return nil
 }
 
+// Authenticate 校验账号密码，来源为LDAP的用户及本地不存在的用户在开启LDAP认证后到目录服务器校验
+func (service userService) Authenticate(username, password string) (model.User, error) {
+	user, err := repository.UserRepository.FindByUsername(context.TODO(), username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) && LdapService.Enabled() {
+			return LdapService.Authenticate(username, password)
+		}
+		return user, err
+	}
+
+	if user.Source == constant.SourceLdap {
+		if !LdapService.Enabled() {
+			return user, errors.New("ldap authentication is disabled")
+		}
+		return LdapService.Authenticate(username, password)
+	}
+
+	if err := utils.Encoder.Match([]byte(user.Password), []byte(password)); err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
 func (service userService) FixUserOnlineState() error {
 	// 修正用户登录状态
 	onlineUsers, err := repository.UserRepository.FindOnlineUsers(context.TODO())
@@ -132,6 +155,7 @@ func (service userService) LogoutById(c context.Context, id string) error {
 		token := loginLogs[j].ID
 		service.Logout(token)
 	}
+	AccessTokenService.EvictByUserId(id)
 	return nil
 }
 
@@ -152,8 +176,8 @@ func (service userService) GetUserLoginToken(c context.Context, username string)
 
 func (service userService) OnEvicted(token string, value interface{}) {
 
-	if strings.HasPrefix(token, "forever") {
-		log.Debugf("re gen forever token")
+	if authorization, ok := value.(dto.Authorization); ok && authorization.Type == constant.AccessToken {
+		log.Debugf("授权令牌「%v」已从缓存中移除", authorization.User.Username)
 	} else {
 		log.Debugf("用户Token「%v」过期", token)
 		err := service.LogoutByToken(token)
@@ -170,12 +194,7 @@ func (service userService) UpdateStatusById(id string, status string) error {
 			return err
 		}
 	}
-	u := model.User{
-		ID:     id,
-		Status: status,
-	}
-	return repository.UserRepository.Update(context.TODO(), &u)
-
+	return repository.UserRepository.UpdateStatusById(context.TODO(), id, status)
 }
 
 func (service userService) ReloadToken() error {
@@ -224,6 +243,9 @@ func (service userService) CreateUser(user model.User) (err error) {
 			return fmt.Errorf("username %s is already used", user.Username)
 		}
 		password := user.Password
+		if err := PasswordPolicyService.Policy().Validate(user.Username, password); err != nil {
+			return err
+		}
 
 		var pass []byte
 		if pass, err = utils.Encoder.Encode([]byte(password)); err != nil {
@@ -233,11 +255,15 @@ func (service userService) CreateUser(user model.User) (err error) {
 
 		user.ID = utils.UUID()
 		user.Created = utils.NowJsonTime()
+		user.PasswordUpdated = user.Created
 		user.Status = constant.StatusEnabled
 
 		if err := repository.UserRepository.Create(c, &user); err != nil {
 			return err
 		}
+		if err := PasswordPolicyService.SaveHistory(c, user.ID, user.Password); err != nil {
+			return err
+		}
 		err = StorageService.CreateStorageByUser(c, &user)
 		if err != nil {
 			return err
@@ -279,6 +305,34 @@ func (service userService) DeleteUserById(userId string) error {
 		if err := repository.ResourceSharerRepository.DeleteByUserId(c, userId); err != nil {
 			return err
 		}
+		// 删除用户的公钥
+		if err := repository.AuthorizedKeyRepository.DeleteByUserId(c, userId); err != nil {
+			return err
+		}
+		// 删除用户的授权令牌
+		if err := repository.AccessTokenRepository.DeleteByUserId(c, userId); err != nil {
+			return err
+		}
+		// 删除用户的历史密码
+		if err := repository.PasswordHistoryRepository.DeleteByUserId(c, userId); err != nil {
+			return err
+		}
+		// 删除用户的恢复码
+		if err := repository.RecoveryCodeRepository.DeleteByUserId(c, userId); err != nil {
+			return err
+		}
+		// 删除用户注册的认证器
+		if err := repository.WebAuthnCredentialRepository.DeleteByUserId(c, userId); err != nil {
+			return err
+		}
+		// 删除用户与登录策略的关系
+		if err := repository.LoginPolicyMemberRepository.DeleteByUserId(c, userId); err != nil {
+			return err
+		}
+		// 删除用户与角色的关系
+		if err := repository.RoleMemberRepository.DeleteByUserId(c, userId); err != nil {
+			return err
+		}
 		// 删除用户的默认磁盘空间
 		if err := StorageService.DeleteStorageById(c, userId, true); err != nil {
 			return err
@@ -298,6 +352,7 @@ func (service userService) DeleteUserById(userId string) error {
 	for _, token := range loginTokens {
 		service.Logout(token)
 	}
+	AccessTokenService.EvictByUserId(userId)
 	return nil
 }
 
@@ -342,6 +397,51 @@ func (service userService) SaveLoginLog(clientIP, clientUserAgent string, userna
 	return nil
 }
 
+// SaveExternalUser 保存从外部身份源（LDAP、OIDC等）获取的用户，不存在时自动创建，同名的其他来源用户不会被覆盖
+func (service userService) SaveExternalUser(c context.Context, source, username, nickname, mail string) (model.User, error) {
+	user, err := repository.UserRepository.FindByUsername(c, username)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+
+	if nickname == "" {
+		nickname = username
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user = model.User{
+			ID:       utils.UUID(),
+			Username: username,
+			Nickname: nickname,
+			Mail:     mail,
+			Type:     constant.TypeUser,
+			Status:   constant.StatusEnabled,
+			Source:   source,
+			Created:  utils.NowJsonTime(),
+		}
+		if err := repository.UserRepository.Create(c, &user); err != nil {
+			return user, err
+		}
+		if err := StorageService.CreateStorageByUser(c, &user); err != nil {
+			return user, err
+		}
+		log.Infof("创建%v用户「%v」", source, user.Username)
+		return user, nil
+	}
+
+	if user.Source != source {
+		return user, constant.ErrUsernameAlreadyUsed
+	}
+
+	user.Nickname = nickname
+	user.Mail = mail
+	// 外部身份源中删除了邮箱时同样需要清空
+	if err := repository.UserRepository.UpdateProfile(c, user.ID, user.Nickname, user.Mail); err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
 func (service userService) DeleteALlLdapUser(ctx context.Context) error {
 	return repository.UserRepository.DeleteBySource(ctx, constant.SourceLdap)
 }
@@ -372,18 +472,22 @@ func (service userService) UpdateUser(id string, user model.User) error {
 
 }
 
-func (service userService) AddSharerResources(ctx context.Context, userGroupId, userId, strategyId, resourceType string, resourceIds []string) error {
+// AddSharerResources 授权资源给用户或用户组，validFrom 与 validUntil 为空时不限制授权的有效期，accountIds 为空时允许使用资产的全部账号
+func (service userService) AddSharerResources(ctx context.Context, userGroupId, userId, strategyId, resourceType string, resourceIds, accountIds []string, validFrom, validUntil utils.JsonTime) error {
+	if !validFrom.IsZero() && !validUntil.IsZero() && !validUntil.After(validFrom.Time) {
+		return errors.New("授权的失效时间必须晚于生效时间")
+	}
 	if service.InTransaction(ctx) {
-		return service.addSharerResources(ctx, resourceIds, userGroupId, userId, strategyId, resourceType)
+		return service.addSharerResources(ctx, resourceIds, accountIds, userGroupId, userId, strategyId, resourceType, validFrom, validUntil)
 	} else {
 		return env.GetDB().Transaction(func(tx *gorm.DB) error {
 			ctx2 := service.Context(tx)
-			return service.addSharerResources(ctx2, resourceIds, userGroupId, userId, strategyId, resourceType)
+			return service.addSharerResources(ctx2, resourceIds, accountIds, userGroupId, userId, strategyId, resourceType, validFrom, validUntil)
 		})
 	}
 }
 
-func (service userService) addSharerResources(ctx context.Context, resourceIds []string, userGroupId string, userId string, strategyId string, resourceType string) error {
+func (service userService) addSharerResources(ctx context.Context, resourceIds, accountIds []string, userGroupId string, userId string, strategyId string, resourceType string, validFrom, validUntil utils.JsonTime) error {
 	for i := range resourceIds {
 		resourceId := resourceIds[i]
 		// 保证同一个资产只能分配给一个用户或者组
@@ -398,6 +502,9 @@ func (service userService) addSharerResources(ctx context.Context, resourceIds [
 			StrategyId:   strategyId,
 			UserId:       userId,
 			UserGroupId:  userGroupId,
+			ValidFrom:    validFrom,
+			ValidUntil:   validUntil,
+			AccountIds:   strings.Join(accountIds, ","),
 		}
 		if err := repository.ResourceSharerRepository.AddSharerResource(ctx, rs); err != nil {
 			return err
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"next-terminal/server/constant"
 	"next-terminal/server/env"
@@ -31,11 +32,23 @@ func (service userGroupService) DeleteById(userGroupId string) error {
 		if err := repository.ResourceSharerRepository.DeleteByUserGroupId(c, userGroupId); err != nil {
 			return err
 		}
+		// 删除用户组与登录策略的关系
+		if err := repository.LoginPolicyMemberRepository.DeleteByUserGroupId(c, userGroupId); err != nil {
+			return err
+		}
+		// 删除用户组与角色的关系
+		if err := repository.RoleMemberRepository.DeleteByUserGroupId(c, userGroupId); err != nil {
+			return err
+		}
 		return nil
 	})
 }
 
-func (service userGroupService) Create(ctx context.Context, name string, members []string) (model.UserGroup, error) {
+func (service userGroupService) Create(ctx context.Context, name, mfaMethod string, members []string) (model.UserGroup, error) {
+	if err := checkMfaMethod(mfaMethod); err != nil {
+		return model.UserGroup{}, err
+	}
+
 	exist, err := repository.UserGroupRepository.ExistByName(ctx, name)
 	if err != nil {
 		return model.UserGroup{}, err
@@ -47,9 +60,10 @@ func (service userGroupService) Create(ctx context.Context, name string, members
 
 	userGroupId := utils.UUID()
 	userGroup := model.UserGroup{
-		ID:      userGroupId,
-		Created: utils.NowJsonTime(),
-		Name:    name,
+		ID:        userGroupId,
+		Created:   utils.NowJsonTime(),
+		Name:      name,
+		MfaMethod: mfaMethod,
 	}
 
 	if service.InTransaction(ctx) {
@@ -81,7 +95,10 @@ func (service userGroupService) create(c context.Context, userGroup model.UserGr
 	return nil
 }
 
-func (service userGroupService) Update(userGroupId string, name string, members []string) (err error) {
+func (service userGroupService) Update(userGroupId, name, mfaMethod string, members []string) (err error) {
+	if err := checkMfaMethod(mfaMethod); err != nil {
+		return err
+	}
 	dbUserGroup, err := repository.UserGroupRepository.FindById(context.TODO(), userGroupId)
 	if err != nil {
 		return err
@@ -107,6 +124,9 @@ func (service userGroupService) Update(userGroupId string, name string, members
 		if err := repository.UserGroupRepository.Update(c, &userGroup); err != nil {
 			return err
 		}
+		if err := repository.UserGroupRepository.UpdateMfaMethodById(c, mfaMethod, userGroupId); err != nil {
+			return err
+		}
 		if err := repository.UserGroupMemberRepository.DeleteByUserGroupId(c, userGroupId); err != nil {
 			return err
 		}
@@ -125,3 +145,39 @@ func (service userGroupService) Update(userGroupId string, name string, members
 		return nil
 	})
 }
+
+// SaveExternalMembers 将外部身份源中的组按名称映射为用户组，只维护该来源用户组的成员关系，不影响手动维护的用户组
+func (service userGroupService) SaveExternalMembers(c context.Context, userId, source string, groups []string) error {
+	if err := repository.UserGroupMemberRepository.DeleteByUserIdAndSource(c, userId, source); err != nil {
+		return err
+	}
+	for _, name := range utils.Distinct(groups) {
+		userGroup, err := repository.UserGroupRepository.FindByName(c, name)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			userGroup = model.UserGroup{
+				ID:      utils.UUID(),
+				Name:    name,
+				Source:  source,
+				Created: utils.NowJsonTime(),
+			}
+			if err := repository.UserGroupRepository.Create(c, &userGroup); err != nil {
+				return err
+			}
+		}
+		if userGroup.Source != source {
+			continue
+		}
+		userGroupMember := model.UserGroupMember{
+			ID:          utils.Sign([]string{userGroup.ID, userId}),
+			UserId:      userId,
+			UserGroupId: userGroup.ID,
+		}
+		if err := repository.UserGroupMemberRepository.Create(c, &userGroupMember); err != nil {
+			return err
+		}
+	}
+	return nil
+}
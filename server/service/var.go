@@ -1,17 +1,34 @@
 package service
 
 var (
-	AssetService       = new(assetService)
-	BackupService      = new(backupService)
-	CredentialService  = new(credentialService)
-	GatewayService     = new(gatewayService)
-	JobService         = new(jobService)
-	MailService        = new(mailService)
-	PropertyService    = new(propertyService)
-	SecurityService    = new(securityService)
-	SessionService     = new(sessionService)
-	StorageService     = new(storageService)
-	UserService        = new(userService)
-	UserGroupService   = new(userGroupService)
-	AccessTokenService = new(accessTokenService)
+	AssetService              = new(assetService)
+	BackupService             = new(backupService)
+	CredentialService         = new(credentialService)
+	GatewayService            = new(gatewayService)
+	JobService                = new(jobService)
+	MailService               = new(mailService)
+	PropertyService           = new(propertyService)
+	SecurityService           = new(securityService)
+	SessionService            = new(sessionService)
+	StorageService            = new(storageService)
+	UserService               = new(userService)
+	UserGroupService          = new(userGroupService)
+	AccessTokenService        = new(accessTokenService)
+	LdapService               = new(ldapService)
+	OidcService               = new(oidcService)
+	SamlService               = new(samlService)
+	AuthorizedKeyService      = new(authorizedKeyService)
+	WebAuthnService           = new(webAuthnService)
+	PasswordPolicyService     = new(passwordPolicyService)
+	LoginLockService          = new(loginLockService)
+	RoleService               = new(roleService)
+	RecoveryCodeService       = new(recoveryCodeService)
+	MfaService                = new(mfaService)
+	LoginPolicyService        = new(loginPolicyService)
+	ConcurrentLimitService    = new(concurrentLimitService)
+	AccessRequestService      = new(accessRequestService)
+	CommandRuleService        = new(commandRuleService)
+	AssetAccountService       = new(assetAccountService)
+	CredentialCheckoutService = new(credentialCheckoutService)
+	SshCAService              = new(sshCAService)
 )
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/url"
+
+	"next-terminal/server/global/cache"
+	"next-terminal/server/model"
+	"next-terminal/server/repository"
+	"next-terminal/server/utils"
+	"next-terminal/server/webauthn"
+)
+
+const (
+	webAuthnRegistration = "registration:"
+	webAuthnLogin        = "login:"
+)
+
+type webAuthnService struct {
+}
+
+// relyingParty 使用访问地址作为依赖方的标识，浏览器只允许在同一域名下使用注册的认证器
+func (service webAuthnService) relyingParty(rootUrl string) (*webauthn.RelyingParty, error) {
+	u, err := url.Parse(rootUrl)
+	if err != nil {
+		return nil, err
+	}
+	return webauthn.NewRelyingParty(webauthn.Config{
+		RPID:          u.Hostname(),
+		RPDisplayName: "Next Terminal",
+		RPOrigins:     []string{rootUrl},
+	})
+}
+
+func (service webAuthnService) webAuthnUser(ctx context.Context, user model.User) (webauthn.User, []model.WebAuthnCredential, error) {
+	items, err := repository.WebAuthnCredentialRepository.FindByUserId(ctx, user.ID)
+	if err != nil {
+		return webauthn.User{}, nil, err
+	}
+	u := webauthn.User{
+		ID:       user.ID,
+		Username: user.Username,
+		Nickname: user.Nickname,
+	}
+	for _, item := range items {
+		id, err := base64.RawURLEncoding.DecodeString(item.CredentialId)
+		if err != nil {
+			return u, nil, err
+		}
+		publicKey, err := base64.RawURLEncoding.DecodeString(item.PublicKey)
+		if err != nil {
+			return u, nil, err
+		}
+		aaguid, _ := hex.DecodeString(item.AAGUID)
+		u.Credentials = append(u.Credentials, webauthn.Credential{
+			ID:              id,
+			PublicKey:       publicKey,
+			AttestationType: item.AttestationType,
+			AAGUID:          aaguid,
+			SignCount:       item.SignCount,
+		})
+	}
+	return u, items, nil
+}
+
+// Enabled 用户是否注册了 WebAuthn 认证器
+func (service webAuthnService) Enabled(ctx context.Context, userId string) bool {
+	total, err := repository.WebAuthnCredentialRepository.CountByUserId(ctx, userId)
+	if err != nil {
+		return false
+	}
+	return total > 0
+}
+
+// session 获取注册或登录时保存的数据，只允许使用一次
+func (service webAuthnService) session(key string) (webauthn.Session, error) {
+	v, ok := cache.WebAuthnSessionManager.Get(key)
+	if !ok {
+		return webauthn.Session{}, errors.New("webauthn: invalid or expired session")
+	}
+	cache.WebAuthnSessionManager.Delete(key)
+	return v.(webauthn.Session), nil
+}
+
+func (service webAuthnService) BeginRegistration(ctx context.Context, rootUrl string, user model.User) (interface{}, error) {
+	rp, err := service.relyingParty(rootUrl)
+	if err != nil {
+		return nil, err
+	}
+	u, _, err := service.webAuthnUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	options, session, err := rp.BeginRegistration(u)
+	if err != nil {
+		return nil, err
+	}
+	cache.WebAuthnSessionManager.Set(webAuthnRegistration+user.ID, *session, cache.WebAuthnSessionExpiration)
+	return options, nil
+}
+
+func (service webAuthnService) FinishRegistration(ctx context.Context, rootUrl string, user model.User, name string, body io.Reader) (*model.WebAuthnCredential, error) {
+	session, err := service.session(webAuthnRegistration + user.ID)
+	if err != nil {
+		return nil, err
+	}
+	rp, err := service.relyingParty(rootUrl)
+	if err != nil {
+		return nil, err
+	}
+	u, _, err := service.webAuthnUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := rp.FinishRegistration(u, session, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = "WebAuthn"
+	}
+	item := &model.WebAuthnCredential{
+		ID:              utils.UUID(),
+		UserId:          user.ID,
+		Name:            name,
+		CredentialId:    base64.RawURLEncoding.EncodeToString(credential.ID),
+		PublicKey:       base64.RawURLEncoding.EncodeToString(credential.PublicKey),
+		AttestationType: credential.AttestationType,
+		AAGUID:          hex.EncodeToString(credential.AAGUID),
+		SignCount:       credential.SignCount,
+		Created:         utils.NowJsonTime(),
+	}
+	if err := repository.WebAuthnCredentialRepository.Create(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (service webAuthnService) BeginLogin(ctx context.Context, rootUrl string, user model.User) (interface{}, error) {
+	rp, err := service.relyingParty(rootUrl)
+	if err != nil {
+		return nil, err
+	}
+	u, _, err := service.webAuthnUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	options, session, err := rp.BeginLogin(u)
+	if err != nil {
+		return nil, err
+	}
+	cache.WebAuthnSessionManager.Set(webAuthnLogin+user.ID, *session, cache.WebAuthnSessionExpiration)
+	return options, nil
+}
+
+// FinishLogin 校验认证器的签名，成功后保存最新的签名计数
+func (service webAuthnService) FinishLogin(ctx context.Context, rootUrl string, user model.User, response []byte) error {
+	session, err := service.session(webAuthnLogin + user.ID)
+	if err != nil {
+		return err
+	}
+	rp, err := service.relyingParty(rootUrl)
+	if err != nil {
+		return err
+	}
+	u, items, err := service.webAuthnUser(ctx, user)
+	if err != nil {
+		return err
+	}
+	credential, err := rp.FinishLogin(u, session, bytes.NewReader(response))
+	if err != nil {
+		return err
+	}
+
+	credentialId := base64.RawURLEncoding.EncodeToString(credential.ID)
+	for _, item := range items {
+		if item.CredentialId != credentialId {
+			continue
+		}
+		return repository.WebAuthnCredentialRepository.UpdateById(ctx, &model.WebAuthnCredential{
+			SignCount: credential.SignCount,
+			LastUsed:  utils.NowJsonTime(),
+		}, item.ID)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"errors"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/model"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+var ErrPermissionDenied = errors.New("permission denied")
+
+// Authenticator 组装 SSH 握手阶段的认证回调，用户校验、双因素认证及登录日志由调用方提供。
+// 握手阶段 gliderlabs 的 ssh.Context 尚未写入连接信息，远程地址等只能从 gossh.ConnMetadata 中获取
+type Authenticator struct {
+	// Password 校验账号密码
+	Password func(conn gossh.ConnMetadata, password string) (model.User, bool)
+	// PublicKey 校验用户添加的公钥
+	PublicKey func(conn gossh.ConnMetadata, key gossh.PublicKey) (model.User, bool)
+	// Methods 返回用户可用的双因素认证方式，以及用户组是否要求必须进行双因素认证
+	Methods func(user model.User) ([]string, bool, error)
+	// Mfa 通过 keyboard-interactive 校验双因素认证授权码或邮件验证码
+	Mfa func(conn gossh.ConnMetadata, user model.User, methods []string, challenge gossh.KeyboardInteractiveChallenge) bool
+	// Denied 第二步认证拒绝登录时保存登录日志
+	Denied func(conn gossh.ConnMetadata, user model.User, reason string)
+}
+
+// ServerConfig 密码及公钥认证通过后，开启了双因素认证的用户需要继续通过 keyboard-interactive 完成登录，
+// 认证成功时返回 permissions
+func (a Authenticator) ServerConfig(permissions *gossh.Permissions) *gossh.ServerConfig {
+	return &gossh.ServerConfig{
+		PasswordCallback: func(conn gossh.ConnMetadata, password []byte) (*gossh.Permissions, error) {
+			user, ok := a.Password(conn, string(password))
+			if !ok {
+				return nil, ErrPermissionDenied
+			}
+			return a.secondFactor(conn, user, permissions)
+		},
+		PublicKeyCallback: func(conn gossh.ConnMetadata, key gossh.PublicKey) (*gossh.Permissions, error) {
+			user, ok := a.PublicKey(conn, key)
+			if !ok {
+				return nil, ErrPermissionDenied
+			}
+			return a.secondFactor(conn, user, permissions)
+		},
+	}
+}
+
+// secondFactor SSH 无法使用 WebAuthn 认证器，用户组要求只能使用 WebAuthn 时拒绝登录
+func (a Authenticator) secondFactor(conn gossh.ConnMetadata, user model.User, permissions *gossh.Permissions) (*gossh.Permissions, error) {
+	methods, required, err := a.Methods(user)
+	if err != nil {
+		a.Denied(conn, user, err.Error())
+		return nil, ErrPermissionDenied
+	}
+	var sshMethods []string
+	for _, method := range methods {
+		if method != constant.MfaWebAuthn {
+			sshMethods = append(sshMethods, method)
+		}
+	}
+	if len(sshMethods) == 0 {
+		if required {
+			a.Denied(conn, user, "SSH登录不支持WebAuthn认证")
+			return nil, ErrPermissionDenied
+		}
+		return permissions, nil
+	}
+	return nil, &gossh.PartialSuccessError{
+		Next: gossh.ServerAuthCallbacks{
+			KeyboardInteractiveCallback: func(conn gossh.ConnMetadata, challenge gossh.KeyboardInteractiveChallenge) (*gossh.Permissions, error) {
+				if !a.Mfa(conn, user, sshMethods, challenge) {
+					return nil, ErrPermissionDenied
+				}
+				return permissions, nil
+			},
+		},
+	}
+}
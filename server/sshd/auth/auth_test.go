@@ -0,0 +1,150 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"next-terminal/server/constant"
+	"next-terminal/server/model"
+	"next-terminal/server/sshd/auth"
+
+	"github.com/stretchr/testify/assert"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+type denial struct {
+	remoteAddr string
+	username   string
+	reason     string
+}
+
+func newAuthenticator(methods []string, required bool, methodsErr error, denied *[]denial) auth.Authenticator {
+	return auth.Authenticator{
+		Password: func(conn gossh.ConnMetadata, password string) (model.User, bool) {
+			return model.User{Username: conn.User()}, password == "secret"
+		},
+		PublicKey: func(conn gossh.ConnMetadata, key gossh.PublicKey) (model.User, bool) {
+			return model.User{}, false
+		},
+		Methods: func(user model.User) ([]string, bool, error) {
+			return methods, required, methodsErr
+		},
+		Mfa: func(conn gossh.ConnMetadata, user model.User, methods []string, challenge gossh.KeyboardInteractiveChallenge) bool {
+			answers, err := challenge(conn.User(), "", []string{"code: "}, []bool{false})
+			return err == nil && len(answers) == 1 && answers[0] == "123456"
+		},
+		Denied: func(conn gossh.ConnMetadata, user model.User, reason string) {
+			*denied = append(*denied, denial{
+				remoteAddr: strings.Split(conn.RemoteAddr().String(), ":")[0],
+				username:   user.Username,
+				reason:     reason,
+			})
+		},
+	}
+}
+
+// handshake 在本地端口上完成一次真实的 SSH 握手，返回客户端的认证结果
+func handshake(t *testing.T, authenticator auth.Authenticator, clientAuth ...gossh.AuthMethod) (*gossh.Permissions, error) {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	signer, err := gossh.NewSignerFromKey(hostKey)
+	assert.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	type result struct {
+		permissions *gossh.Permissions
+		err         error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer conn.Close()
+		config := authenticator.ServerConfig(&gossh.Permissions{Extensions: map[string]string{"user": "ok"}})
+		config.AddHostKey(signer)
+		serverConn, _, _, err := gossh.NewServerConn(conn, config)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer serverConn.Close()
+		done <- result{permissions: serverConn.Permissions}
+	}()
+
+	client, err := gossh.Dial("tcp", listener.Addr().String(), &gossh.ClientConfig{
+		User:            "alice",
+		Auth:            clientAuth,
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+	})
+	if err == nil {
+		_ = client.Close()
+	}
+	r := <-done
+	return r.permissions, r.err
+}
+
+func keyboardInteractive(answer string) gossh.AuthMethod {
+	return gossh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		answers := make([]string, len(questions))
+		for i := range questions {
+			answers[i] = answer
+		}
+		return answers, nil
+	})
+}
+
+func TestMethodsErrorDenied(t *testing.T) {
+	var denied []denial
+	authenticator := newAuthenticator(nil, false, errors.New("database is locked"), &denied)
+	_, err := handshake(t, authenticator, gossh.Password("secret"))
+	assert.Error(t, err)
+	assert.Equal(t, []denial{{remoteAddr: "127.0.0.1", username: "alice", reason: "database is locked"}}, denied)
+}
+
+func TestWebAuthnOnlyDenied(t *testing.T) {
+	var denied []denial
+	authenticator := newAuthenticator([]string{constant.MfaWebAuthn}, true, nil, &denied)
+	_, err := handshake(t, authenticator, gossh.Password("secret"))
+	assert.Error(t, err)
+	assert.Len(t, denied, 1)
+	assert.Equal(t, "127.0.0.1", denied[0].remoteAddr)
+}
+
+func TestWithoutSecondFactor(t *testing.T) {
+	var denied []denial
+	authenticator := newAuthenticator([]string{constant.MfaWebAuthn}, false, nil, &denied)
+	permissions, err := handshake(t, authenticator, gossh.Password("secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", permissions.Extensions["user"])
+	assert.Empty(t, denied)
+
+	_, err = handshake(t, authenticator, gossh.Password("wrong"))
+	assert.Error(t, err)
+	assert.Empty(t, denied)
+}
+
+func TestKeyboardInteractiveSecondFactor(t *testing.T) {
+	var denied []denial
+	authenticator := newAuthenticator([]string{constant.MfaTotp, constant.MfaWebAuthn}, true, nil, &denied)
+	permissions, err := handshake(t, authenticator, gossh.Password("secret"), keyboardInteractive("123456"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", permissions.Extensions["user"])
+
+	_, err = handshake(t, authenticator, gossh.Password("secret"), keyboardInteractive("000000"))
+	assert.Error(t, err)
+
+	// 只通过 keyboard-interactive 无法跳过第一步认证
+	_, err = handshake(t, authenticator, keyboardInteractive("123456"))
+	assert.Error(t, err)
+	assert.Empty(t, denied)
+}
@@ -0,0 +1,65 @@
+package sshd
+
+import (
+	"io"
+
+	"next-terminal/server/api"
+	"next-terminal/server/global/session"
+	"next-terminal/server/service"
+
+	"github.com/gliderlabs/ssh"
+)
+
+// Reader 将用户的输入经过命令过滤器后再发送到目标资产
+type Reader struct {
+	sess        *ssh.Session
+	nextSession *session.Session
+	writer      *Writer
+	filter      *service.CommandFilter
+	buf         []byte
+	paused      bool
+}
+
+func NewReader(sess *ssh.Session, nextSession *session.Session, writer *Writer, filter *service.CommandFilter) *Reader {
+	return &Reader{sess: sess, nextSession: nextSession, writer: writer, filter: filter, paused: !nextSession.InputAllowed()}
+}
+
+func (r *Reader) Read(p []byte) (n int, err error) {
+	for len(r.buf) == 0 {
+		n, err = (*r.sess).Read(p)
+		if n > 0 {
+			if !r.nextSession.InputAllowed() {
+				// 双人复核的会话在监控人不在线时丢弃输入，暂停时只提示一次
+				if !r.paused {
+					r.paused = true
+					_, _ = io.WriteString(*r.sess, api.DualControlWaitingMessage)
+				}
+				n = 0
+				if err != nil {
+					return 0, err
+				}
+				continue
+			}
+			r.paused = false
+			r.nextSession.Limit.Active()
+			if r.writer.rz || r.writer.sz {
+				// 文件传输过程中的数据不是命令
+				return n, err
+			}
+			forward, message := r.filter.Filter(p[:n])
+			if message != "" {
+				_, _ = io.WriteString(*r.sess, message)
+			}
+			r.buf = forward
+		}
+		if err != nil {
+			if len(r.buf) > 0 {
+				break
+			}
+			return 0, err
+		}
+	}
+	n = copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
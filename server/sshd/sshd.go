@@ -12,16 +12,24 @@ import (
 	"next-terminal/server/constant"
 	"next-terminal/server/global/security"
 	"next-terminal/server/log"
+	"next-terminal/server/model"
 	"next-terminal/server/repository"
 	"next-terminal/server/service"
+	"next-terminal/server/sshd/auth"
 	"next-terminal/server/utils"
 
 	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
 	"gorm.io/gorm"
 )
 
 var Sshd *sshd
 
+type contextKey string
+
+// authorizedKeyIdContextKey 使用公钥登录时保存匹配到的公钥ID
+const authorizedKeyIdContextKey = contextKey("authorized-key-id")
+
 type sshd struct {
 	gui *Gui
 }
@@ -33,20 +41,129 @@ func init() {
 	}
 }
 
-func (sshd sshd) passwordAuth(ctx ssh.Context, pass string) bool {
-	username := ctx.User()
-	remoteAddr := strings.Split(ctx.RemoteAddr().String(), ":")[0]
-	user, err := repository.UserRepository.FindByUsername(context.TODO(), username)
+// serverConfig 握手阶段的认证回调，密码及公钥认证通过后，开启了双因素认证的用户需要继续输入授权码或邮件验证码才能完成登录
+func (sshd sshd) serverConfig(ctx ssh.Context) *gossh.ServerConfig {
+	authenticator := auth.Authenticator{
+		Password: sshd.passwordAuth,
+		PublicKey: func(conn gossh.ConnMetadata, key gossh.PublicKey) (model.User, bool) {
+			return sshd.publicKeyAuth(ctx, conn, key)
+		},
+		Methods: func(user model.User) ([]string, bool, error) {
+			return service.MfaService.Methods(context.TODO(), user)
+		},
+		Mfa: sshd.mfaAuth,
+		Denied: func(conn gossh.ConnMetadata, user model.User, reason string) {
+			remoteAddr := strings.Split(conn.RemoteAddr().String(), ":")[0]
+			_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", user.Username, false, false, "", reason)
+		},
+	}
+	return authenticator.ServerConfig(ctx.Permissions().Permissions)
+}
+
+func (sshd sshd) passwordAuth(conn gossh.ConnMetadata, pass string) (model.User, bool) {
+	username := conn.User()
+	remoteAddr := strings.Split(conn.RemoteAddr().String(), ":")[0]
+	if err := service.LoginLockService.Check(username); err != nil {
+		// 保存登录日志
+		_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, false, false, "", err.Error())
+		return model.User{}, false
+	}
+	user, err := service.UserService.Authenticate(username, pass)
 
 	if err != nil {
+		service.LoginLockService.Failed(username)
 		// 保存登录日志
 		_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, false, false, "", "账号或密码不正确")
+		return user, false
+	}
+
+	if user.Status == constant.StatusDisabled {
+		// 保存登录日志
+		_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, false, false, "", "该账户已停用")
+		return user, false
+	}
+
+	if service.PasswordPolicyService.Expired(user) {
+		_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, false, false, "", "密码已过期")
+		return user, false
+	}
+
+	if err := service.LoginPolicyService.Check(context.TODO(), user, remoteAddr); err != nil {
+		_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, false, false, "", err.Error())
+		return user, false
+	}
+	return user, true
+}
+
+// publicKeyAuth 校验用户添加的公钥，客户端可能会依次尝试多个公钥，只有匹配到公钥但无法登录时才记录登录日志
+func (sshd sshd) publicKeyAuth(ctx ssh.Context, conn gossh.ConnMetadata, key gossh.PublicKey) (model.User, bool) {
+	username := conn.User()
+	remoteAddr := strings.Split(conn.RemoteAddr().String(), ":")[0]
+	user, err := repository.UserRepository.FindByUsername(context.TODO(), username)
+	if err != nil {
+		return user, false
+	}
+
+	authorizedKey, err := service.AuthorizedKeyService.Match(context.TODO(), user.ID, key)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, false, false, "", err.Error())
+		}
+		return user, false
+	}
+
+	// 账户被锁定时公钥同样不允许登录
+	if err := service.LoginLockService.Check(username); err != nil {
+		_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, false, false, "", err.Error())
+		return user, false
+	}
+
+	if user.Status == constant.StatusDisabled {
+		// 保存登录日志
+		_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, false, false, "", "该账户已停用")
+		return user, false
+	}
+
+	if service.PasswordPolicyService.Expired(user) {
+		_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, false, false, "", "密码已过期")
+		return user, false
+	}
+
+	if err := service.LoginPolicyService.Check(context.TODO(), user, remoteAddr); err != nil {
+		_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, false, false, "", err.Error())
+		return user, false
+	}
+	ctx.SetValue(authorizedKeyIdContextKey, authorizedKey.ID)
+	return user, true
+}
+
+// mfaAuth 通过 keyboard-interactive 校验双因素认证授权码或邮件验证码，失败次数过多时锁定账户
+func (sshd sshd) mfaAuth(conn gossh.ConnMetadata, user model.User, methods []string, challenge gossh.KeyboardInteractiveChallenge) bool {
+	username := conn.User()
+	remoteAddr := strings.Split(conn.RemoteAddr().String(), ":")[0]
+
+	if err := service.LoginLockService.Check(username); err != nil {
+		_, _ = challenge(username, err.Error(), nil, nil)
 		return false
 	}
 
-	if err := utils.Encoder.Match([]byte(user.Password), []byte(pass)); err != nil {
+	question := "请输入双因素认证授权码或恢复码: "
+	if !utils.Contains(methods, constant.MfaTotp) {
+		if err := service.MfaService.SendEmailCode(user); err != nil {
+			_, _ = challenge(username, err.Error(), nil, nil)
+			return false
+		}
+		question = "验证码已发送至您的邮箱，请输入邮件验证码: "
+	}
+
+	answers, err := challenge(username, "", []string{question}, []bool{false})
+	if err != nil || len(answers) != 1 {
+		return false
+	}
+	if !service.MfaService.Verify(user, methods, answers[0]) {
+		service.LoginLockService.Failed(username)
 		// 保存登录日志
-		_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, false, false, "", "账号或密码不正确")
+		_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, false, false, "", "双因素认证授权码不正确")
 		return false
 	}
 	return true
@@ -119,14 +236,19 @@ func (sshd sshd) sessionHandler(sess *ssh.Session) {
 		return
 	}
 
-	// 判断是否需要进行双因素认证
-	if user.TOTPSecret != "" && user.TOTPSecret != "-" {
-		sshd.gui.totpUI(sess, user, remoteAddr, username)
-	} else {
-		// 保存登录日志
-		_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, true, false, utils.LongUUID(), "")
-		sshd.gui.MainUI(sess, user)
+	if authorizedKeyId, ok := (*sess).Context().Value(authorizedKeyIdContextKey).(string); ok {
+		_ = repository.AuthorizedKeyRepository.UpdateLastUsedById(context.TODO(), authorizedKeyId)
 	}
+
+	// 双因素认证已在握手阶段完成
+	service.LoginLockService.Reset(username)
+	loginLogId := utils.LongUUID()
+	_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, true, false, loginLogId, "")
+	defer func() {
+		// SSH 连接断开时记录退出时间，否则会一直被统计为在线
+		_ = service.UserService.LogoutByToken(loginLogId)
+	}()
+	sshd.gui.MainUI(sess, user)
 }
 
 func (sshd sshd) Serve() {
@@ -139,7 +261,14 @@ func (sshd sshd) Serve() {
 	err := ssh.ListenAndServe(
 		config.GlobalCfg.Sshd.Addr,
 		nil,
-		ssh.PasswordAuth(sshd.passwordAuth),
+		// 密码及公钥认证在 serverConfig 中处理，这里注册的 keyboard-interactive 仅作为第一步认证方式时总是拒绝
+		ssh.KeyboardInteractiveAuth(func(ctx ssh.Context, challenger gossh.KeyboardInteractiveChallenge) bool {
+			return false
+		}),
+		func(srv *ssh.Server) error {
+			srv.ServerConfigCallback = sshd.serverConfig
+			return nil
+		},
 		ssh.HostKeyFile(config.GlobalCfg.Sshd.Key),
 		ssh.WrapConn(sshd.connCallback),
 	)
@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"next-terminal/server/api"
 	"next-terminal/server/config"
 	"next-terminal/server/constant"
-	"next-terminal/server/global/cache"
+	"next-terminal/server/dto"
 	"next-terminal/server/global/session"
 	"next-terminal/server/guacd"
 	"next-terminal/server/log"
@@ -19,7 +21,6 @@ import (
 	"next-terminal/server/repository"
 	"next-terminal/server/service"
 	"next-terminal/server/term"
-	"next-terminal/server/totp"
 	"next-terminal/server/utils"
 
 	"github.com/gliderlabs/ssh"
@@ -32,7 +33,7 @@ type Gui struct {
 func (gui Gui) MainUI(sess *ssh.Session, user model.User) {
 	prompt := promptui.Select{
 		Label:  "欢迎使用 Next Terminal，请选择您要使用的功能",
-		Items:  []string{"我的资产", "退出系统"},
+		Items:  []string{"我的资产", "申请访问资产", "退出系统"},
 		Stdin:  *sess,
 		Stdout: *sess,
 	}
@@ -47,6 +48,8 @@ MainLoop:
 		switch result {
 		case "我的资产":
 			gui.AssetUI(sess, user)
+		case "申请访问资产":
+			gui.AccessRequestUI(sess, user)
 		case "退出系统":
 			break MainLoop
 		}
@@ -112,7 +115,15 @@ AssetUILoop:
 		case "quit":
 			break AssetUILoop
 		default:
-			if err := gui.createSession(sess, chooseAssetId, user.ID); err != nil {
+			accountId, err := gui.AccountUI(sess, chooseAssetId, user)
+			if err != nil {
+				_, _ = io.WriteString(*sess, err.Error()+"\r\n")
+				return
+			}
+			if accountId == "" {
+				continue
+			}
+			if err := gui.createSession(sess, chooseAssetId, accountId, user); err != nil {
 				_, _ = io.WriteString(*sess, err.Error()+"\r\n")
 				return
 			}
@@ -120,53 +131,146 @@ AssetUILoop:
 	}
 }
 
-func (gui Gui) createSession(sess *ssh.Session, assetId, creator string) (err error) {
-	asset, err := repository.AssetRepository.FindById(context.TODO(), assetId)
+// AccessRequestUI 选择资产并填写申请理由及访问时长，提交后等待审批
+func (gui Gui) AccessRequestUI(sess *ssh.Session, user model.User) {
+	all, err := repository.AssetRepository.FindAll(context.TODO())
 	if err != nil {
-		return err
+		return
 	}
 
-	ClientIP := strings.Split((*sess).RemoteAddr().String(), ":")[0]
-
-	s := &model.Session{
-		ID:              utils.UUID(),
-		AssetId:         asset.ID,
-		Username:        asset.Username,
-		Password:        asset.Password,
-		PrivateKey:      asset.PrivateKey,
-		Passphrase:      asset.Passphrase,
-		Protocol:        asset.Protocol,
-		IP:              asset.IP,
-		Port:            asset.Port,
-		Status:          constant.NoConnect,
-		Creator:         creator,
-		ClientIP:        ClientIP,
-		Mode:            constant.Terminal,
-		Upload:          "0",
-		Download:        "0",
-		Delete:          "0",
-		Rename:          "0",
-		StorageId:       "",
-		AccessGatewayId: asset.AccessGatewayId,
-	}
-
-	if asset.AccountType == "credential" {
-		credential, err := repository.CredentialRepository.FindById(context.TODO(), asset.CredentialId)
-		if err != nil {
-			return nil
+	assets := make([]model.Asset, 0)
+	for i := range all {
+		if all[i].Owner == user.ID {
+			continue
 		}
+		assets = append(assets, model.Asset{ID: all[i].ID, Name: all[i].Name, Protocol: all[i].Protocol})
+	}
+	if len(assets) == 0 {
+		_, _ = io.WriteString(*sess, "没有可以申请访问的资产\r\n")
+		return
+	}
 
-		if credential.Type == constant.Custom {
-			s.Username = credential.Username
-			s.Password = credential.Password
-		} else {
-			s.Username = credential.Username
-			s.PrivateKey = credential.PrivateKey
-			s.Passphrase = credential.Passphrase
-		}
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}?",
+		Active:   "\U0001F336  {{ .Name | cyan }}",
+		Inactive: "  {{ .Name | cyan }}",
+		Selected: "\U0001F336  {{ .Name | red | cyan }}",
+		Details: `
+--------- 详细信息 ----------
+{{ "名称:" | faint }}	{{ .Name }}
+{{ "协议:" | faint }}	{{ .Protocol }}
+`,
+	}
+
+	searcher := func(input string, index int) bool {
+		name := strings.Replace(strings.ToLower(assets[index].Name), " ", "", -1)
+		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+		return strings.Contains(name, input)
+	}
+
+	selectPrompt := promptui.Select{
+		Label:     "请选择您要申请访问的资产",
+		Items:     assets,
+		Templates: templates,
+		Size:      4,
+		Searcher:  searcher,
+		Stdin:     *sess,
+		Stdout:    *sess,
+	}
+	i, _, err := selectPrompt.Run()
+	if err != nil {
+		return
+	}
+
+	reasonPrompt := promptui.Prompt{
+		Label: "申请理由",
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return errors.New("请填写申请理由")
+			}
+			return nil
+		},
+		Stdin:  *sess,
+		Stdout: *sess,
+	}
+	reason, err := reasonPrompt.Run()
+	if err != nil {
+		return
+	}
+
+	durationPrompt := promptui.Prompt{
+		Label:   "访问时长（分钟）",
+		Default: "60",
+		Validate: func(input string) error {
+			if _, err := strconv.Atoi(input); err != nil {
+				return errors.New("请输入整数")
+			}
+			return nil
+		},
+		Stdin:  *sess,
+		Stdout: *sess,
+	}
+	result, err := durationPrompt.Run()
+	if err != nil {
+		return
+	}
+	duration, _ := strconv.Atoi(result)
+
+	clientIP := strings.Split((*sess).RemoteAddr().String(), ":")[0]
+	item := dto.AccessRequest{AssetId: assets[i].ID, Reason: reason, Duration: duration}
+	if _, err := service.AccessRequestService.Create(&user, item, clientIP); err != nil {
+		_, _ = io.WriteString(*sess, err.Error()+"\r\n")
+		return
+	}
+	_, _ = io.WriteString(*sess, "申请已提交，请等待审批\r\n")
+}
+
+// AccountUI 资产有多个可以使用的账号时选择账号，返回空字符串表示返回上级菜单
+func (gui Gui) AccountUI(sess *ssh.Session, assetId string, user model.User) (string, error) {
+	asset, err := repository.AssetRepository.FindById(context.TODO(), assetId)
+	if err != nil {
+		return "", err
+	}
+	accounts, err := service.AssetAccountService.FindAllowed(context.TODO(), asset, &user)
+	if err != nil {
+		return "", err
+	}
+	if len(accounts) == 0 {
+		return "", constant.ErrAccountNotAllowed
+	}
+	if len(accounts) == 1 {
+		return accounts[0].ID, nil
 	}
 
-	if err := repository.SessionRepository.Create(context.TODO(), s); err != nil {
+	quitItem := model.AssetAccount{ID: "", Name: "返回上级菜单"}
+	accounts = append([]model.AssetAccount{quitItem}, accounts...)
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}?",
+		Active:   "\U0001F336  {{ .Name | cyan }}",
+		Inactive: "  {{ .Name | cyan }}",
+		Selected: "\U0001F336  {{ .Name | red | cyan }}",
+	}
+
+	prompt := promptui.Select{
+		Label:     "请选择登录「" + asset.Name + "」使用的账号",
+		Items:     accounts,
+		Templates: templates,
+		Size:      4,
+		Stdin:     *sess,
+		Stdout:    *sess,
+	}
+	i, _, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+	return accounts[i].ID, nil
+}
+
+func (gui Gui) createSession(sess *ssh.Session, assetId, accountId string, user model.User) (err error) {
+	clientIP := strings.Split((*sess).RemoteAddr().String(), ":")[0]
+	s, err := service.SessionService.Create(clientIP, assetId, accountId, constant.Terminal, &user)
+	if err != nil {
 		return err
 	}
 
@@ -178,6 +282,9 @@ func (gui Gui) handleAccessAsset(sess *ssh.Session, sessionId string) (err error
 	if err != nil {
 		return err
 	}
+	if err := service.ConcurrentLimitService.CheckConnect(s); err != nil {
+		return err
+	}
 
 	var (
 		username   = s.Username
@@ -212,20 +319,36 @@ func (gui Gui) handleAccessAsset(sess *ssh.Session, sessionId string) (err error
 
 	recording := ""
 	property, err := repository.PropertyRepository.FindByName(context.TODO(), guacd.EnableRecording)
-	if err == nil && property.Value == "true" {
+	if (err == nil && property.Value == "true") || s.ForceRecording == "1" {
 		recording = path.Join(config.GlobalCfg.Guacd.Recording, sessionId, "recording.cast")
 	}
 
+	attributes, err := repository.AssetRepository.FindAssetAttrMapByAssetId(context.TODO(), s.AssetId)
+	if err != nil {
+		return err
+	}
+
 	nextTerminal, err := term.NewNextTerminal(ip, port, username, password, privateKey, passphrase, pty.Window.Height, pty.Window.Width, recording, pty.Term, false)
 	if err != nil {
 		return err
 	}
 	sshSession := nextTerminal.SshSession
 
-	writer := NewWriter(sessionId, sess, nextTerminal.Recorder)
+	nextSession := &session.Session{
+		ID:           s.ID,
+		Protocol:     s.Protocol,
+		Mode:         s.Mode,
+		NextTerminal: nextTerminal,
+		Observer:     session.NewObserver(s.ID),
+		Supervisor:   session.NewSupervisor(attributes[constant.DualControl]),
+		Limit:        session.NewLimit(s.ConnectedTime.Time, s.MaxDuration, s.IdleTimeout),
+	}
+
+	filter := service.CommandRuleService.NewCommandFilter(s, nextTerminal.Recorder)
+	writer := NewWriter(sessionId, sess, nextTerminal.Recorder, filter)
 
 	sshSession.Stdout = writer
-	sshSession.Stdin = *sess
+	sshSession.Stdin = NewReader(sess, nextSession, writer, filter)
 	sshSession.Stderr = *sess
 
 	if err := nextTerminal.RequestPty(pty.Term, pty.Window.Height, pty.Window.Width); err != nil {
@@ -264,15 +387,17 @@ func (gui Gui) handleAccessAsset(sess *ssh.Session, sessionId string) (err error
 	}
 	// ==== 修改数据库中的会话状态为已连接 ====
 
-	nextSession := &session.Session{
-		ID:           s.ID,
-		Protocol:     s.Protocol,
-		Mode:         s.Mode,
-		NextTerminal: nextTerminal,
-		Observer:     session.NewObserver(s.ID),
-	}
 	go nextSession.Observer.Start()
 	session.GlobalSessionManager.Add <- nextSession
+	if !nextSession.InputAllowed() {
+		_, _ = io.WriteString(*sess, api.DualControlWaitingMessage)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	if nextSession.Limit != nil {
+		go gui.checkLimit(sess, sessionId, nextSession.Limit, done)
+	}
 
 	if err := sshSession.Wait(); err != nil {
 		return err
@@ -285,57 +410,24 @@ func (gui Gui) handleAccessAsset(sess *ssh.Session, sessionId string) (err error
 	return nil
 }
 
-func (gui Gui) totpUI(sess *ssh.Session, user model.User, remoteAddr string, username string) {
-
-	validate := func(input string) error {
-		if len(input) < 6 {
-			return errors.New("双因素认证授权码必须为6个数字")
-		}
-		return nil
-	}
-
-	prompt := promptui.Prompt{
-		Label:    "请输入双因素认证授权码",
-		Validate: validate,
-		Mask:     '*',
-		Stdin:    *sess,
-		Stdout:   *sess,
-	}
-
-	var success = false
-	for i := 0; i < 5; i++ {
-		result, err := prompt.Run()
-		if err != nil {
-			fmt.Printf("Prompt failed %v\n", err)
+// checkLimit 检查授权策略限制的会话时长，超出时断开会话
+func (gui Gui) checkLimit(sess *ssh.Session, sessionId string, limit *session.Limit, done <-chan struct{}) {
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-done:
 			return
+		case now := <-tick.C:
+			warning, reason := limit.Check(now)
+			if reason != "" {
+				_, _ = io.WriteString(*sess, "\r\n"+reason+"\r\n")
+				service.SessionService.CloseSessionById(sessionId, api.SessionLimitExceeded, reason)
+				return
+			}
+			if warning != "" {
+				_, _ = io.WriteString(*sess, "\r\n"+warning+"\r\n")
+			}
 		}
-		loginFailCountKey := remoteAddr + username
-
-		v, ok := cache.LoginFailedKeyManager.Get(loginFailCountKey)
-		if !ok {
-			v = 1
-		}
-		count := v.(int)
-		if count >= 5 {
-			_, _ = io.WriteString(*sess, "登录失败次数过多，请等待5分钟后再试\r\n")
-			continue
-		}
-		if !totp.Validate(result, user.TOTPSecret) {
-			count++
-			println(count)
-			cache.LoginFailedKeyManager.Set(loginFailCountKey, count, cache.LoginLockExpiration)
-			// 保存登录日志
-			_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, false, false, "", "双因素认证授权码不正确")
-			_, _ = io.WriteString(*sess, "您输入的双因素认证授权码不匹配\r\n")
-			continue
-		}
-		success = true
-		break
-	}
-
-	if success {
-		// 保存登录日志
-		_ = service.UserService.SaveLoginLog(remoteAddr, "terminal", username, true, false, utils.UUID(), "")
-		gui.MainUI(sess, user)
 	}
 }
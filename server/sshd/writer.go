@@ -7,6 +7,7 @@ import (
 	"next-terminal/server/api"
 	"next-terminal/server/dto"
 	"next-terminal/server/global/session"
+	"next-terminal/server/service"
 	"next-terminal/server/term"
 
 	"github.com/gliderlabs/ssh"
@@ -16,15 +17,20 @@ type Writer struct {
 	sessionId string
 	sess      *ssh.Session
 	recorder  *term.Recorder
+	filter    *service.CommandFilter
 	rz        bool
 	sz        bool
 }
 
-func NewWriter(sessionId string, sess *ssh.Session, recorder *term.Recorder) *Writer {
-	return &Writer{sessionId: sessionId, sess: sess, recorder: recorder}
+func NewWriter(sessionId string, sess *ssh.Session, recorder *term.Recorder, filter *service.CommandFilter) *Writer {
+	return &Writer{sessionId: sessionId, sess: sess, recorder: recorder, filter: filter}
 }
 
 func (w *Writer) Write(p []byte) (n int, err error) {
+	if !w.rz && !w.sz {
+		// 解析当前目录及命令退出码
+		w.filter.Output(string(p))
+	}
 	if w.recorder != nil {
 		s := string(p)
 		if !w.sz && !w.rz {
@@ -2,12 +2,14 @@ package task
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"time"
 
 	"next-terminal/server/constant"
 	"next-terminal/server/log"
 	"next-terminal/server/repository"
+	"next-terminal/server/service"
 )
 
 type Ticker struct {
@@ -16,11 +18,6 @@ type Ticker struct {
 func NewTicker() *Ticker {
 	return &Ticker{}
 }
-func init() {
-	ticker := NewTicker()
-	ticker.SetupTicker()
-}
-
 func (t *Ticker) SetupTicker() {
 
 	// 每隔一小时删除一次未使用的会话信息
@@ -40,6 +37,17 @@ func (t *Ticker) SetupTicker() {
 			deleteOutTimeJobLog()
 		}
 	}()
+
+	// 每分钟处理一次到期的授权及凭证借出
+	grantTicker := time.NewTicker(time.Minute)
+	go func() {
+		for range grantTicker.C {
+			service.AccessRequestService.ExpireGrants()
+			service.CredentialCheckoutService.ExpireCheckouts()
+			notifyExpiringResourceSharers()
+			deleteExpiredResourceSharers()
+		}
+	}()
 }
 
 func (t *Ticker) deleteUnUsedSession() {
@@ -150,3 +158,90 @@ func deleteOutTimeJobLog() {
 		}
 	}
 }
+
+func deleteExpiredResourceSharers() {
+	resourceSharers, err := repository.ResourceSharerRepository.FindExpired(context.TODO(), time.Now())
+	if err != nil {
+		log.Errorf("查询过期授权失败 %v", err)
+		return
+	}
+	for i := range resourceSharers {
+		if err := repository.ResourceSharerRepository.DeleteById(context.TODO(), resourceSharers[i].ID); err != nil {
+			log.Errorf("删除过期授权失败 %v", err)
+			continue
+		}
+		log.Infof("授权「%v」已过期，已删除。", resourceSharers[i].ID)
+	}
+}
+
+// notifyExpiringResourceSharers 授权即将到期时邮件提醒资源所有者，每个授权只提醒一次
+func notifyExpiringResourceSharers() {
+	property, err := repository.PropertyRepository.FindByName(context.TODO(), constant.ResourceSharerExpiryNotice)
+	if err != nil {
+		return
+	}
+	hours, err := strconv.Atoi(property.Value)
+	if err != nil || hours <= 0 {
+		return
+	}
+
+	resourceSharers, err := repository.ResourceSharerRepository.FindExpiringNotNotified(context.TODO(), time.Now().Add(time.Hour*time.Duration(hours)))
+	if err != nil {
+		log.Errorf("查询即将到期的授权失败 %v", err)
+		return
+	}
+	for _, item := range resourceSharers {
+		if err := repository.ResourceSharerRepository.UpdateNotifiedById(context.TODO(), item.ID); err != nil {
+			log.Errorf("更新授权提醒状态失败 %v", err)
+			continue
+		}
+
+		var resourceName, owner string
+		switch item.ResourceType {
+		case "asset":
+			resource, err := repository.AssetRepository.FindById(context.TODO(), item.ResourceId)
+			if err != nil {
+				continue
+			}
+			resourceName, owner = resource.Name, resource.Owner
+		case "command":
+			resource, err := repository.CommandRepository.FindById(context.TODO(), item.ResourceId)
+			if err != nil {
+				continue
+			}
+			resourceName, owner = resource.Name, resource.Owner
+		case "credential":
+			resource, err := repository.CredentialRepository.FindById(context.TODO(), item.ResourceId)
+			if err != nil {
+				continue
+			}
+			resourceName, owner = resource.Name, resource.Owner
+		default:
+			continue
+		}
+
+		user, err := repository.UserRepository.FindById(context.TODO(), owner)
+		if err != nil || user.Mail == "" {
+			continue
+		}
+
+		sharer := ""
+		if item.UserId != "" {
+			u, err := repository.UserRepository.FindById(context.TODO(), item.UserId)
+			if err != nil {
+				continue
+			}
+			sharer = "用户「" + u.Username + "」"
+		} else {
+			g, err := repository.UserGroupRepository.FindById(context.TODO(), item.UserGroupId)
+			if err != nil {
+				continue
+			}
+			sharer = "用户组「" + g.Name + "」"
+		}
+
+		subject := fmt.Sprintf("授权即将到期：%s", resourceName)
+		text := fmt.Sprintf("您授权给%s的资源「%s」将于 %s 到期，到期后将自动收回授权。", sharer, resourceName, item.ValidUntil.Format("2006-01-02 15:04:05"))
+		service.MailService.SendMail(user.Mail, subject, text)
+	}
+}
@@ -52,10 +52,14 @@ func (recorder *Recorder) WriteHeader(header *Header) (err error) {
 	return
 }
 
-func (recorder *Recorder) WriteData(data string) (err error) {
+// Offset 当前时间相对于录屏开始的秒数
+func (recorder *Recorder) Offset() float64 {
 	now := int(time.Now().UnixNano())
+	return float64(now-recorder.Timestamp*1000*1000*1000) / 1000 / 1000 / 1000
+}
 
-	delta := float64(now-recorder.Timestamp*1000*1000*1000) / 1000 / 1000 / 1000
+func (recorder *Recorder) WriteData(data string) (err error) {
+	delta := recorder.Offset()
 
 	row := make([]interface{}, 0)
 	row = append(row, delta)
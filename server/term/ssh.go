@@ -5,6 +5,8 @@ import (
 	"net"
 	"time"
 
+	"next-terminal/server/utils"
+
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/net/proxy"
 )
@@ -24,19 +26,11 @@ func NewSshClient(ip string, port int, username, password, privateKey, passphras
 		passphrase = ""
 	}
 
-	var err error
 	if privateKey != "" {
-		var key ssh.Signer
-		if len(passphrase) > 0 {
-			key, err = ssh.ParsePrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			key, err = ssh.ParsePrivateKey([]byte(privateKey))
-			if err != nil {
-				return nil, err
-			}
+		// 私钥之后可以附带 SSH CA 签发的证书
+		key, err := utils.ParseSshSigner(privateKey, passphrase)
+		if err != nil {
+			return nil, err
 		}
 		authMethod = ssh.PublicKeys(key)
 	} else {
@@ -69,19 +63,11 @@ func NewSshClientUseSocks(ip string, port int, username, password, privateKey, p
 		passphrase = ""
 	}
 
-	var err error
 	if privateKey != "" {
-		var key ssh.Signer
-		if len(passphrase) > 0 {
-			key, err = ssh.ParsePrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			key, err = ssh.ParsePrivateKey([]byte(privateKey))
-			if err != nil {
-				return nil, err
-			}
+		// 私钥之后可以附带 SSH CA 签发的证书
+		key, err := utils.ParseSshSigner(privateKey, passphrase)
+		if err != nil {
+			return nil, err
 		}
 		authMethod = ssh.PublicKeys(key)
 	} else {
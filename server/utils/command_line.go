@@ -0,0 +1,130 @@
+package utils
+
+// CommandLine 根据用户在终端中的输入还原当前正在编辑的命令行。
+// 只处理常见的行编辑按键，Tab 补全、历史命令、Ctrl+W 等依赖远程 shell 的输入无法还原，此时命令行被标记为不可信
+type CommandLine struct {
+	buf     []rune
+	cursor  int
+	state   int // 0: 普通输入, 1: 收到 ESC, 2: CSI 控制序列, 3: SS3 控制序列
+	param   []rune
+	unknown bool // 当前行出现了无法处理的控制字符或控制序列
+}
+
+const (
+	stateNormal = iota
+	stateEscape
+	stateCSI
+	stateSS3
+	stateOSC
+)
+
+// Input 处理一个输入字符，遇到回车时返回完整的命令行并清空缓冲区。
+// exact 为 false 时表示返回的命令行与远程 shell 实际执行的命令可能不一致
+func (l *CommandLine) Input(r rune) (line string, enter, exact bool) {
+	switch l.state {
+	case stateEscape:
+		switch r {
+		case '[':
+			l.state = stateCSI
+			l.param = l.param[:0]
+		case 'O':
+			l.state = stateSS3
+		default:
+			// Alt+B、Alt+F 等组合键
+			l.state = stateNormal
+			l.unknown = true
+		}
+		return "", false, false
+	case stateCSI:
+		if r >= 0x40 && r <= 0x7e {
+			l.state = stateNormal
+			l.control(string(l.param) + string(r))
+		} else {
+			l.param = append(l.param, r)
+		}
+		return "", false, false
+	case stateSS3:
+		l.state = stateNormal
+		l.control(string(r))
+		return "", false, false
+	}
+
+	switch r {
+	case '\r', '\n':
+		line, exact = string(l.buf), !l.unknown
+		l.Reset()
+		return line, true, exact
+	case 0x1b:
+		l.state = stateEscape
+	case 0x7f, 0x08:
+		// 退格
+		if l.cursor > 0 {
+			l.buf = append(l.buf[:l.cursor-1], l.buf[l.cursor:]...)
+			l.cursor--
+		}
+	case 0x03, 0x15:
+		// Ctrl+C 取消输入，Ctrl+U 删除整行
+		l.Reset()
+	case 0x01:
+		// Ctrl+A
+		l.cursor = 0
+	case 0x05:
+		// Ctrl+E
+		l.cursor = len(l.buf)
+	case 0x0b:
+		// Ctrl+K 删除光标之后的内容
+		l.buf = l.buf[:l.cursor]
+	case 0x02:
+		// Ctrl+B
+		l.control("D")
+	case 0x06:
+		// Ctrl+F
+		l.control("C")
+	case 0x0c:
+		// Ctrl+L 清屏，不影响命令行
+	default:
+		if r < 0x20 {
+			// Tab 补全、Ctrl+R 搜索历史、Ctrl+W 删除单词等
+			l.unknown = true
+			return "", false, false
+		}
+		l.buf = append(l.buf, 0)
+		copy(l.buf[l.cursor+1:], l.buf[l.cursor:])
+		l.buf[l.cursor] = r
+		l.cursor++
+	}
+	return "", false, false
+}
+
+// control 处理方向键等控制序列
+func (l *CommandLine) control(seq string) {
+	switch seq {
+	case "C":
+		if l.cursor < len(l.buf) {
+			l.cursor++
+		}
+	case "D":
+		if l.cursor > 0 {
+			l.cursor--
+		}
+	case "H", "1~":
+		l.cursor = 0
+	case "F", "4~":
+		l.cursor = len(l.buf)
+	case "3~":
+		if l.cursor < len(l.buf) {
+			l.buf = append(l.buf[:l.cursor], l.buf[l.cursor+1:]...)
+		}
+	case "200~", "201~":
+		// 粘贴的开始及结束标记
+	default:
+		// 上下方向键切换历史命令等
+		l.unknown = true
+	}
+}
+
+func (l *CommandLine) Reset() {
+	l.buf = l.buf[:0]
+	l.cursor = 0
+	l.unknown = false
+}
@@ -28,9 +28,10 @@ func (j JsonTime) MarshalJSON() ([]byte, error) {
 	return []byte(stamp), nil
 }
 
+// UnmarshalJSON 前端提交的时间不包含时区，按服务器所在时区解析，与 MarshalJSON 的输出保持一致
 func (j *JsonTime) UnmarshalJSON(b []byte) error {
 	s := strings.ReplaceAll(string(b), "\"", "")
-	t, err := time.Parse("2006-01-02 15:04:05", s)
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.Local)
 	if err != nil {
 		return err
 	}
@@ -38,12 +39,13 @@ func (j *JsonTime) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// Value 统一转换为服务器所在时区后保存，sqlite 以字符串保存时间，时区不一致时无法与 time.Now() 正确比较
 func (j JsonTime) Value() (driver.Value, error) {
 	var zeroTime time.Time
 	if j.Time.UnixNano() == zeroTime.UnixNano() {
 		return nil, nil
 	}
-	return j.Time, nil
+	return j.Time.Local(), nil
 }
 
 func (j *JsonTime) Scan(v interface{}) error {
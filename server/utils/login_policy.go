@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// IpMatch 判断IP是否符合规则，规则支持单个IP、CIDR 以及 192.168.1.1-192.168.1.100 格式的范围段
+func IpMatch(rule, ip string) bool {
+	rule = strings.TrimSpace(rule)
+	if strings.Contains(rule, "/") {
+		_, ipNet, err := net.ParseCIDR(rule)
+		if err != nil {
+			return false
+		}
+		return ipNet.Contains(net.ParseIP(ip))
+	}
+	if strings.Contains(rule, "-") {
+		split := strings.Split(rule, "-")
+		if len(split) < 2 {
+			return false
+		}
+		intIp := IpToInt(ip)
+		return intIp >= IpToInt(strings.TrimSpace(split[0])) && intIp <= IpToInt(strings.TrimSpace(split[1]))
+	}
+	return rule == ip
+}
+
+// TimePeriod 每周允许登录的时间段，Weekdays 中 0 表示周日，End 早于 Start 时表示跨越零点
+type TimePeriod struct {
+	Weekdays []int  `json:"weekdays"`
+	Start    string `json:"start"` // 格式为 15:04
+	End      string `json:"end"`
+}
+
+func (p TimePeriod) Validate() error {
+	if len(p.Weekdays) == 0 {
+		return fmt.Errorf("请选择星期")
+	}
+	for _, weekday := range p.Weekdays {
+		if weekday < 0 || weekday > 6 {
+			return fmt.Errorf("星期格式不正确: %d", weekday)
+		}
+	}
+	if _, err := time.Parse("15:04", p.Start); err != nil {
+		return fmt.Errorf("开始时间格式不正确: %s", p.Start)
+	}
+	if _, err := time.Parse("15:04", p.End); err != nil {
+		return fmt.Errorf("结束时间格式不正确: %s", p.End)
+	}
+	return nil
+}
+
+// Contains 判断时间是否在时间段内，时间需要提前转换为策略配置的时区
+func (p TimePeriod) Contains(t time.Time) bool {
+	start, err := time.Parse("15:04", p.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", p.End)
+	if err != nil {
+		return false
+	}
+	minutes := t.Hour()*60 + t.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+
+	weekday := int(t.Weekday())
+	if startMinutes <= endMinutes {
+		return p.hasWeekday(weekday) && minutes >= startMinutes && minutes < endMinutes
+	}
+	// 跨越零点时，零点之后的部分属于前一天的时间段
+	if minutes >= startMinutes {
+		return p.hasWeekday(weekday)
+	}
+	return minutes < endMinutes && p.hasWeekday((weekday+6)%7)
+}
+
+func (p TimePeriod) hasWeekday(weekday int) bool {
+	for _, w := range p.Weekdays {
+		if w == weekday {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,12 @@
 package utils
 
 import (
-	"math/rand"
-	"time"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -23,22 +27,147 @@ var Encoder = Bcrypt{
 	cost: bcrypt.DefaultCost,
 }
 
-func GenPassword() string {
-	rand.Seed(time.Now().UnixNano())
-	digits := "0123456789"
-	specials := "~=+%^*/()[]{}/!@#$?|"
-	all := "ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
-		"abcdefghijklmnopqrstuvwxyz" +
-		digits + specials
-	length := 8
+const (
+	upperLetters    = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	lowerLetters    = "abcdefghijklmnopqrstuvwxyz"
+	digits          = "0123456789"
+	defaultSpecials = "~=+%^*/()[]{}/!@#$?|"
+)
+
+// GenPassword 生成8位随机密码，包含大小写字母、数字及特殊字符
+func GenPassword() (string, error) {
+	return genPassword(8, defaultSpecials, false)
+}
+
+// PasswordPolicy 密码复杂度策略
+type PasswordPolicy struct {
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSpecial   bool
+	BannedPasswords  []string // 禁止使用的密码，不区分大小写
+	Specials         string   // 生成密码时使用的特殊字符，为空时使用默认字符集
+	ExcludeSpecial   bool     // 生成的密码不包含特殊字符，用于不支持特殊字符的系统
+}
+
+// Validate 校验密码是否满足策略，密码中不允许包含用户名
+func (p PasswordPolicy) Validate(username, password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("密码长度不能少于%d位", p.MinLength)
+	}
+	var upper, lower, digit, special bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			special = true
+		}
+	}
+	if p.RequireUppercase && !upper {
+		return errors.New("密码必须包含大写字母")
+	}
+	if p.RequireLowercase && !lower {
+		return errors.New("密码必须包含小写字母")
+	}
+	if p.RequireDigit && !digit {
+		return errors.New("密码必须包含数字")
+	}
+	if p.RequireSpecial && !special {
+		return errors.New("密码必须包含特殊字符")
+	}
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return errors.New("密码不能包含用户名")
+	}
+	for _, banned := range p.BannedPasswords {
+		if strings.EqualFold(password, banned) {
+			return errors.New("该密码过于简单，禁止使用")
+		}
+	}
+	return nil
+}
+
+// ValidateSpecials 校验生成密码使用的特殊字符，只允许使用字母及数字以外的可见ASCII字符，否则无法满足包含特殊字符的要求
+func (p PasswordPolicy) ValidateSpecials() error {
+	if p.ExcludeSpecial {
+		return nil
+	}
+	for _, r := range p.Specials {
+		if r <= ' ' || r > '~' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return fmt.Errorf("特殊字符「%c」无效，只能使用字母及数字以外的可见ASCII字符", r)
+		}
+	}
+	return nil
+}
+
+// GenPasswordWithPolicy 使用 crypto/rand 生成满足密码策略的随机密码，长度不少于12位
+func GenPasswordWithPolicy(p PasswordPolicy) (string, error) {
+	if err := p.ValidateSpecials(); err != nil {
+		return "", err
+	}
+	length := p.MinLength
+	if length < 12 {
+		length = 12
+	}
+	if p.ExcludeSpecial {
+		p.RequireSpecial = false
+	}
+	specials := p.Specials
+	if specials == "" {
+		specials = defaultSpecials
+	}
+	// 每类字符至少包含一个，只有命中禁用密码时才需要重新生成
+	for i := 0; i < 10; i++ {
+		password, err := genPassword(length, specials, p.ExcludeSpecial)
+		if err != nil {
+			return "", err
+		}
+		if p.Validate("", password) == nil {
+			return password, nil
+		}
+	}
+	return "", errors.New("无法生成满足密码策略的密码")
+}
+
+func genPassword(length int, specials string, excludeSpecial bool) (string, error) {
+	groups := []string{upperLetters, lowerLetters, digits}
+	if !excludeSpecial {
+		groups = append(groups, specials)
+	}
+	all := strings.Join(groups, "")
 	buf := make([]byte, length)
-	buf[0] = digits[rand.Intn(len(digits))]
-	buf[1] = specials[rand.Intn(len(specials))]
-	for i := 2; i < length; i++ {
-		buf[i] = all[rand.Intn(len(all))]
+	// 每类字符至少包含一个
+	for i := range buf {
+		chars := all
+		if i < len(groups) {
+			chars = groups[i]
+		}
+		n, err := randInt(len(chars))
+		if err != nil {
+			return "", err
+		}
+		buf[i] = chars[n]
 	}
-	rand.Shuffle(len(buf), func(i, j int) {
+	for i := len(buf) - 1; i > 0; i-- {
+		j, err := randInt(i + 1)
+		if err != nil {
+			return "", err
+		}
 		buf[i], buf[j] = buf[j], buf[i]
-	})
-	return string(buf)
+	}
+	return string(buf), nil
+}
+
+// randInt 返回 [0, n) 之间的安全随机数
+func randInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
 }
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 常见的默认命令提示符，例如 root@host:/var/log# 以及 [root@host log]$
+var promptPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^[^\s@]+@[^\s:]+:(~?[^\s]*)[$#] `),
+	regexp.MustCompile(`^\[[^\s@]+@[^\s]+ ([^\]]+)\][$#] `),
+}
+
+// 关闭了回显的密码输入提示，例如 [sudo] password for root: 以及 Enter passphrase for key '/root/.ssh/id_rsa':
+var passwordPromptPattern = regexp.MustCompile(`(?i)(password|passphrase|pass phrase|密码|口令)[^:：]*[:：]\s*$`)
+
+// ShellOutput 跟踪终端的输出，解析当前目录及命令的退出码。
+// 远程 Shell 开启了 Shell 集成时使用 OSC 7 上报的目录及 OSC 133 上报的退出码，否则从命令提示符中解析当前目录
+type ShellOutput struct {
+	line      []rune // 当前行可见的字符
+	state     int
+	seq       []rune
+	cwd       string
+	altScreen bool // vim、less、top 等全屏程序使用的备用屏幕
+}
+
+// Write 处理一段终端输出，返回其中上报的命令退出码
+func (o *ShellOutput) Write(s string) (exitCodes []int) {
+	for _, r := range s {
+		switch o.state {
+		case stateEscape:
+			switch r {
+			case '[':
+				o.state = stateCSI
+				o.seq = o.seq[:0]
+			case ']':
+				o.state = stateOSC
+				o.seq = o.seq[:0]
+			default:
+				o.state = stateNormal
+			}
+		case stateCSI:
+			if r >= 0x40 && r <= 0x7e {
+				o.state = stateNormal
+				o.csi(string(o.seq), r)
+			} else {
+				o.seq = append(o.seq, r)
+			}
+		case stateOSC:
+			// OSC 以 BEL 或 ESC \ 结束
+			if r == 0x07 || r == 0x1b {
+				o.state = stateNormal
+				if code, ok := o.osc(string(o.seq)); ok {
+					exitCodes = append(exitCodes, code)
+				}
+				if r == 0x1b {
+					o.state = stateEscape
+				}
+			} else {
+				o.seq = append(o.seq, r)
+			}
+		default:
+			switch r {
+			case 0x1b:
+				o.state = stateEscape
+			case '\r', '\n':
+				o.line = o.line[:0]
+			case 0x08:
+				if len(o.line) > 0 {
+					o.line = o.line[:len(o.line)-1]
+				}
+			default:
+				if r >= 0x20 {
+					o.line = append(o.line, r)
+				}
+			}
+		}
+	}
+	return
+}
+
+// csi 处理切换备用屏幕的 CSI 控制序列
+func (o *ShellOutput) csi(params string, final rune) {
+	if final != 'h' && final != 'l' {
+		return
+	}
+	switch params {
+	case "?1049", "?1047", "?47":
+		o.altScreen = final == 'h'
+		o.line = o.line[:0]
+	}
+}
+
+// osc 处理 OSC 控制序列，返回 OSC 133;D 上报的退出码
+func (o *ShellOutput) osc(seq string) (int, bool) {
+	switch {
+	case strings.HasPrefix(seq, "7;"):
+		if u, err := url.Parse(seq[2:]); err == nil && u.Path != "" {
+			o.cwd = u.Path
+		}
+	case strings.HasPrefix(seq, "133;D;"):
+		code, err := strconv.Atoi(strings.SplitN(seq[6:], ";", 2)[0])
+		if err == nil {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// Cwd 返回当前目录，无法解析时返回空字符串
+func (o *ShellOutput) Cwd() string {
+	if o.cwd != "" {
+		return o.cwd
+	}
+	line := string(o.line)
+	for _, pattern := range promptPatterns {
+		if matches := pattern.FindStringSubmatch(line); len(matches) > 1 {
+			return matches[1]
+		}
+	}
+	return ""
+}
+
+// PasswordPrompt 当前行是否为密码输入提示，此时用户输入的内容不会回显，属于敏感信息
+func (o *ShellOutput) PasswordPrompt() bool {
+	return passwordPromptPattern.MatchString(string(o.line))
+}
+
+// AlternateScreen 远程终端是否处于备用屏幕，此时运行的是全屏程序，用户的输入不是命令行
+func (o *ShellOutput) AlternateScreen() bool {
+	return o.altScreen
+}
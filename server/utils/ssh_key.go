@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	SshKeyEd25519 = "ed25519"
+	SshKeyRSA     = "rsa"
+	SshKeyECDSA   = "ecdsa"
+)
+
+// GenerateSshKey 生成SSH密钥对，返回 OpenSSH 格式的私钥及 authorized_keys 格式的公钥。
+// bits 为0时 RSA 使用3072位，ECDSA 使用256位，ed25519 忽略该参数
+func GenerateSshKey(keyType string, bits int, passphrase, comment string) (privateKey, publicKey string, err error) {
+	var key crypto.Signer
+	switch keyType {
+	case SshKeyEd25519, "":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case SshKeyRSA:
+		if bits == 0 {
+			bits = 3072
+		}
+		if bits < 2048 || bits > 8192 {
+			return "", "", errors.New("RSA密钥长度必须在2048到8192位之间")
+		}
+		key, err = rsa.GenerateKey(rand.Reader, bits)
+	case SshKeyECDSA:
+		var curve elliptic.Curve
+		switch bits {
+		case 0, 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return "", "", errors.New("ECDSA密钥长度只能是256、384或521位")
+		}
+		key, err = ecdsa.GenerateKey(curve, rand.Reader)
+	default:
+		return "", "", fmt.Errorf("不支持的密钥类型：%v", keyType)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	var block *pem.Block
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(key, comment, []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(key, comment)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	sshPublicKey, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return "", "", err
+	}
+	return string(pem.EncodeToMemory(block)), AuthorizedKeyLine(sshPublicKey, comment), nil
+}
+
+// AuthorizedKeyLine 返回公钥在 authorized_keys 文件中的一行，不包含换行符
+func AuthorizedKeyLine(publicKey ssh.PublicKey, comment string) string {
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
+	if comment != "" {
+		line += " " + comment
+	}
+	return line
+}
+
+// SignUserCertificate 使用CA签发用户证书，只允许分配终端
+func SignUserCertificate(ca ssh.Signer, publicKey ssh.PublicKey, keyId string, principals []string, validAfter, validBefore time.Time) (*ssh.Certificate, error) {
+	serial := make([]byte, 8)
+	if _, err := rand.Read(serial); err != nil {
+		return nil, err
+	}
+	cert := &ssh.Certificate{
+		Key:             publicKey,
+		Serial:          binary.BigEndian.Uint64(serial),
+		CertType:        ssh.UserCert,
+		KeyId:           keyId,
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(validAfter.Unix()),
+		ValidBefore:     uint64(validBefore.Unix()),
+		Permissions: ssh.Permissions{
+			Extensions: map[string]string{"permit-pty": ""},
+		},
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// BundleCertificate 将证书附加在私钥之后，使用 ParseSshSigner 解析
+func BundleCertificate(privateKey string, cert *ssh.Certificate) string {
+	return strings.TrimSpace(privateKey) + "\n" + string(ssh.MarshalAuthorizedKey(cert))
+}
+
+// ParseSshSigner 解析私钥，私钥之后附带了 OpenSSH 证书时返回使用证书认证的 Signer
+func ParseSshSigner(privateKey, passphrase string) (ssh.Signer, error) {
+	var (
+		signer ssh.Signer
+		err    error
+	)
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey([]byte(privateKey))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, rest := pem.Decode([]byte(privateKey))
+	if block == nil || len(strings.TrimSpace(string(rest))) == 0 {
+		return signer, nil
+	}
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey(rest)
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := publicKey.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New("私钥之后附带的内容不是SSH证书")
+	}
+	return ssh.NewCertSigner(cert, signer)
+}
@@ -1,16 +1,22 @@
 package utils_test
 
 import (
+	"bytes"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net"
 	"testing"
+	"time"
 
 	"next-terminal/server/utils"
 
+	"github.com/glebarez/sqlite"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
 )
 
 func TestTcping(t *testing.T) {
@@ -94,3 +100,269 @@ func TestAesEncryptCBC2(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "3Tbnz0MYHQNTsN2L6QDGCJumbNFsQcmErrRz/KglYI/IDh88lsyOhVi7mgaAs/bjevvJa2F1JT7jUMLsz9/cpw==", base64.StdEncoding.EncodeToString(encryptedCBC))
 }
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := utils.PasswordPolicy{
+		MinLength:        8,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSpecial:   true,
+		BannedPasswords:  []string{"P@ssw0rd"},
+	}
+	assert.Error(t, policy.Validate("alice", "Ab1!"))
+	assert.Error(t, policy.Validate("alice", "abcdef1!"))
+	assert.Error(t, policy.Validate("alice", "ABCDEF1!"))
+	assert.Error(t, policy.Validate("alice", "Abcdefg!"))
+	assert.Error(t, policy.Validate("alice", "Abcdefg1"))
+	assert.Error(t, policy.Validate("alice", "Alice123!"))
+	assert.Error(t, policy.Validate("alice", "p@ssw0rd"))
+	assert.NoError(t, policy.Validate("alice", "Next-Terminal1"))
+
+	for i := 0; i < 10; i++ {
+		password, err := utils.GenPasswordWithPolicy(policy)
+		assert.NoError(t, err)
+		assert.Equal(t, 12, len(password))
+		assert.NoError(t, policy.Validate("", password))
+	}
+	password, err := utils.GenPasswordWithPolicy(utils.PasswordPolicy{MinLength: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, 20, len(password))
+
+	noSpecial := utils.PasswordPolicy{MinLength: 16, RequireSpecial: true, ExcludeSpecial: true}
+	for i := 0; i < 10; i++ {
+		password, err := utils.GenPasswordWithPolicy(noSpecial)
+		assert.NoError(t, err)
+		assert.Equal(t, 16, len(password))
+		assert.Regexp(t, "^[A-Za-z0-9]+$", password)
+	}
+	for i := 0; i < 10; i++ {
+		password, err := utils.GenPasswordWithPolicy(utils.PasswordPolicy{RequireSpecial: true, Specials: "_-"})
+		assert.NoError(t, err)
+		assert.Regexp(t, "^[A-Za-z0-9_-]+$", password)
+		assert.Regexp(t, "[_-]", password)
+	}
+
+	// 特殊字符无法满足策略时返回错误，不能一直重试
+	_, err = utils.GenPasswordWithPolicy(utils.PasswordPolicy{RequireSpecial: true, Specials: "abc"})
+	assert.Error(t, err)
+	_, err = utils.GenPasswordWithPolicy(utils.PasswordPolicy{Specials: "_ "})
+	assert.Error(t, err)
+
+	// 并发生成的密码不能重复
+	passwords := make(chan string, 100)
+	for i := 0; i < 100; i++ {
+		go func() {
+			password, _ := utils.GenPasswordWithPolicy(policy)
+			passwords <- password
+		}()
+	}
+	generated := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		password := <-passwords
+		assert.False(t, generated[password])
+		generated[password] = true
+	}
+}
+
+func TestIpMatch(t *testing.T) {
+	assert.True(t, utils.IpMatch("192.168.1.10", "192.168.1.10"))
+	assert.False(t, utils.IpMatch("192.168.1.10", "192.168.1.11"))
+	assert.True(t, utils.IpMatch("10.0.0.0/8", "10.1.2.3"))
+	assert.False(t, utils.IpMatch("10.0.0.0/8", "11.1.2.3"))
+	assert.True(t, utils.IpMatch("192.168.1.1-192.168.1.100", "192.168.1.50"))
+	assert.False(t, utils.IpMatch("192.168.1.1-192.168.1.100", "192.168.1.101"))
+}
+
+func TestTimePeriod(t *testing.T) {
+	workday := utils.TimePeriod{Weekdays: []int{1, 2, 3, 4, 5}, Start: "08:00", End: "20:00"}
+	assert.NoError(t, workday.Validate())
+	// 2024-01-01 为周一
+	assert.True(t, workday.Contains(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)))
+	assert.False(t, workday.Contains(time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)))
+	assert.False(t, workday.Contains(time.Date(2024, 1, 6, 12, 0, 0, 0, time.UTC)))
+
+	night := utils.TimePeriod{Weekdays: []int{5}, Start: "22:00", End: "06:00"}
+	assert.True(t, night.Contains(time.Date(2024, 1, 5, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, night.Contains(time.Date(2024, 1, 6, 5, 59, 0, 0, time.UTC)))
+	assert.False(t, night.Contains(time.Date(2024, 1, 5, 5, 0, 0, 0, time.UTC)))
+
+	assert.Error(t, utils.TimePeriod{Weekdays: []int{7}, Start: "08:00", End: "20:00"}.Validate())
+	assert.Error(t, utils.TimePeriod{Weekdays: []int{1}, Start: "8点", End: "20:00"}.Validate())
+}
+
+func TestCommandLine(t *testing.T) {
+	input := func(l *utils.CommandLine, s string) (lines []string) {
+		for _, r := range s {
+			if line, enter, _ := l.Input(r); enter {
+				lines = append(lines, line)
+			}
+		}
+		return
+	}
+
+	var l utils.CommandLine
+	assert.Equal(t, []string{"ls -al"}, input(&l, "ls -al\r"))
+	// 退格及方向键编辑
+	assert.Equal(t, []string{"rm -rf /"}, input(&l, "rm -rf /tmpp\x7f\x7f\x7f\x7f\r"))
+	assert.Equal(t, []string{"sudo reboot"}, input(&l, "reboot\x1b[Hsudo \x1b[F\r"))
+	assert.Equal(t, []string{"echo 你好"}, input(&l, "echo 你好\r"))
+	// Ctrl+C 取消当前输入
+	assert.Equal(t, []string{"whoami"}, input(&l, "shutdown\x03whoami\r"))
+	assert.Equal(t, []string{"ab"}, input(&l, "axb\x1b[D\x1b[D\x1b[3~\r"))
+
+	exact := func(l *utils.CommandLine, s string) bool {
+		for _, r := range s {
+			if _, enter, exact := l.Input(r); enter {
+				return exact
+			}
+		}
+		return false
+	}
+	assert.True(t, exact(&l, "ls\x02\x02-\x06\x0c\r"))
+	assert.True(t, exact(&l, "\x1b[200~ls\x1b[201~\r"))
+	// 历史命令、Tab 补全、Ctrl+R、Ctrl+W 及 Alt+B 无法还原
+	assert.False(t, exact(&l, "\x1b[A\r"))
+	assert.False(t, exact(&l, "rm -rf /tm\t\r"))
+	assert.False(t, exact(&l, "\x12reboot\r"))
+	assert.False(t, exact(&l, "ls /tmp\x17/\r"))
+	assert.False(t, exact(&l, "ls /tmp\x1bbsudo \r"))
+	// Ctrl+U 清空后重新输入的命令可以还原
+	assert.True(t, exact(&l, "\x1b[A\x15whoami\r"))
+}
+
+func TestShellOutput(t *testing.T) {
+	var o utils.ShellOutput
+	o.Write("Welcome\r\n\x1b[01;32mroot@web\x1b[00m:\x1b[01;34m/var/log\x1b[00m# ls")
+	assert.Equal(t, "/var/log", o.Cwd())
+
+	o.Write("\r\n[root@db nginx]$ pwd")
+	assert.Equal(t, "nginx", o.Cwd())
+
+	// Shell 集成上报的目录及退出码
+	exitCodes := o.Write("\r\n\x1b]133;D;127\x07\x1b]7;file://db/home/admin\x1b\\$ ")
+	assert.Equal(t, []int{127}, exitCodes)
+	assert.Equal(t, "/home/admin", o.Cwd())
+	assert.False(t, o.PasswordPrompt())
+
+	// 关闭回显的密码输入提示
+	for _, prompt := range []string{
+		"[sudo] password for admin: ",
+		"Enter password: ",
+		"Password:",
+		"Enter passphrase for key '/root/.ssh/id_rsa': ",
+		"请输入密码：",
+	} {
+		o.Write("\r\n" + prompt)
+		assert.True(t, o.PasswordPrompt(), prompt)
+	}
+	o.Write("\r\n$ ls password.txt")
+	assert.False(t, o.PasswordPrompt())
+
+	// 全屏程序切换到备用屏幕
+	assert.False(t, o.AlternateScreen())
+	o.Write("\x1b[?1049h\x1b[22;0;0t\x1b[1;24r\x1b[?12h~\r\n~")
+	assert.True(t, o.AlternateScreen())
+	o.Write("\x1b[?1049l\x1b[23;0;0t\r\n[root@db nginx]$ ")
+	assert.False(t, o.AlternateScreen())
+}
+
+func TestGenerateSshKey(t *testing.T) {
+	for _, c := range []struct {
+		keyType string
+		bits    int
+		algo    string
+	}{
+		{utils.SshKeyEd25519, 0, ssh.KeyAlgoED25519},
+		{utils.SshKeyRSA, 2048, ssh.KeyAlgoRSA},
+		{utils.SshKeyECDSA, 384, ssh.KeyAlgoECDSA384},
+	} {
+		privateKey, publicKey, err := utils.GenerateSshKey(c.keyType, c.bits, "", "next-terminal")
+		assert.NoError(t, err)
+		signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+		assert.NoError(t, err)
+		assert.Equal(t, c.algo, signer.PublicKey().Type())
+		assert.Equal(t, utils.AuthorizedKeyLine(signer.PublicKey(), "next-terminal"), publicKey)
+	}
+
+	privateKey, _, err := utils.GenerateSshKey(utils.SshKeyEd25519, 0, "secret", "")
+	assert.NoError(t, err)
+	_, err = ssh.ParsePrivateKey([]byte(privateKey))
+	assert.Error(t, err)
+	_, err = ssh.ParsePrivateKeyWithPassphrase([]byte(privateKey), []byte("secret"))
+	assert.NoError(t, err)
+
+	_, _, err = utils.GenerateSshKey(utils.SshKeyRSA, 1024, "", "")
+	assert.Error(t, err)
+	_, _, err = utils.GenerateSshKey("dsa", 0, "", "")
+	assert.Error(t, err)
+}
+
+func TestSignUserCertificate(t *testing.T) {
+	caKey, _, err := utils.GenerateSshKey(utils.SshKeyEd25519, 0, "", "")
+	assert.NoError(t, err)
+	ca, err := ssh.ParsePrivateKey([]byte(caKey))
+	assert.NoError(t, err)
+
+	privateKey, _, err := utils.GenerateSshKey(utils.SshKeyEd25519, 0, "", "")
+	assert.NoError(t, err)
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	assert.NoError(t, err)
+
+	now := time.Now()
+	cert, err := utils.SignUserCertificate(ca, signer.PublicKey(), "session-1", []string{"deploy"}, now.Add(-time.Minute), now.Add(time.Hour))
+	assert.NoError(t, err)
+
+	// 私钥之后附带证书时使用证书认证
+	certSigner, err := utils.ParseSshSigner(utils.BundleCertificate(privateKey, cert), "")
+	assert.NoError(t, err)
+	parsed, ok := certSigner.PublicKey().(*ssh.Certificate)
+	assert.True(t, ok)
+	assert.Equal(t, "session-1", parsed.KeyId)
+
+	checker := ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return bytes.Equal(auth.Marshal(), ca.PublicKey().Marshal())
+		},
+	}
+	assert.NoError(t, checker.CheckCert("deploy", parsed))
+	assert.Error(t, checker.CheckCert("root", parsed))
+
+	// 不附带证书时与普通私钥一致
+	plain, err := utils.ParseSshSigner(privateKey, "")
+	assert.NoError(t, err)
+	assert.Equal(t, signer.PublicKey().Marshal(), plain.PublicKey().Marshal())
+}
+
+func TestJsonTimeLocal(t *testing.T) {
+	// 模拟 TZ=Asia/Shanghai
+	local := time.Local
+	time.Local = time.FixedZone("CST", 8*60*60)
+	defer func() { time.Local = local }()
+
+	var j utils.JsonTime
+	assert.NoError(t, json.Unmarshal([]byte(`"2024-01-01 10:00:00"`), &j))
+	assert.True(t, time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC).Equal(j.Time))
+	data, err := json.Marshal(j)
+	assert.NoError(t, err)
+	assert.Equal(t, `"2024-01-01 10:00:00"`, string(data))
+
+	type grant struct {
+		ID         int
+		ValidUntil utils.JsonTime
+	}
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&grant{}))
+
+	now := time.Now()
+	var submitted utils.JsonTime
+	assert.NoError(t, json.Unmarshal([]byte(now.Add(time.Hour).Format(`"2006-01-02 15:04:05"`)), &submitted))
+	assert.NoError(t, db.Create(&grant{ID: 1, ValidUntil: submitted}).Error)
+	assert.NoError(t, db.Create(&grant{ID: 2, ValidUntil: utils.NewJsonTime(now.Add(time.Hour).UTC())}).Error)
+	assert.NoError(t, db.Create(&grant{ID: 3, ValidUntil: utils.NewJsonTime(now.Add(-time.Hour).UTC())}).Error)
+
+	var ids []int
+	assert.NoError(t, db.Model(&grant{}).Where("valid_until > ?", now).Order("id").Pluck("id", &ids).Error)
+	assert.Equal(t, []int{1, 2}, ids)
+}
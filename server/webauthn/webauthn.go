@@ -0,0 +1,142 @@
+package webauthn
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	webauthn_t "github.com/go-webauthn/webauthn/webauthn"
+)
+
+// ErrSignCount 签名计数器没有增长，认证器可能已被复制
+var ErrSignCount = errors.New("webauthn: sign count did not increase")
+
+type Config struct {
+	RPID          string // 一般为不包含端口的域名
+	RPDisplayName string
+	RPOrigins     []string
+}
+
+// Credential 用户已注册的认证器
+type Credential struct {
+	ID              []byte
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	SignCount       uint32
+}
+
+type User struct {
+	ID          string
+	Username    string
+	Nickname    string
+	Credentials []Credential
+}
+
+func (u User) WebAuthnID() []byte {
+	return []byte(u.ID)
+}
+
+func (u User) WebAuthnName() string {
+	return u.Username
+}
+
+func (u User) WebAuthnDisplayName() string {
+	if u.Nickname == "" {
+		return u.Username
+	}
+	return u.Nickname
+}
+
+func (u User) WebAuthnIcon() string {
+	return ""
+}
+
+func (u User) WebAuthnCredentials() []webauthn_t.Credential {
+	credentials := make([]webauthn_t.Credential, 0, len(u.Credentials))
+	for _, credential := range u.Credentials {
+		credentials = append(credentials, webauthn_t.Credential{
+			ID:              credential.ID,
+			PublicKey:       credential.PublicKey,
+			AttestationType: credential.AttestationType,
+			Authenticator: webauthn_t.Authenticator{
+				AAGUID:    credential.AAGUID,
+				SignCount: credential.SignCount,
+			},
+		})
+	}
+	return credentials
+}
+
+// Session 注册或登录过程中需要在服务端保存的数据
+type Session = webauthn_t.SessionData
+
+type RelyingParty struct {
+	webauthn *webauthn_t.WebAuthn
+}
+
+func NewRelyingParty(config Config) (*RelyingParty, error) {
+	w, err := webauthn_t.New(&webauthn_t.Config{
+		RPID:          config.RPID,
+		RPDisplayName: config.RPDisplayName,
+		RPOrigins:     config.RPOrigins,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RelyingParty{webauthn: w}, nil
+}
+
+// BeginRegistration 生成注册认证器所需的参数，已注册的认证器不允许重复注册
+func (r *RelyingParty) BeginRegistration(user User) (*protocol.CredentialCreation, *Session, error) {
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.Credentials))
+	for _, credential := range user.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+	return r.webauthn.BeginRegistration(user, webauthn_t.WithExclusions(exclusions))
+}
+
+func (r *RelyingParty) FinishRegistration(user User, session Session, body io.Reader) (*Credential, error) {
+	response, err := protocol.ParseCredentialCreationResponseBody(body)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := r.webauthn.CreateCredential(user, session, response)
+	if err != nil {
+		return nil, err
+	}
+	return &Credential{
+		ID:              credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+	}, nil
+}
+
+func (r *RelyingParty) BeginLogin(user User) (*protocol.CredentialAssertion, *Session, error) {
+	return r.webauthn.BeginLogin(user)
+}
+
+// FinishLogin 校验认证器的签名，返回的 Credential 中包含最新的签名计数，调用方需要将其保存下来
+func (r *RelyingParty) FinishLogin(user User, session Session, body io.Reader) (*Credential, error) {
+	response, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := r.webauthn.ValidateLogin(user, session, response)
+	if err != nil {
+		return nil, err
+	}
+	if credential.Authenticator.CloneWarning {
+		return nil, ErrSignCount
+	}
+	for _, c := range user.Credentials {
+		if bytes.Equal(c.ID, credential.ID) {
+			c.SignCount = credential.Authenticator.SignCount
+			return &c, nil
+		}
+	}
+	return nil, errors.New("webauthn: credential not found")
+}
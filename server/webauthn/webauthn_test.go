@@ -0,0 +1,171 @@
+package webauthn_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"next-terminal/server/webauthn"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	rpId   = "localhost"
+	origin = "http://localhost:8088"
+)
+
+// softAuthenticator 使用 ECDSA P-256 密钥模拟的认证器，注册时不提供证明（none）
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialId []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	credentialId := make([]byte, 16)
+	_, _ = rand.Read(credentialId)
+	return &softAuthenticator{key: key, credentialId: credentialId}
+}
+
+func (a *softAuthenticator) authData(flags byte, attestedCredentialData []byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(rpId))
+	data := append([]byte{}, rpIdHash[:]...)
+	data = append(data, flags)
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, a.signCount)
+	data = append(data, counter...)
+	return append(data, attestedCredentialData...)
+}
+
+func clientData(t *testing.T, typ, challenge string) []byte {
+	data, err := json.Marshal(map[string]string{
+		"type":      typ,
+		"challenge": challenge,
+		"origin":    origin,
+	})
+	assert.NoError(t, err)
+	return data
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// create 模拟 navigator.credentials.create() 返回的数据
+func (a *softAuthenticator) create(t *testing.T, challenge string) []byte {
+	publicKey, err := webauthncbor.Marshal(map[int]interface{}{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		-3: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	assert.NoError(t, err)
+
+	attested := make([]byte, 16) // AAGUID
+	length := make([]byte, 2)
+	binary.BigEndian.PutUint16(length, uint16(len(a.credentialId)))
+	attested = append(attested, length...)
+	attested = append(attested, a.credentialId...)
+	attested = append(attested, publicKey...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(0x41, attested), // UP | AT
+	})
+	assert.NoError(t, err)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":    encode(a.credentialId),
+		"rawId": encode(a.credentialId),
+		"type":  "public-key",
+		"response": map[string]string{
+			"attestationObject": encode(attestationObject),
+			"clientDataJSON":    encode(clientData(t, "webauthn.create", challenge)),
+		},
+	})
+	assert.NoError(t, err)
+	return body
+}
+
+// get 模拟 navigator.credentials.get() 返回的数据
+func (a *softAuthenticator) get(t *testing.T, challenge string) []byte {
+	authData := a.authData(0x01, nil) // UP
+	clientDataJSON := clientData(t, "webauthn.get", challenge)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	assert.NoError(t, err)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":    encode(a.credentialId),
+		"rawId": encode(a.credentialId),
+		"type":  "public-key",
+		"response": map[string]string{
+			"authenticatorData": encode(authData),
+			"clientDataJSON":    encode(clientDataJSON),
+			"signature":         encode(signature),
+		},
+	})
+	assert.NoError(t, err)
+	return body
+}
+
+func TestRegistrationAndLogin(t *testing.T) {
+	rp, err := webauthn.NewRelyingParty(webauthn.Config{
+		RPID:          rpId,
+		RPDisplayName: "Next Terminal",
+		RPOrigins:     []string{origin},
+	})
+	assert.NoError(t, err)
+
+	authenticator := newSoftAuthenticator(t)
+	user := webauthn.User{ID: "user-1", Username: "alice"}
+
+	_, session, err := rp.BeginRegistration(user)
+	assert.NoError(t, err)
+	credential, err := rp.FinishRegistration(user, *session, bytes.NewReader(authenticator.create(t, session.Challenge)))
+	assert.NoError(t, err)
+	assert.Equal(t, authenticator.credentialId, credential.ID)
+
+	// 使用其他挑战值的响应会被拒绝
+	_, session, err = rp.BeginRegistration(user)
+	assert.NoError(t, err)
+	_, err = rp.FinishRegistration(user, *session, bytes.NewReader(authenticator.create(t, "invalid")))
+	assert.Error(t, err)
+
+	user.Credentials = append(user.Credentials, *credential)
+
+	authenticator.signCount = 1
+	_, session, err = rp.BeginLogin(user)
+	assert.NoError(t, err)
+	credential, err = rp.FinishLogin(user, *session, bytes.NewReader(authenticator.get(t, session.Challenge)))
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), credential.SignCount)
+	user.Credentials[0] = *credential
+
+	// 签名计数没有增长
+	_, session, err = rp.BeginLogin(user)
+	assert.NoError(t, err)
+	_, err = rp.FinishLogin(user, *session, bytes.NewReader(authenticator.get(t, session.Challenge)))
+	assert.ErrorIs(t, err, webauthn.ErrSignCount)
+
+	// 其他用户的认证器
+	other := newSoftAuthenticator(t)
+	other.signCount = 2
+	_, session, err = rp.BeginLogin(user)
+	assert.NoError(t, err)
+	_, err = rp.FinishLogin(user, *session, bytes.NewReader(other.get(t, session.Challenge)))
+	assert.Error(t, err)
+}